@@ -0,0 +1,53 @@
+// Command rotate-token-keys re-wraps every stored Plaid access token under the
+// active key-encryption key (PLAID_TOKEN_ACTIVE_KEY_ID).
+//
+// Rotation without downtime:
+//
+//  1. Add the new key to PLAID_TOKEN_KEYS next to the old one and make it active, then deploy
+//  2. Run this command until it reports 0 rows re-wrapped
+//  3. Remove the old key from PLAID_TOKEN_KEYS and deploy again
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	config "github.com/GalaDe/payments-service/internal/config"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+	repository "github.com/GalaDe/payments-service/internal/storage/postgres"
+)
+
+func main() {
+	batchSize := flag.Int("batch-size", 100, "rows re-wrapped per transaction")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	tokenKeys, err := repository.ParseTokenKeys(cfg.PlaidTokenKeys)
+	if err != nil {
+		log.Fatalf("invalid PLAID_TOKEN_KEYS: %v", err)
+	}
+	tokenKeyring, err := repository.NewTokenKeyring(tokenKeys, cfg.PlaidTokenActiveKeyID)
+	if err != nil {
+		log.Fatalf("failed to load Plaid token keys: %v", err)
+	}
+
+	db, err := repository.NewPostgresDB(&repository.PostgresSecret{DBConnString: cfg.DatabaseURL})
+	if err != nil {
+		log.Fatalf("failed to connect to DB: %v", err)
+	}
+	defer db.Close()
+
+	transactor := repository.NewPostgresTransactor(db, orm.New(db.Pool))
+	rotator := repository.NewTokenKeyRotator(transactor, tokenKeyring)
+
+	rotated, err := rotator.Rotate(context.Background(), int32(*batchSize))
+	if err != nil {
+		log.Fatalf("rotation stopped after %d rows: %v", rotated, err)
+	}
+	log.Printf("Re-wrapped %d Plaid access tokens under key %q", rotated, tokenKeyring.ActiveKeyID())
+}
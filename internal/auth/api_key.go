@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+/*
+	API keys look like psk_<prefix>_<secret>. The prefix is stored in clear to look the key
+	up; the key as a whole is only stored as a SHA-256 hash. The secret has 256 bits of
+	entropy, so a fast hash is enough, unlike for passwords.
+*/
+
+// APIKeyPrefix starts every API key, telling it apart from a JWT in the Authorization header.
+const APIKeyPrefix = "psk_"
+
+// NewAPIKey generates a key. It returns the key, to hand out once, and the prefix and hash to store.
+func NewAPIKey() (key, prefix, hash string, err error) {
+	prefixBytes := make([]byte, 8)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+
+	prefix = hex.EncodeToString(prefixBytes)
+	key = APIKeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefix, HashAPIKey(key), nil
+}
+
+// IsAPIKey reports whether a bearer token is an API key rather than a JWT.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// ParseAPIKey returns the prefix of a well-formed key.
+func ParseAPIKey(key string) (string, error) {
+	prefix, secret, found := strings.Cut(strings.TrimPrefix(key, APIKeyPrefix), "_")
+	if !IsAPIKey(key) || !found || len(prefix) != 16 || secret == "" {
+		return "", fmt.Errorf("%w: malformed API key", ErrInvalidToken)
+	}
+	return prefix, nil
+}
+
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyMatches compares a key with a stored hash in constant time.
+func APIKeyMatches(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKey(key)), []byte(hash)) == 1
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{name: "valid", key: "psk_0123456789abcdef_c2VjcmV0", want: "0123456789abcdef"},
+		{name: "secret with underscores", key: "psk_0123456789abcdef_se_cr_et", want: "0123456789abcdef"},
+		{name: "missing psk_", key: "0123456789abcdef_c2VjcmV0", wantErr: true},
+		{name: "other prefix", key: "sk_0123456789abcdef_c2VjcmV0", wantErr: true},
+		{name: "short prefix", key: "psk_0123456789abcde_c2VjcmV0", wantErr: true},
+		{name: "long prefix", key: "psk_0123456789abcdef0_c2VjcmV0", wantErr: true},
+		{name: "no secret", key: "psk_0123456789abcdef_", wantErr: true},
+		{name: "no separator", key: "psk_0123456789abcdef", wantErr: true},
+		{name: "empty", key: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAPIKey(tt.key)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("ParseAPIKey(%q) error = %v, want %v", tt.key, err, ErrInvalidToken)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("ParseAPIKey(%q) = %q, %v, want %q", tt.key, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyMatches(t *testing.T) {
+	key, prefix, hash, err := NewAPIKey()
+	if err != nil {
+		t.Fatalf("NewAPIKey: %v", err)
+	}
+	if !IsAPIKey(key) {
+		t.Fatalf("IsAPIKey(%q) = false, want true", key)
+	}
+	if got, err := ParseAPIKey(key); err != nil || got != prefix {
+		t.Fatalf("ParseAPIKey(%q) = %q, %v, want %q", key, got, err, prefix)
+	}
+	other, _, _, err := NewAPIKey()
+	if err != nil {
+		t.Fatalf("NewAPIKey: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		hash string
+		want bool
+	}{
+		{name: "same key", key: key, hash: hash, want: true},
+		{name: "other key", key: other, hash: hash},
+		{name: "same prefix, other secret", key: key + "x", hash: hash},
+		{name: "uppercase hash", key: key, hash: strings.ToUpper(hash)},
+		{name: "empty hash", key: key, hash: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := APIKeyMatches(tt.key, tt.hash); got != tt.want {
+				t.Fatalf("APIKeyMatches(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
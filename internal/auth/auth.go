@@ -0,0 +1,150 @@
+/*
+Package auth authenticates API callers. A caller is identified by a Principal, which the
+HTTP middleware stores in the request context after verifying the caller's JWT.
+
+Users authenticate with a JWT, HS256-signed by the identity provider with a secret shared
+with this service. The subject is the user ID that owns payments, Plaid items and Stripe
+payment methods; the space-separated scope claim grants extra permissions, e.g. admin.
+
+Internal backends authenticate with an API key instead, which only has the scopes it was
+created with.
+*/
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Scopes of the API. Users have every scope but admin; API keys only the ones they were created with.
+const (
+	ScopePaymentsRead  = "payments:read"  // read payments, recurring payments, accounts and the ledger
+	ScopePaymentsWrite = "payments:write" // create and change payments, link accounts and payment methods
+	ScopeRefundsWrite  = "refunds:write"  // refund payments
+	ScopeAdmin         = "admin"          // use the admin API and act on every user's resources
+)
+
+// IsKnownScope reports whether scope is one of the API's scopes.
+func IsKnownScope(scope string) bool {
+	switch scope {
+	case ScopePaymentsRead, ScopePaymentsWrite, ScopeRefundsWrite, ScopeAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// ErrInvalidToken is returned for a token that is malformed, badly signed, expired
+// or meant for another service.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID   string   `json:"user_id"` // for API keys, the user the backend acts for, if any
+	Scopes   []string `json:"scopes"`
+	APIKeyID string   `json:"api_key_id,omitempty"` // set when the caller used an API key
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p.HasScope(ScopeAdmin)
+}
+
+// Permits reports whether the principal may use a route that requires scope.
+func (p *Principal) Permits(scope string) bool {
+	if p.IsAdmin() || p.HasScope(scope) {
+		return true
+	}
+	return p.APIKeyID == "" && scope != ScopeAdmin
+}
+
+// Actor identifies the principal in audit fields such as APIKey.CreatedBy.
+func (p *Principal) Actor() string {
+	if p.APIKeyID != "" {
+		return "api_key:" + p.APIKeyID
+	}
+	return p.UserID
+}
+
+// CanAccess reports whether the principal may act on a resource owned by userID.
+func (p *Principal) CanAccess(userID string) bool {
+	return p.IsAdmin() || (p.UserID != "" && p.UserID == userID)
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal stored by the middleware, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+type JWTConfig struct {
+	Secret   string // HS256 signing secret shared with the identity provider
+	Issuer   string // optional: required iss claim
+	Audience string // optional: required aud claim
+}
+
+type JWTVerifier struct {
+	config JWTConfig
+	parser *jwt.Parser
+}
+
+func NewJWTVerifier(config JWTConfig) (*JWTVerifier, error) {
+	if config.Secret == "" {
+		return nil, errors.New("JWT secret is empty")
+	}
+	return &JWTVerifier{
+		config: config,
+		// Only accept the algorithm we sign with, never "none" or a public-key algorithm
+		parser: jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()})),
+	}, nil
+}
+
+type claims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope"`
+}
+
+// Verify checks a token's signature, expiry, issuer and audience and returns its principal.
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	var c claims
+	_, err := v.parser.ParseWithClaims(token, &c, func(*jwt.Token) (interface{}, error) {
+		return []byte(v.config.Secret), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	switch {
+	case c.ExpiresAt == nil:
+		return nil, fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	case c.Subject == "":
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	case v.config.Issuer != "" && !c.VerifyIssuer(v.config.Issuer, true):
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, c.Issuer)
+	case v.config.Audience != "" && !c.VerifyAudience(v.config.Audience, true):
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	return &Principal{
+		UserID: c.Subject,
+		Scopes: strings.Fields(c.Scope),
+	}, nil
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const testSecret = "test-secret"
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, c jwt.MapClaims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(method, c).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+func TestVerify(t *testing.T) {
+	verifier, err := NewJWTVerifier(JWTConfig{Secret: testSecret, Issuer: "identity", Audience: "payments"})
+	if err != nil {
+		t.Fatalf("NewJWTVerifier: %v", err)
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	valid := jwt.MapClaims{"sub": "user-1", "exp": exp, "iss": "identity", "aud": "payments", "scope": "payments:read payments:write"}
+	with := func(key string, value interface{}) jwt.MapClaims {
+		c := jwt.MapClaims{}
+		for k, v := range valid {
+			c[k] = v
+		}
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    *Principal
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), valid),
+			want:  &Principal{UserID: "user-1", Scopes: []string{ScopePaymentsRead, ScopePaymentsWrite}},
+		},
+		{
+			name:  "no scope",
+			token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), with("scope", nil)),
+			want:  &Principal{UserID: "user-1"},
+		},
+		{name: "wrong secret", token: signToken(t, jwt.SigningMethodHS256, []byte("other-secret"), valid), wantErr: true},
+		{name: "hs384", token: signToken(t, jwt.SigningMethodHS384, []byte(testSecret), valid), wantErr: true},
+		{name: "none", token: signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid), wantErr: true},
+		{name: "missing exp", token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), with("exp", nil)), wantErr: true},
+		{name: "expired", token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), with("exp", time.Now().Add(-time.Minute).Unix())), wantErr: true},
+		{name: "missing sub", token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), with("sub", nil)), wantErr: true},
+		{name: "empty sub", token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), with("sub", "")), wantErr: true},
+		{name: "other issuer", token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), with("iss", "someone-else")), wantErr: true},
+		{name: "other audience", token: signToken(t, jwt.SigningMethodHS256, []byte(testSecret), with("aud", "ledger")), wantErr: true},
+		{name: "malformed", token: "not-a-jwt", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.Verify(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Verify error = %v, want %v", err, ErrInvalidToken)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if got.UserID != tt.want.UserID || !slices.Equal(got.Scopes, tt.want.Scopes) || got.APIKeyID != "" {
+				t.Fatalf("Verify = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewJWTVerifierRequiresSecret(t *testing.T) {
+	if _, err := NewJWTVerifier(JWTConfig{}); err == nil {
+		t.Fatal("NewJWTVerifier without a secret succeeded, want an error")
+	}
+}
+
+func TestPermits(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal Principal
+		scope     string
+		want      bool
+	}{
+		{name: "user, read", principal: Principal{UserID: "user-1"}, scope: ScopePaymentsRead, want: true},
+		{name: "user, write", principal: Principal{UserID: "user-1"}, scope: ScopePaymentsWrite, want: true},
+		{name: "user, refunds", principal: Principal{UserID: "user-1"}, scope: ScopeRefundsWrite, want: true},
+		{name: "user, admin", principal: Principal{UserID: "user-1"}, scope: ScopeAdmin},
+		{name: "admin user, admin", principal: Principal{UserID: "user-1", Scopes: []string{ScopeAdmin}}, scope: ScopeAdmin, want: true},
+		{name: "api key with the scope", principal: Principal{APIKeyID: "key-1", Scopes: []string{ScopePaymentsRead}}, scope: ScopePaymentsRead, want: true},
+		{name: "api key without the scope", principal: Principal{APIKeyID: "key-1", Scopes: []string{ScopePaymentsRead}}, scope: ScopePaymentsWrite},
+		{name: "api key without scopes", principal: Principal{APIKeyID: "key-1"}, scope: ScopePaymentsRead},
+		{name: "api key, admin", principal: Principal{APIKeyID: "key-1", Scopes: []string{ScopePaymentsWrite}}, scope: ScopeAdmin},
+		{name: "admin api key, refunds", principal: Principal{APIKeyID: "key-1", Scopes: []string{ScopeAdmin}}, scope: ScopeRefundsWrite, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.Permits(tt.scope); got != tt.want {
+				t.Fatalf("Permits(%s) = %v, want %v", tt.scope, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanAccess(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal Principal
+		owner     string
+		want      bool
+	}{
+		{name: "own resource", principal: Principal{UserID: "user-1"}, owner: "user-1", want: true},
+		{name: "other user's resource", principal: Principal{UserID: "user-1"}, owner: "user-2"},
+		{name: "no user, unowned resource", principal: Principal{APIKeyID: "key-1"}, owner: ""},
+		{name: "api key acting for the owner", principal: Principal{UserID: "user-1", APIKeyID: "key-1"}, owner: "user-1", want: true},
+		{name: "admin, other user's resource", principal: Principal{UserID: "user-1", Scopes: []string{ScopeAdmin}}, owner: "user-2", want: true},
+		{name: "admin acting for nobody", principal: Principal{APIKeyID: "key-1", Scopes: []string{ScopeAdmin}}, owner: "user-2", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.CanAccess(tt.owner); got != tt.want {
+				t.Fatalf("CanAccess(%q) = %v, want %v", tt.owner, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Package calendar models the US banking calendar ACH payments run on: Federal Reserve
+business days, the same-day ACH submission windows, and when a debit is expected to settle.
+
+All dates are Federal Reserve dates, in Eastern time. Times passed in may be in any
+location and results are returned in the caller's location.
+*/
+package calendar
+
+import (
+	"time"
+	_ "time/tzdata" // the service's containers don't ship a zoneinfo database
+)
+
+// Eastern is the time zone of the Federal Reserve's ACH deadlines.
+var Eastern = mustLoadLocation("America/New_York")
+
+// DebitSettlementDays is how many business days after submission Stripe reports an ACH
+// debit as succeeded, once the bank had time to return it.
+const DebitSettlementDays = 4
+
+// The Fed settles standard ACH entries at 8:30 ET on the settlement day.
+var standardSettlement = clock{8, 30}
+
+type clock struct {
+	hour, minute int
+}
+
+func (c clock) on(day time.Time) time.Time {
+	year, month, d := day.In(Eastern).Date()
+	return time.Date(year, month, d, c.hour, c.minute, 0, 0, Eastern)
+}
+
+/*
+Same-day ACH windows, Eastern time:
+
+	window  submission deadline  settlement
+	1       10:30                13:00
+	2       14:45                17:00
+	3       16:45                18:00
+
+Files submitted after the last deadline, or on a non-business day, go into the first
+window of the next business day.
+*/
+var sameDayWindows = []struct {
+	name                 string
+	deadline, settlement clock
+}{
+	{"same_day_1", clock{10, 30}, clock{13, 0}},
+	{"same_day_2", clock{14, 45}, clock{17, 0}},
+	{"same_day_3", clock{16, 45}, clock{18, 0}},
+}
+
+// Window is a same-day ACH window on a specific business day.
+type Window struct {
+	Name       string    `json:"name"`
+	Deadline   time.Time `json:"deadline"`   // last moment to submit into this window
+	Settlement time.Time `json:"settlement"` // when entries of this window settle
+}
+
+// IsBusinessDay reports whether t falls on a Fed business day: a weekday that is not a Fed holiday.
+func IsBusinessDay(t time.Time) bool {
+	et := t.In(Eastern)
+	if et.Weekday() == time.Saturday || et.Weekday() == time.Sunday {
+		return false
+	}
+	_, holiday := Holiday(et)
+	return !holiday
+}
+
+// NextBusinessDay returns t if it falls on a business day, otherwise the same time of
+// day on the next business day.
+func NextBusinessDay(t time.Time) time.Time {
+	et := t.In(Eastern)
+	for !IsBusinessDay(et) {
+		et = et.AddDate(0, 0, 1)
+	}
+	return et.In(t.Location())
+}
+
+// AddBusinessDays moves t forward by n business days, keeping the time of day.
+// A t on a non-business day is counted from the next business day.
+func AddBusinessDays(t time.Time, n int) time.Time {
+	et := NextBusinessDay(t).In(Eastern)
+	for ; n > 0; n-- {
+		et = NextBusinessDay(et.AddDate(0, 0, 1))
+	}
+	return et.In(t.Location())
+}
+
+// NextWindow returns the first same-day ACH window an entry submitted at t makes.
+func NextWindow(t time.Time) Window {
+	day := t
+	if IsBusinessDay(day) {
+		for _, w := range sameDayWindows {
+			if deadline := w.deadline.on(day); !t.After(deadline) {
+				return window(w.name, deadline, w.settlement.on(day), t.Location())
+			}
+		}
+	}
+
+	day = NextBusinessDay(day.In(Eastern).AddDate(0, 0, 1))
+	first := sameDayWindows[0]
+	return window(first.name, first.deadline.on(day), first.settlement.on(day), t.Location())
+}
+
+func window(name string, deadline, settlement time.Time, loc *time.Location) Window {
+	return Window{Name: name, Deadline: deadline.In(loc), Settlement: settlement.In(loc)}
+}
+
+// SubmissionDay returns the business day an entry submitted at t is processed on: t's
+// own day before the last same-day deadline, otherwise the next business day.
+func SubmissionDay(t time.Time) time.Time {
+	return NextWindow(t).Deadline
+}
+
+// ExpectedSettlement returns when an ACH debit submitted at t is expected to settle:
+// DebitSettlementDays business days after its submission day, at the Fed's standard
+// settlement time.
+func ExpectedSettlement(t time.Time) time.Time {
+	day := AddBusinessDays(SubmissionDay(t), DebitSettlementDays)
+	return standardSettlement.on(day).In(t.Location())
+}
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
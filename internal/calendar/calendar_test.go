@@ -0,0 +1,175 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func eastern(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, Eastern)
+}
+
+func TestIsBusinessDay(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{name: "monday", t: date(2025, time.March, 10), want: true},
+		{name: "friday", t: date(2025, time.March, 14), want: true},
+		{name: "saturday", t: date(2025, time.March, 15)},
+		{name: "sunday", t: date(2025, time.March, 16)},
+		{name: "holiday", t: date(2025, time.January, 20)},
+		{name: "sunday holiday observed monday", t: date(2023, time.January, 2)},
+		{name: "saturday holiday", t: date(2026, time.July, 4)},
+		{name: "friday before saturday holiday", t: date(2026, time.July, 3), want: true},
+		{name: "friday before saturday new year's day", t: date(2021, time.December, 31), want: true},
+		{name: "saturday morning in utc, friday in eastern", t: time.Date(2025, time.March, 15, 3, 0, 0, 0, time.UTC), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBusinessDay(tt.t); got != tt.want {
+				t.Fatalf("IsBusinessDay(%s) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextBusinessDay(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{name: "business day is kept", t: eastern(2025, time.March, 12, 9, 15), want: eastern(2025, time.March, 12, 9, 15)},
+		{name: "saturday moves to monday", t: eastern(2025, time.March, 15, 9, 15), want: eastern(2025, time.March, 17, 9, 15)},
+		{name: "weekend before a monday holiday", t: eastern(2025, time.January, 18, 9, 15), want: eastern(2025, time.January, 21, 9, 15)},
+		{name: "new year's day", t: eastern(2026, time.January, 1, 23, 0), want: eastern(2026, time.January, 2, 23, 0)},
+		{name: "observed new year's day after a weekend", t: eastern(2022, time.December, 31, 9, 15), want: eastern(2023, time.January, 3, 9, 15)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextBusinessDay(tt.t); !got.Equal(tt.want) {
+				t.Fatalf("NextBusinessDay(%s) = %s, want %s", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddBusinessDays(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		n    int
+		want time.Time
+	}{
+		{name: "zero days", t: eastern(2025, time.March, 10, 9, 0), n: 0, want: eastern(2025, time.March, 10, 9, 0)},
+		{name: "within the week", t: eastern(2025, time.March, 10, 9, 0), n: 4, want: eastern(2025, time.March, 14, 9, 0)},
+		{name: "over a weekend", t: eastern(2025, time.March, 13, 9, 0), n: 2, want: eastern(2025, time.March, 17, 9, 0)},
+		{name: "from a saturday", t: eastern(2025, time.March, 15, 9, 0), n: 1, want: eastern(2025, time.March, 18, 9, 0)},
+		{name: "over thanksgiving", t: eastern(2025, time.November, 26, 9, 0), n: 1, want: eastern(2025, time.November, 28, 9, 0)},
+		{name: "over christmas", t: eastern(2025, time.December, 24, 9, 0), n: 2, want: eastern(2025, time.December, 29, 9, 0)},
+		{name: "into the next year", t: eastern(2025, time.December, 31, 9, 0), n: 1, want: eastern(2026, time.January, 2, 9, 0)},
+		{name: "into the next year, observed new year's day", t: eastern(2022, time.December, 30, 9, 0), n: 1, want: eastern(2023, time.January, 3, 9, 0)},
+		{name: "keeps the caller's location", t: time.Date(2025, time.March, 14, 14, 0, 0, 0, time.UTC), n: 1, want: time.Date(2025, time.March, 17, 14, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AddBusinessDays(tt.t, tt.n)
+			if !got.Equal(tt.want) || got.Location() != tt.want.Location() {
+				t.Fatalf("AddBusinessDays(%s, %d) = %s, want %s", tt.t, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextWindow(t *testing.T) {
+	tests := []struct {
+		name       string
+		t          time.Time
+		want       string
+		deadline   time.Time
+		settlement time.Time
+	}{
+		{
+			name: "morning", t: eastern(2025, time.March, 10, 9, 0),
+			want: "same_day_1", deadline: eastern(2025, time.March, 10, 10, 30), settlement: eastern(2025, time.March, 10, 13, 0),
+		},
+		{
+			name: "at the first deadline", t: eastern(2025, time.March, 10, 10, 30),
+			want: "same_day_1", deadline: eastern(2025, time.March, 10, 10, 30), settlement: eastern(2025, time.March, 10, 13, 0),
+		},
+		{
+			name: "just after the first deadline", t: eastern(2025, time.March, 10, 10, 30).Add(time.Second),
+			want: "same_day_2", deadline: eastern(2025, time.March, 10, 14, 45), settlement: eastern(2025, time.March, 10, 17, 0),
+		},
+		{
+			name: "at the last deadline", t: eastern(2025, time.March, 10, 16, 45),
+			want: "same_day_3", deadline: eastern(2025, time.March, 10, 16, 45), settlement: eastern(2025, time.March, 10, 18, 0),
+		},
+		{
+			name: "after the last deadline", t: eastern(2025, time.March, 10, 16, 46),
+			want: "same_day_1", deadline: eastern(2025, time.March, 11, 10, 30), settlement: eastern(2025, time.March, 11, 13, 0),
+		},
+		{
+			name: "friday evening", t: eastern(2025, time.March, 14, 18, 0),
+			want: "same_day_1", deadline: eastern(2025, time.March, 17, 10, 30), settlement: eastern(2025, time.March, 17, 13, 0),
+		},
+		{
+			name: "saturday morning", t: eastern(2025, time.March, 15, 9, 0),
+			want: "same_day_1", deadline: eastern(2025, time.March, 17, 10, 30), settlement: eastern(2025, time.March, 17, 13, 0),
+		},
+		{
+			name: "holiday morning", t: eastern(2025, time.January, 20, 9, 0),
+			want: "same_day_1", deadline: eastern(2025, time.January, 21, 10, 30), settlement: eastern(2025, time.January, 21, 13, 0),
+		},
+		{
+			name: "new year's eve after the last deadline", t: eastern(2025, time.December, 31, 17, 0),
+			want: "same_day_1", deadline: eastern(2026, time.January, 2, 10, 30), settlement: eastern(2026, time.January, 2, 13, 0),
+		},
+		{
+			// 15:20 UTC is 10:20 EST, before the first deadline at 15:30 UTC
+			name: "utc in winter", t: time.Date(2025, time.January, 6, 15, 20, 0, 0, time.UTC),
+			want: "same_day_1", deadline: time.Date(2025, time.January, 6, 15, 30, 0, 0, time.UTC), settlement: time.Date(2025, time.January, 6, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			// 15:20 UTC is 11:20 EDT, past the first deadline at 14:30 UTC
+			name: "utc in summer", t: time.Date(2025, time.July, 7, 15, 20, 0, 0, time.UTC),
+			want: "same_day_2", deadline: time.Date(2025, time.July, 7, 18, 45, 0, 0, time.UTC), settlement: time.Date(2025, time.July, 7, 21, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NextWindow(tt.t)
+			if got.Name != tt.want || !got.Deadline.Equal(tt.deadline) || !got.Settlement.Equal(tt.settlement) {
+				t.Fatalf("NextWindow(%s) = %s %s %s, want %s %s %s",
+					tt.t, got.Name, got.Deadline, got.Settlement, tt.want, tt.deadline, tt.settlement)
+			}
+			if got.Deadline.Location() != tt.t.Location() {
+				t.Fatalf("NextWindow(%s) deadline in %s, want %s", tt.t, got.Deadline.Location(), tt.t.Location())
+			}
+		})
+	}
+}
+
+func TestExpectedSettlement(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{name: "before the cutoff", t: eastern(2025, time.March, 10, 9, 0), want: eastern(2025, time.March, 14, 8, 30)},
+		{name: "at the cutoff", t: eastern(2025, time.March, 10, 16, 45), want: eastern(2025, time.March, 14, 8, 30)},
+		{name: "after the cutoff", t: eastern(2025, time.March, 10, 16, 46), want: eastern(2025, time.March, 17, 8, 30)},
+		{name: "on a weekend", t: eastern(2025, time.March, 15, 9, 0), want: eastern(2025, time.March, 21, 8, 30)},
+		{name: "over thanksgiving", t: eastern(2025, time.November, 24, 9, 0), want: eastern(2025, time.December, 1, 8, 30)},
+		{name: "into the next year", t: eastern(2025, time.December, 29, 17, 0), want: eastern(2026, time.January, 6, 8, 30)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExpectedSettlement(tt.t); !got.Equal(tt.want) {
+				t.Fatalf("ExpectedSettlement(%s) = %s, want %s", tt.t, got, tt.want)
+			}
+		})
+	}
+}
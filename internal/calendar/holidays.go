@@ -0,0 +1,76 @@
+package calendar
+
+import "time"
+
+/*
+Federal Reserve holidays. The Fed observes a holiday falling on a Sunday on the
+following Monday; one falling on a Saturday is not moved, the Fed is open the Friday before.
+
+	New Year's Day            January 1
+	Martin Luther King Jr.    third Monday in January
+	Presidents Day            third Monday in February
+	Memorial Day              last Monday in May
+	Juneteenth                June 19
+	Independence Day          July 4
+	Labor Day                 first Monday in September
+	Columbus Day              second Monday in October
+	Veterans Day              November 11
+	Thanksgiving Day          fourth Thursday in November
+	Christmas Day             December 25
+*/
+
+type holiday struct {
+	name  string
+	month time.Month
+	day   int
+}
+
+func fedHolidays(year int) []holiday {
+	return []holiday{
+		fixed("New Year's Day", year, time.January, 1),
+		nthWeekday("Martin Luther King Jr. Day", year, time.January, time.Monday, 3),
+		nthWeekday("Presidents Day", year, time.February, time.Monday, 3),
+		lastWeekday("Memorial Day", year, time.May, time.Monday),
+		fixed("Juneteenth", year, time.June, 19),
+		fixed("Independence Day", year, time.July, 4),
+		nthWeekday("Labor Day", year, time.September, time.Monday, 1),
+		nthWeekday("Columbus Day", year, time.October, time.Monday, 2),
+		fixed("Veterans Day", year, time.November, 11),
+		nthWeekday("Thanksgiving Day", year, time.November, time.Thursday, 4),
+		fixed("Christmas Day", year, time.December, 25),
+	}
+}
+
+// Holiday returns the name of the Fed holiday observed on t's date in Eastern time.
+func Holiday(t time.Time) (string, bool) {
+	year, month, day := t.In(Eastern).Date()
+	for _, h := range fedHolidays(year) {
+		if h.month == month && h.day == day {
+			return h.name, true
+		}
+	}
+	return "", false
+}
+
+// fixed is a holiday on a fixed date, observed on Monday when it falls on a Sunday.
+func fixed(name string, year int, month time.Month, day int) holiday {
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if date.Weekday() == time.Sunday {
+		date = date.AddDate(0, 0, 1)
+	}
+	return holiday{name: name, month: date.Month(), day: date.Day()}
+}
+
+// nthWeekday is a holiday on the nth weekday of the month, e.g. the third Monday.
+func nthWeekday(name string, year int, month time.Month, weekday time.Weekday, n int) holiday {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return holiday{name: name, month: month, day: 1 + offset + 7*(n-1)}
+}
+
+// lastWeekday is a holiday on the last weekday of the month, e.g. the last Monday.
+func lastWeekday(name string, year int, month time.Month, weekday time.Weekday) holiday {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return holiday{name: name, month: month, day: last.Day() - offset}
+}
@@ -0,0 +1,56 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 12, 0, 0, 0, Eastern)
+}
+
+func TestHoliday(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want string // empty when t is not a Fed holiday
+	}{
+		{name: "new year's day", t: date(2025, time.January, 1), want: "New Year's Day"},
+		{name: "martin luther king jr. day", t: date(2025, time.January, 20), want: "Martin Luther King Jr. Day"},
+		{name: "presidents day", t: date(2025, time.February, 17), want: "Presidents Day"},
+		{name: "memorial day", t: date(2025, time.May, 26), want: "Memorial Day"},
+		{name: "memorial day, 31-day may", t: date(2021, time.May, 31), want: "Memorial Day"},
+		{name: "juneteenth", t: date(2025, time.June, 19), want: "Juneteenth"},
+		{name: "independence day", t: date(2025, time.July, 4), want: "Independence Day"},
+		{name: "labor day", t: date(2025, time.September, 1), want: "Labor Day"},
+		{name: "columbus day", t: date(2025, time.October, 13), want: "Columbus Day"},
+		{name: "veterans day", t: date(2025, time.November, 11), want: "Veterans Day"},
+		{name: "thanksgiving day", t: date(2025, time.November, 27), want: "Thanksgiving Day"},
+		{name: "christmas day", t: date(2025, time.December, 25), want: "Christmas Day"},
+		{name: "day after thanksgiving", t: date(2025, time.November, 28)},
+		{name: "christmas eve", t: date(2025, time.December, 24)},
+
+		// A holiday on a Sunday is observed the Monday after
+		{name: "sunday new year's day observed monday", t: date(2023, time.January, 2), want: "New Year's Day"},
+		{name: "sunday new year's day itself", t: date(2023, time.January, 1)},
+		{name: "sunday juneteenth observed monday", t: date(2022, time.June, 20), want: "Juneteenth"},
+		{name: "sunday christmas observed monday", t: date(2022, time.December, 26), want: "Christmas Day"},
+
+		// A holiday on a Saturday stays there: the Fed is open the Friday before
+		{name: "saturday independence day", t: date(2026, time.July, 4), want: "Independence Day"},
+		{name: "friday before saturday independence day", t: date(2026, time.July, 3)},
+		{name: "friday before saturday veterans day", t: date(2023, time.November, 10)},
+
+		// The date is taken in Eastern time, whatever location t is in
+		{name: "july 4 in utc, july 3 in eastern", t: time.Date(2025, time.July, 4, 2, 0, 0, 0, time.UTC)},
+		{name: "july 3 in pacific, july 4 in eastern", t: time.Date(2025, time.July, 3, 22, 0, 0, 0, mustLoadLocation("America/Los_Angeles")), want: "Independence Day"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Holiday(tt.t)
+			if got != tt.want || ok != (tt.want != "") {
+				t.Fatalf("Holiday(%s) = %q, %v; want %q", tt.t, got, ok, tt.want)
+			}
+		})
+	}
+}
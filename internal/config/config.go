@@ -3,18 +3,36 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds all configuration for the application.
 type Config struct {
-	Port             string
-	DatabaseURL      string
-	StripeAPIKey     string
-	PlaidClientID    string
-	PlaidSecret      string
-	PlaidEnv         string
+	Port                   string
+	DatabaseURL            string
+	StripeAPIKey           string
+	StripeWebhookKey       string
+	StripeWebhookTolerance time.Duration
+	// How long a payment workflow waits for an ACH settlement webhook before polling Stripe
+	PaymentSettlementTimeout time.Duration
+	// What to do when the funding account's balance can't cover a payment: off, fail or review
+	PaymentBalanceCheck string
+	// Cents required on top of the payment amount for the balance check to pass
+	PaymentBalanceBuffer int64
+	PlaidClientID        string
+	PlaidSecret          string
+	PlaidEnv             string
+	// Key-encryption keys for Plaid access tokens, "id:base64key,..." with 32-byte keys.
+	// During a rotation it lists both the old and the new key.
+	PlaidTokenKeys        string
+	PlaidTokenActiveKeyID string
+	// HS256 secret API access tokens are signed with, and the iss/aud they must carry if set
+	JWTSecret        string
+	JWTIssuer        string
+	JWTAudience      string
 	TemporalHostPort string
 	LogLevel         string
 }
@@ -25,14 +43,24 @@ func Load() (*Config, error) {
 	_ = godotenv.Load()
 
 	cfg := &Config{
-		Port:             getEnv("PORT", "8080"),
-		DatabaseURL:      mustEnv("DATABASE_URL"),
-		StripeAPIKey:     mustEnv("STRIPE_API_KEY"),
-		PlaidClientID:    mustEnv("PLAID_CLIENT_ID"),
-		PlaidSecret:      mustEnv("PLAID_SECRET"),
-		PlaidEnv:         getEnv("PLAID_ENV", "sandbox"), // sandbox | development | production
-		TemporalHostPort: getEnv("TEMPORAL_HOST_PORT", "localhost:7233"),
-		LogLevel:         getEnv("LOG_LEVEL", "info"),
+		Port:                     getEnv("PORT", "8080"),
+		DatabaseURL:              mustEnv("DATABASE_URL"),
+		StripeAPIKey:             mustEnv("STRIPE_API_KEY"),
+		StripeWebhookKey:         mustEnv("STRIPE_WEBHOOK_SECRET"),
+		StripeWebhookTolerance:   getDurationEnv("STRIPE_WEBHOOK_TOLERANCE", 5*time.Minute),
+		PaymentSettlementTimeout: getDurationEnv("PAYMENT_SETTLEMENT_TIMEOUT", 7*24*time.Hour),
+		PaymentBalanceCheck:      getEnv("PAYMENT_BALANCE_CHECK", "off"), // off | fail | review
+		PaymentBalanceBuffer:     getInt64Env("PAYMENT_BALANCE_BUFFER", 0),
+		PlaidClientID:            mustEnv("PLAID_CLIENT_ID"),
+		PlaidSecret:              mustEnv("PLAID_SECRET"),
+		PlaidEnv:                 getEnv("PLAID_ENV", "sandbox"), // sandbox | development | production
+		PlaidTokenKeys:           mustEnv("PLAID_TOKEN_KEYS"),
+		PlaidTokenActiveKeyID:    mustEnv("PLAID_TOKEN_ACTIVE_KEY_ID"),
+		JWTSecret:                mustEnv("JWT_SECRET"),
+		JWTIssuer:                getEnv("JWT_ISSUER", ""),
+		JWTAudience:              getEnv("JWT_AUDIENCE", ""),
+		TemporalHostPort:         getEnv("TEMPORAL_HOST_PORT", "localhost:7233"),
+		LogLevel:                 getEnv("LOG_LEVEL", "info"),
 	}
 
 	return cfg, nil
@@ -55,3 +83,29 @@ func getEnv(key, defaultVal string) string {
 	}
 	return val
 }
+
+// getDurationEnv parses the env var as a time.Duration (e.g. "5m") or returns default if unset.
+func getDurationEnv(key string, defaultVal time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultVal
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		panic(fmt.Sprintf("invalid duration for environment variable %s: %v", key, err))
+	}
+	return d
+}
+
+// getInt64Env parses the env var as an int64 or returns default if unset.
+func getInt64Env(key string, defaultVal int64) int64 {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultVal
+	}
+	n, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("invalid integer for environment variable %s: %v", key, err))
+	}
+	return n
+}
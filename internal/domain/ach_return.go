@@ -0,0 +1,87 @@
+package domain
+
+import "time"
+
+// MaxACHRetryAttempts caps how many times a debit is re-presented after a retryable
+// return. NACHA allows at most two re-presentments of a returned entry.
+const MaxACHRetryAttempts = 2
+
+const (
+	ACHReturnActionPending               = "pending"
+	ACHReturnActionRetryScheduled        = "retry_scheduled"
+	ACHReturnActionRetryLimitReached     = "retry_limit_reached"
+	ACHReturnActionPaymentMethodDisabled = "payment_method_disabled"
+	ACHReturnActionNone                  = "none"
+)
+
+// ACHReturnCode describes a NACHA return reason code.
+type ACHReturnCode struct {
+	Code      string `json:"code"`      // e.g. R01
+	Reason    string `json:"reason"`    // NACHA description
+	Retryable bool   `json:"retryable"` // the same debit may succeed if presented again later
+	// DisablesPaymentMethod is set when the bank account can no longer be debited
+	// (closed, unknown, or the customer revoked authorization).
+	DisablesPaymentMethod bool `json:"disables_payment_method"`
+}
+
+var achReturnCodes = map[string]ACHReturnCode{
+	"R01": {Code: "R01", Reason: "Insufficient funds", Retryable: true},
+	"R02": {Code: "R02", Reason: "Account closed", DisablesPaymentMethod: true},
+	"R03": {Code: "R03", Reason: "No account / unable to locate account", DisablesPaymentMethod: true},
+	"R04": {Code: "R04", Reason: "Invalid account number", DisablesPaymentMethod: true},
+	"R05": {Code: "R05", Reason: "Unauthorized debit to consumer account", DisablesPaymentMethod: true},
+	"R07": {Code: "R07", Reason: "Authorization revoked by customer", DisablesPaymentMethod: true},
+	"R08": {Code: "R08", Reason: "Payment stopped"},
+	"R09": {Code: "R09", Reason: "Uncollected funds", Retryable: true},
+	"R10": {Code: "R10", Reason: "Customer advises not authorized", DisablesPaymentMethod: true},
+	"R16": {Code: "R16", Reason: "Account frozen", DisablesPaymentMethod: true},
+	"R20": {Code: "R20", Reason: "Non-transaction account", DisablesPaymentMethod: true},
+	"R29": {Code: "R29", Reason: "Corporate customer advises not authorized", DisablesPaymentMethod: true},
+}
+
+// Stripe reports ACH returns as failure codes on the charge rather than raw NACHA codes.
+var stripeACHFailureCodes = map[string]string{
+	"insufficient_funds":             "R01",
+	"account_closed":                 "R02",
+	"no_account":                     "R03",
+	"invalid_account_number":         "R04",
+	"debit_not_authorized":           "R10",
+	"bank_account_restricted":        "R16",
+	"account_frozen":                 "R16",
+	"bank_account_unusable":          "R20",
+	"authorization_revoked":          "R07",
+	"payment_stopped":                "R08",
+	"uncollected_funds":              "R09",
+	"corporate_debit_not_authorized": "R29",
+}
+
+// LookupACHReturnCode returns the NACHA return code with the given code, e.g. R01.
+func LookupACHReturnCode(code string) (ACHReturnCode, bool) {
+	c, ok := achReturnCodes[code]
+	return c, ok
+}
+
+// ACHReturnFromStripe maps a Stripe charge failure_code to its NACHA return code.
+// Unknown failure codes are treated as non-retryable without disabling the payment method.
+func ACHReturnFromStripe(failureCode string) (ACHReturnCode, bool) {
+	code, ok := stripeACHFailureCodes[failureCode]
+	if !ok {
+		return ACHReturnCode{Reason: failureCode}, false
+	}
+	return achReturnCodes[code], true
+}
+
+type ACHReturn struct {
+	ID                string    `json:"id"`
+	PaymentID         string    `json:"payment_id"`
+	StripeChargeID    string    `json:"stripe_charge_id"`
+	StripeEventID     string    `json:"stripe_event_id"`
+	ReturnCode        string    `json:"return_code"` // NACHA code, empty if Stripe's failure code is unknown
+	Reason            string    `json:"reason"`
+	StripeFailureCode string    `json:"stripe_failure_code"`
+	Retryable         bool      `json:"retryable"`
+	Action            string    `json:"action"`           // what we did about the return
+	RetryPaymentID    string    `json:"retry_payment_id"` // payment created to re-present the debit
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// APIKey is a credential of an internal backend. Only a hash of the key is stored; the
+// key itself is returned once, when it's created.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`   // the backend using the key
+	Prefix     string     `json:"prefix"` // public part of the key, identifies it in logs
+	SecretHash string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  string     `json:"created_by"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Balance check modes, set with PAYMENT_BALANCE_CHECK. They decide what the payment
+// workflow does when the funding account can't cover a payment.
+const (
+	BalanceCheckModeOff    = "off"    // don't check balances
+	BalanceCheckModeFail   = "fail"   // fail the payment
+	BalanceCheckModeReview = "review" // hold the payment until it is approved or rejected
+)
+
+const (
+	BalanceCheckPassed       = "passed"
+	BalanceCheckInsufficient = "insufficient"
+	BalanceCheckUnsupported  = "unsupported" // the institution doesn't offer real-time balances
+	BalanceCheckUnavailable  = "unavailable" // Plaid couldn't be reached, the payment went ahead unchecked
+)
+
+// BalanceCheckPolicy configures the pre-charge balance check.
+type BalanceCheckPolicy struct {
+	Mode   string
+	Buffer int64 // in cents, required on top of the payment amount
+}
+
+func (p BalanceCheckPolicy) Validate() error {
+	switch p.Mode {
+	case BalanceCheckModeOff, BalanceCheckModeFail, BalanceCheckModeReview:
+	default:
+		return fmt.Errorf("unknown balance check mode %q", p.Mode)
+	}
+	if p.Buffer < 0 {
+		return fmt.Errorf("balance check buffer must not be negative, got %d", p.Buffer)
+	}
+	return nil
+}
+
+// BalanceCheck is the balance snapshot taken before a payment was charged.
+type BalanceCheck struct {
+	Result    string    `json:"result"`
+	Available int64     `json:"available"` // in cents, as reported by Plaid
+	Required  int64     `json:"required"`  // payment amount plus the configured buffer
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// PaymentReviewSignal releases or rejects a payment held for review.
+const PaymentReviewSignal = "payment-review"
+
+type PaymentReviewDecision struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason"`
+}
@@ -0,0 +1,107 @@
+package domain
+
+import "time"
+
+// Dispute statuses, as reported by Stripe. warning_* statuses belong to inquiries,
+// which don't withdraw funds and may still turn into a dispute.
+const (
+	DisputeStatusWarningNeedsResponse = "warning_needs_response"
+	DisputeStatusWarningUnderReview   = "warning_under_review"
+	DisputeStatusWarningClosed        = "warning_closed"
+	DisputeStatusNeedsResponse        = "needs_response"
+	DisputeStatusUnderReview          = "under_review"
+	DisputeStatusWon                  = "won"
+	DisputeStatusLost                 = "lost"
+)
+
+type Dispute struct {
+	ID                  string     `json:"id"`
+	PaymentID           string     `json:"payment_id"`
+	StripeDisputeID     string     `json:"stripe_dispute_id"`
+	StripeChargeID      string     `json:"stripe_charge_id"`
+	Amount              int64      `json:"amount"` // in cents
+	Currency            string     `json:"currency"`
+	Reason              string     `json:"reason"` // Stripe reason, e.g. fraudulent, product_not_received
+	Status              string     `json:"status"`
+	EvidenceDueBy       *time.Time `json:"evidence_due_by"` // nil when the bank doesn't accept a response
+	EvidenceSubmittedAt *time.Time `json:"evidence_submitted_at"`
+	LastAlertAt         *time.Time `json:"last_alert_at"` // last evidence deadline alert raised
+	StripeEventID       string     `json:"stripe_event_id"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// IsClosed reports whether the dispute was decided and no longer changes.
+func (d *Dispute) IsClosed() bool {
+	switch d.Status {
+	case DisputeStatusWon, DisputeStatusLost, DisputeStatusWarningClosed:
+		return true
+	default:
+		return false
+	}
+}
+
+// FundsWithdrawn reports whether Stripe took the disputed amount out of the balance,
+// which is the case for every open dispute except inquiries.
+func (d *Dispute) FundsWithdrawn() bool {
+	return d.Status == DisputeStatusNeedsResponse || d.Status == DisputeStatusUnderReview
+}
+
+// AwaitingEvidence reports whether evidence can still be submitted and nobody has yet.
+func (d *Dispute) AwaitingEvidence() bool {
+	if d.EvidenceDueBy == nil || d.EvidenceSubmittedAt != nil {
+		return false
+	}
+	return d.Status == DisputeStatusNeedsResponse || d.Status == DisputeStatusWarningNeedsResponse
+}
+
+/*
+NextEvidenceAlert returns when to alert next about a dispute's evidence deadline. An alert
+is due lead before the deadline for every lead; alerts at or before lastAlert were already
+raised. It reports false once no alert is left.
+*/
+func (d *Dispute) NextEvidenceAlert(leads []time.Duration) (time.Time, bool) {
+	if !d.AwaitingEvidence() {
+		return time.Time{}, false
+	}
+
+	var next time.Time
+	for _, lead := range leads {
+		at := d.EvidenceDueBy.Add(-lead)
+		if d.LastAlertAt != nil && !at.After(*d.LastAlertAt) {
+			continue
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// DisputeEvidence is the response to a dispute sent to Stripe. File fields hold the IDs
+// of files already uploaded to Stripe (file_...).
+type DisputeEvidence struct {
+	UncategorizedText        string `json:"uncategorized_text"`
+	ProductDescription       string `json:"product_description"`
+	CustomerName             string `json:"customer_name"`
+	CustomerEmailAddress     string `json:"customer_email_address"`
+	ServiceDate              string `json:"service_date"`
+	RefundRefusalExplanation string `json:"refund_refusal_explanation"`
+	UncategorizedFile        string `json:"uncategorized_file"`
+	Receipt                  string `json:"receipt"`
+	CustomerCommunication    string `json:"customer_communication"`
+	ServiceDocumentation     string `json:"service_documentation"`
+	// Submit sends the evidence to the bank now; otherwise Stripe only stages it
+	Submit bool `json:"submit"`
+}
+
+// Files returns the file references of the evidence that are set.
+func (e *DisputeEvidence) Files() []string {
+	var files []string
+	for _, f := range []string{e.UncategorizedFile, e.Receipt, e.CustomerCommunication, e.ServiceDocumentation} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
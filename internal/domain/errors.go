@@ -0,0 +1,99 @@
+package domain
+
+import "errors"
+
+// ErrorKind classifies an error for the API caller; the HTTP layer maps each kind to one status.
+type ErrorKind string
+
+const (
+	KindNotFound            ErrorKind = "not_found"            // the resource doesn't exist or belongs to someone else
+	KindConflict            ErrorKind = "conflict"             // the resource's state doesn't allow the request
+	KindValidation          ErrorKind = "validation"           // the request itself is invalid
+	KindUnprocessable       ErrorKind = "unprocessable"        // the request is valid but can't be processed as sent
+	KindProviderDeclined    ErrorKind = "provider_declined"    // Stripe or Plaid refused the request
+	KindProviderUnavailable ErrorKind = "provider_unavailable" // Stripe or Plaid failed, retrying later may work
+	KindInternal            ErrorKind = "internal"             // a bug on our side, the caller can't fix it
+)
+
+/*
+Error is an error the API can explain to its caller. Code is stable, clients may switch on it;
+Message is safe to show. Err is the underlying cause, e.g. the Stripe error, and is only logged.
+*/
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NotFound(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func Validation(code, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+func Unprocessable(code, message string) *Error {
+	return &Error{Kind: KindUnprocessable, Code: code, Message: message}
+}
+
+func ProviderDeclined(code, message string, err error) *Error {
+	return &Error{Kind: KindProviderDeclined, Code: code, Message: message, Err: err}
+}
+
+func ProviderUnavailable(code, message string, err error) *Error {
+	return &Error{Kind: KindProviderUnavailable, Code: code, Message: message, Err: err}
+}
+
+func Internal(code, message string) *Error {
+	return &Error{Kind: KindInternal, Code: code, Message: message}
+}
+
+// AsError returns the first *Error in err's chain.
+func AsError(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+var (
+	// ErrPaymentNotFound is returned for a payment that doesn't exist or belongs to another user.
+	ErrPaymentNotFound = NotFound("payment_not_found", "payment not found")
+	// ErrPaymentNotRefundable is returned when a payment is not in a state that allows refunds.
+	ErrPaymentNotRefundable = Conflict("payment_not_refundable", "payment is not refundable")
+	// ErrRefundExceedsRemaining is returned when a refund is larger than the amount left to refund.
+	ErrRefundExceedsRemaining = Unprocessable("refund_exceeds_remaining", "refund amount exceeds remaining refundable amount")
+	// ErrInvalidPaymentTransition is returned when a payment can't move from its current status to the requested one.
+	ErrInvalidPaymentTransition = Conflict("invalid_payment_transition", "invalid payment status transition")
+	// ErrIdempotencyKeyReused is returned when an Idempotency-Key is sent again with a different request body.
+	ErrIdempotencyKeyReused = Unprocessable("idempotency_key_reused", "idempotency key reused with a different request")
+	// ErrPaymentNotCancelable is returned when a payment was already submitted to the ACH network.
+	ErrPaymentNotCancelable = Conflict("payment_not_cancelable", "payment can no longer be canceled")
+	// ErrInvalidRecurringPayment is returned when a recurring payment can't be scheduled as described.
+	ErrInvalidRecurringPayment = Validation("invalid_recurring_payment", "invalid recurring payment")
+	// ErrUnbalancedJournalEntry is returned when a journal entry's debits and credits don't match.
+	// Entries are built by our posting rules, so it is always a bug.
+	ErrUnbalancedJournalEntry = Internal("unbalanced_journal_entry", "unbalanced journal entry")
+	// ErrLedgerAccountNotFound is returned for a ledger account that doesn't exist or belongs to another user.
+	ErrLedgerAccountNotFound = NotFound("ledger_account_not_found", "ledger account not found")
+	// ErrPaymentMethodNotFound is returned when a Stripe payment method isn't attached to the user's customer.
+	ErrPaymentMethodNotFound = NotFound("payment_method_not_found", "payment method not found")
+)
@@ -0,0 +1,224 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Ledger account codes. Customer accounts are kept per user, the others are
+// system accounts shared by all users. Every account is kept per currency.
+const (
+	LedgerAccountCustomerReceivable = "customer_receivable" // debited, not yet settled by the bank
+	LedgerAccountCustomerPayments   = "customer_payments"   // collected from the customer, net of refunds and returns
+	LedgerAccountCustomerDisputes   = "customer_disputes"   // withheld by Stripe while a dispute is open
+	LedgerAccountStripeBalance      = "stripe_balance"      // settled funds held by Stripe
+	LedgerAccountProcessingFees     = "processing_fees"     // fees charged by Stripe
+)
+
+const (
+	LedgerDebit  = "debit"
+	LedgerCredit = "credit"
+)
+
+// ledgerNormalBalances holds the side that increases each account.
+var ledgerNormalBalances = map[string]string{
+	LedgerAccountCustomerReceivable: LedgerDebit,
+	LedgerAccountCustomerPayments:   LedgerCredit,
+	LedgerAccountCustomerDisputes:   LedgerDebit,
+	LedgerAccountStripeBalance:      LedgerDebit,
+	LedgerAccountProcessingFees:     LedgerDebit,
+}
+
+// LedgerNormalBalance returns the side that increases the account with the given code.
+func LedgerNormalBalance(code string) (string, bool) {
+	side, ok := ledgerNormalBalances[code]
+	return side, ok
+}
+
+// Journal entry types, one per posting rule.
+const (
+	JournalEntryCharge         = "charge"          // debit submitted to the customer's bank
+	JournalEntryChargeReversal = "charge_reversal" // submitted debit failed or was canceled
+	JournalEntrySettlement     = "settlement"      // debit settled into the Stripe balance
+	JournalEntryFee            = "fee"
+	JournalEntryRefund         = "refund"
+	JournalEntryRefundReversal = "refund_reversal" // a succeeded refund failed after all
+	JournalEntryReturn         = "return"          // settled debit returned by the customer's bank
+	JournalEntryDispute        = "dispute"
+	JournalEntryDisputeWon     = "dispute_won"
+	JournalEntryDisputeLost    = "dispute_lost"
+)
+
+type LedgerAccount struct {
+	ID            string    `json:"id"`
+	Code          string    `json:"code"`
+	UserID        string    `json:"user_id"` // empty for system accounts
+	Currency      string    `json:"currency"`
+	NormalBalance string    `json:"normal_balance"` // debit or credit
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// LedgerBalance is an account's balance at a point in time, positive on the
+// account's normal side.
+type LedgerBalance struct {
+	Account *LedgerAccount `json:"account"`
+	Balance int64          `json:"balance"` // in cents
+	AsOf    time.Time      `json:"as_of"`
+}
+
+/*
+JournalEntry is one balanced posting to the ledger. Entries are never changed once
+posted; a mistake or a reversed event is corrected by posting another entry.
+
+Reference names what caused the entry (a status change, a refund, a Stripe balance
+transaction) and is unique, so posting the same event twice keeps only the first.
+*/
+type JournalEntry struct {
+	ID          string         `json:"id"`
+	Type        string         `json:"type"`
+	Reference   string         `json:"reference"`
+	PaymentID   string         `json:"payment_id"`
+	Currency    string         `json:"currency"`
+	Description string         `json:"description"`
+	EffectiveAt time.Time      `json:"effective_at"` // when the money moved, balances are taken as of this time
+	Lines       []*JournalLine `json:"lines"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type JournalLine struct {
+	AccountID   string `json:"account_id"`
+	AccountCode string `json:"account_code"`
+	UserID      string `json:"user_id"` // empty for system accounts
+	Direction   string `json:"direction"`
+	Amount      int64  `json:"amount"` // in cents, always positive
+}
+
+// Validate checks that the entry has at least two lines on known accounts and that
+// its debits equal its credits.
+func (e *JournalEntry) Validate() error {
+	if e.Reference == "" || e.Currency == "" {
+		return fmt.Errorf("%w: reference and currency are required", ErrUnbalancedJournalEntry)
+	}
+	if len(e.Lines) < 2 {
+		return fmt.Errorf("%w: %s needs at least two lines", ErrUnbalancedJournalEntry, e.Reference)
+	}
+
+	var debits, credits int64
+	for _, l := range e.Lines {
+		if _, ok := ledgerNormalBalances[l.AccountCode]; !ok {
+			return fmt.Errorf("%w: %s posts to unknown account %q", ErrUnbalancedJournalEntry, e.Reference, l.AccountCode)
+		}
+		if l.Amount <= 0 {
+			return fmt.Errorf("%w: %s has a line of %d", ErrUnbalancedJournalEntry, e.Reference, l.Amount)
+		}
+		switch l.Direction {
+		case LedgerDebit:
+			debits += l.Amount
+		case LedgerCredit:
+			credits += l.Amount
+		default:
+			return fmt.Errorf("%w: %s has a line with direction %q", ErrUnbalancedJournalEntry, e.Reference, l.Direction)
+		}
+	}
+	if debits != credits {
+		return fmt.Errorf("%w: %s debits %d, credits %d", ErrUnbalancedJournalEntry, e.Reference, debits, credits)
+	}
+	return nil
+}
+
+// newJournalEntry builds a two-line entry moving amount from the credited account to the debited one.
+func newJournalEntry(entryType, reference string, payment *Payment, amount int64, debit, credit string, at time.Time) *JournalEntry {
+	return &JournalEntry{
+		Type:        entryType,
+		Reference:   reference,
+		PaymentID:   payment.ID,
+		Currency:    payment.Currency,
+		Description: fmt.Sprintf("%s of payment %s", entryType, payment.ID),
+		EffectiveAt: at,
+		Lines: []*JournalLine{
+			ledgerLine(debit, payment.UserID, LedgerDebit, amount),
+			ledgerLine(credit, payment.UserID, LedgerCredit, amount),
+		},
+	}
+}
+
+func ledgerLine(code, userID, direction string, amount int64) *JournalLine {
+	if code == LedgerAccountStripeBalance || code == LedgerAccountProcessingFees {
+		userID = ""
+	}
+	return &JournalLine{AccountCode: code, UserID: userID, Direction: direction, Amount: amount}
+}
+
+/*
+PaymentPostings returns the entries a payment posts when it moves from one status to
+another. net is the part of the payment that has not been refunded. Refunds post their
+own entries (see RefundPosting) since a payment can be refunded in several parts.
+
+	-> processing                  charge:          Dr customer_receivable  Cr customer_payments
+	processing -> succeeded        settlement:      Dr stripe_balance       Cr customer_receivable
+	created -> succeeded           charge and settlement
+	processing -> failed, canceled charge_reversal: Dr customer_payments    Cr customer_receivable
+	-> returned                    return:          Dr customer_payments    Cr stripe_balance
+	-> disputed                    dispute:         Dr customer_disputes    Cr stripe_balance
+	disputed -> refunded           dispute_lost:    Dr customer_payments    Cr customer_disputes
+	disputed -> anything else      dispute_won:     Dr stripe_balance       Cr customer_disputes
+
+reference identifies the status change and is suffixed with the entry type.
+*/
+func PaymentPostings(payment *Payment, from, to PaymentStatus, net int64, reference string, at time.Time) []*JournalEntry {
+	entry := func(entryType string, amount int64, debit, credit string) *JournalEntry {
+		return newJournalEntry(entryType, reference+":"+entryType, payment, amount, debit, credit, at)
+	}
+
+	var entries []*JournalEntry
+	switch {
+	case to == PaymentStatusProcessing:
+		entries = append(entries, entry(JournalEntryCharge, payment.Amount, LedgerAccountCustomerReceivable, LedgerAccountCustomerPayments))
+
+	case from == PaymentStatusCreated && to == PaymentStatusSucceeded:
+		entries = append(entries,
+			entry(JournalEntryCharge, payment.Amount, LedgerAccountCustomerReceivable, LedgerAccountCustomerPayments),
+			entry(JournalEntrySettlement, payment.Amount, LedgerAccountStripeBalance, LedgerAccountCustomerReceivable),
+		)
+
+	case from == PaymentStatusProcessing && to == PaymentStatusSucceeded:
+		entries = append(entries, entry(JournalEntrySettlement, payment.Amount, LedgerAccountStripeBalance, LedgerAccountCustomerReceivable))
+
+	case from == PaymentStatusProcessing && (to == PaymentStatusFailed || to == PaymentStatusCanceled):
+		entries = append(entries, entry(JournalEntryChargeReversal, payment.Amount, LedgerAccountCustomerPayments, LedgerAccountCustomerReceivable))
+
+	case to == PaymentStatusReturned && net > 0:
+		entries = append(entries, entry(JournalEntryReturn, net, LedgerAccountCustomerPayments, LedgerAccountStripeBalance))
+
+	case to == PaymentStatusDisputed && net > 0:
+		entries = append(entries, entry(JournalEntryDispute, net, LedgerAccountCustomerDisputes, LedgerAccountStripeBalance))
+
+	case from == PaymentStatusDisputed && to == PaymentStatusRefunded && net > 0:
+		entries = append(entries, entry(JournalEntryDisputeLost, net, LedgerAccountCustomerPayments, LedgerAccountCustomerDisputes))
+
+	case from == PaymentStatusDisputed && net > 0:
+		entries = append(entries, entry(JournalEntryDisputeWon, net, LedgerAccountStripeBalance, LedgerAccountCustomerDisputes))
+	}
+	return entries
+}
+
+// RefundPosting returns the entry for a refund of payment that succeeded:
+// Dr customer_payments, Cr stripe_balance.
+func RefundPosting(payment *Payment, refund *Refund, at time.Time) *JournalEntry {
+	return newJournalEntry(JournalEntryRefund, "refund:"+refund.ID, payment, refund.Amount,
+		LedgerAccountCustomerPayments, LedgerAccountStripeBalance, at)
+}
+
+// RefundReversalPosting undoes RefundPosting for a refund that failed after it had succeeded.
+func RefundReversalPosting(payment *Payment, refund *Refund, at time.Time) *JournalEntry {
+	return newJournalEntry(JournalEntryRefundReversal, "refund_reversal:"+refund.ID, payment, refund.Amount,
+		LedgerAccountStripeBalance, LedgerAccountCustomerPayments, at)
+}
+
+// FeePosting returns the entry for a Stripe fee taken from the balance:
+// Dr processing_fees, Cr stripe_balance. reference is usually the Stripe balance
+// transaction the fee was reported on.
+func FeePosting(payment *Payment, fee int64, reference string, at time.Time) *JournalEntry {
+	return newJournalEntry(JournalEntryFee, "fee:"+reference, payment, fee,
+		LedgerAccountProcessingFees, LedgerAccountStripeBalance, at)
+}
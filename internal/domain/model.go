@@ -1,16 +1,18 @@
 package domain
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/guregu/null"
 )
 
 type PlaidToken struct {
-	UserID      string
-	AccessToken string
-	AccountID   string
-	ItemID      string
+	UserID          string
+	AccessToken     string
+	AccountID       string
+	ItemID          string
+	PaymentMethodID string // Stripe payment method that debits the account, empty until one is created
 }
 
 type StripeCustomer struct {
@@ -27,42 +29,256 @@ type StripeCustomer struct {
 }
 
 type CreateACHChargeInput struct {
-	CustomerID      string `json:"customer_id"`       // Required: Stripe Customer ID
-	Amount          int64  `json:"amount"`            // Required: Amount in cents (e.g., 500 = $5.00)
-	Currency        string `json:"currency"`          // e.g., "usd"
-	PaymentMethodID string `json:"payment_method_id"` // Optional: Use a specific payment method ID
-	IdempotencyKey  string `json:"idempotency_key"`   // Required: Prevents duplicate charges
-	Description     string `json:"description"`       // Optional: Charge description
+	PaymentID       string             `json:"payment_id"`        // Optional: internal payment ID, stored in Stripe metadata
+	CustomerID      string             `json:"customer_id"`       // Required: Stripe Customer ID
+	Amount          int64              `json:"amount"`            // Required: Amount in cents (e.g., 500 = $5.00)
+	Currency        string             `json:"currency"`          // e.g., "usd"
+	PaymentMethodID string             `json:"payment_method_id"` // Required: us_bank_account payment method to confirm against
+	IdempotencyKey  string             `json:"idempotency_key"`   // Required: Prevents duplicate charges
+	Description     string             `json:"description"`       // Optional: Charge description
+	Mandate         *MandateAcceptance `json:"mandate"`           // Optional: online mandate acceptance, offline if nil
+}
+
+// MandateAcceptance records how the customer authorized the ACH debit.
+// Stripe requires a mandate for every us_bank_account PaymentIntent.
+type MandateAcceptance struct {
+	AcceptedAt int64  `json:"accepted_at"` // Unix timestamp of acceptance, defaults to when the payment was requested
+	IPAddress  string `json:"ip_address"`  // IP address the customer accepted from
+	UserAgent  string `json:"user_agent"`  // User agent the customer accepted from
+}
+
+// AcceptedBy returns a copy of the mandate, an offline one when m is nil, that was accepted
+// at t unless it names its own time. The time has to be fixed before charging: Stripe rejects
+// a retry with the same Idempotency-Key but a different acceptance time.
+func (m *MandateAcceptance) AcceptedBy(t time.Time) *MandateAcceptance {
+	var accepted MandateAcceptance
+	if m != nil {
+		accepted = *m
+	}
+	if accepted.AcceptedAt == 0 {
+		accepted.AcceptedAt = t.Unix()
+	}
+	return &accepted
 }
 
 type ACHCharge struct {
-	ID        string `json:"id"`         // Stripe charge ID
-	Amount    int64  `json:"amount"`     // Charged amount in cents
-	Currency  string `json:"currency"`   // e.g., "usd"
-	Status    string `json:"status"`     // e.g., "succeeded", "pending", "failed"
-	CreatedAt int64  `json:"created_at"` // Unix timestamp of the charge creation
+	ID              string        `json:"id"`                // Stripe PaymentIntent ID
+	Amount          int64         `json:"amount"`            // Charged amount in cents
+	Currency        string        `json:"currency"`          // e.g., "usd"
+	Status          PaymentStatus `json:"status"`            // Payment status: "processing", "succeeded" or "failed"
+	StripeStatus    string        `json:"stripe_status"`     // Raw PaymentIntent status, e.g. "processing"
+	PaymentMethodID string        `json:"payment_method_id"` // Payment method the intent was confirmed against
+	FailureMessage  string        `json:"failure_message"`   // Last payment error reported by Stripe, if any
+	CreatedAt       int64         `json:"created_at"`        // Unix timestamp of the charge creation
 }
 
 type PaymentMethod struct {
-	ID              string    `json:"id"`
-	Type            string    `json:"type"`              // e.g., "us_bank_account", "card"
-	CustomerID      string    `json:"customer_id"`       // Stripe customer ID
-	Last4           string    `json:"last4"`             // Last 4 digits of bank/card
-	BankName        string    `json:"bank_name"`         // Optional bank name
-	IsDefault       bool      `json:"is_default"`        // Indicates if this is the default payment method
-	CreatedAt       time.Time `json:"created_at"`
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`        // e.g., "us_bank_account", "card"
+	CustomerID string    `json:"customer_id"` // Stripe customer ID
+	Last4      string    `json:"last4"`       // Last 4 digits of bank/card
+	BankName   string    `json:"bank_name"`   // Optional bank name
+	IsDefault  bool      `json:"is_default"`  // Indicates if this is the default payment method
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 type Payment struct {
-	ID               string    `json:"id"`
-	UserID           string    `json:"user_id"`
-	Amount           int64     `json:"amount"`
-	Currency         string    `json:"currency"`
-	PlaidAccountID   string    `json:"plaid_account_id"`
-	PlaidItemID      string    `json:"plaid_item_id"`
-	StripeCustomerID string    `json:"stripe_customer_id"`
-	StripePaymentID  string    `json:"stripe_payment_id"`
-	Status           string    `json:"status"`
-	CreatedAt        time.Time `json:"created_at"`
-	UpdatedAt        time.Time `json:"updated_at"`
+	ID               string        `json:"id"`
+	UserID           string        `json:"user_id"`
+	Amount           int64         `json:"amount"`
+	Currency         string        `json:"currency"`
+	PlaidAccountID   string        `json:"plaid_account_id"`
+	PlaidItemID      string        `json:"plaid_item_id"`
+	StripeCustomerID string        `json:"stripe_customer_id"`
+	StripePaymentID  string        `json:"stripe_payment_id"`
+	Status           PaymentStatus `json:"status"`
+	WorkflowID       string        `json:"workflow_id"`
+	PaymentMethodID  string        `json:"payment_method_id"`   // Stripe payment method debited
+	RetryOfPaymentID string        `json:"retry_of_payment_id"` // set when re-presenting a returned debit
+	Attempt          int32         `json:"attempt"`             // 1 for the original debit
+	// RecurringPaymentID is set when the payment is one cycle of a recurring payment
+	RecurringPaymentID string     `json:"recurring_payment_id"`
+	ExecuteAt          *time.Time `json:"execute_at"` // set for payments scheduled for a later date
+	// ExpectedSettlementAt is when the ACH debit should settle, set once it was submitted
+	ExpectedSettlementAt *time.Time `json:"expected_settlement_at"`
+	// BalanceCheck is the funding account balance seen before charging, if it was checked
+	BalanceCheck *BalanceCheck `json:"balance_check"`
+	// DisputeStatus is the status of the latest Stripe dispute, empty when never disputed
+	DisputeStatus string    `json:"dispute_status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// IdempotencyKey is a client-supplied key for a POST /payments request.
+type IdempotencyKey struct {
+	UserID         string    `json:"user_id"`
+	Key            string    `json:"key"`
+	RequestHash    string    `json:"request_hash"` // hex SHA-256 of the request body
+	PaymentID      string    `json:"payment_id"`
+	ResponseStatus int       `json:"response_status"` // 0 until the first request finished
+	ResponseBody   []byte    `json:"response_body"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// PaymentSettlementSignal is the Temporal signal a running PaymentWorkflow waits on
+// for the ACH settlement outcome reported by Stripe webhooks.
+const PaymentSettlementSignal = "payment-settlement"
+
+type PaymentSettlement struct {
+	Status         PaymentStatus `json:"status"`          // succeeded, failed or returned
+	StripeEventID  string        `json:"stripe_event_id"` // webhook event that reported the outcome
+	FailureMessage string        `json:"failure_message"` // reason reported by Stripe, if any
+}
+
+// PaymentCancelSignal asks a running PaymentWorkflow to cancel its payment. The
+// outcome is read back with the PaymentCancellationQuery.
+const (
+	PaymentCancelSignal      = "payment-cancel"
+	PaymentCancellationQuery = "payment-cancellation"
+)
+
+const (
+	PaymentCancellationRequested = "requested"
+	PaymentCancellationCanceled  = "canceled"
+	PaymentCancellationRejected  = "rejected" // too late, the debit was already submitted or settled
+)
+
+type PaymentCancelRequest struct {
+	Reason string `json:"reason"`
+}
+
+type PaymentCancellation struct {
+	State  string `json:"state"` // empty until a cancel was requested
+	Reason string `json:"reason"`
+}
+
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusSucceeded = "succeeded"
+	RefundStatusFailed    = "failed"
+	RefundStatusCanceled  = "canceled"
+)
+
+type Refund struct {
+	ID             string    `json:"id"`
+	PaymentID      string    `json:"payment_id"`
+	Amount         int64     `json:"amount"` // in cents
+	Currency       string    `json:"currency"`
+	Reason         string    `json:"reason"` // duplicate, fraudulent, requested_by_customer
+	StripeRefundID string    `json:"stripe_refund_id"`
+	Status         string    `json:"status"`
+	FailureReason  string    `json:"failure_reason"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type CreateRefundInput struct {
+	PaymentIntentID string `json:"payment_intent_id"` // Required: Stripe PaymentIntent being refunded
+	Amount          int64  `json:"amount"`            // Required: Amount in cents
+	Reason          string `json:"reason"`            // Optional: Stripe refund reason
+	IdempotencyKey  string `json:"idempotency_key"`   // Required: Prevents duplicate refunds
+	RefundID        string `json:"refund_id"`         // Optional: internal refund ID, stored in Stripe metadata
+}
+
+type ACHRefund struct {
+	ID            string `json:"id"`             // Stripe refund ID
+	Amount        int64  `json:"amount"`         // Refunded amount in cents
+	Status        string `json:"status"`         // e.g., "pending", "succeeded", "failed"
+	FailureReason string `json:"failure_reason"` // Set when the refund failed
+}
+
+const (
+	WebhookProviderStripe = "stripe"
+	WebhookProviderPlaid  = "plaid"
+)
+
+const (
+	WebhookEventStatusReceived   = "received"
+	WebhookEventStatusProcessing = "processing"
+	WebhookEventStatusProcessed  = "processed"
+	WebhookEventStatusFailed     = "failed"
+)
+
+type WebhookEvent struct {
+	ID          string          `json:"id"`
+	Provider    string          `json:"provider"`   // stripe, plaid
+	EventID     string          `json:"event_id"`   // provider event ID, used for deduplication
+	EventType   string          `json:"event_type"` // e.g. "charge.succeeded", "TRANSACTIONS.TRANSACTIONS_UPDATED"
+	Payload     json.RawMessage `json:"payload"`    // raw request body
+	Status      string          `json:"status"`
+	Attempts    int32           `json:"attempts"`
+	LastError   string          `json:"last_error"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	ProcessedAt *time.Time      `json:"processed_at"`
+}
+
+const (
+	PlaidItemStatusHealthy           = "healthy"
+	PlaidItemStatusLoginRequired     = "login_required"     // ITEM ERROR with ITEM_LOGIN_REQUIRED, user must go through Link update mode
+	PlaidItemStatusPendingExpiration = "pending_expiration" // consent expires soon, see ConsentExpiresAt
+	PlaidItemStatusPermissionRevoked = "permission_revoked" // user revoked access at their bank, the access token is dead
+	PlaidItemStatusError             = "error"              // any other ITEM ERROR
+)
+
+const (
+	PlaidVerificationStatusAutomaticallyVerified = "automatically_verified"
+	PlaidVerificationStatusVerificationExpired   = "verification_expired"
+)
+
+// PlaidItem is a user's login at one institution, linked through Plaid Link. Its
+// health is kept up to date from Plaid webhooks.
+type PlaidItem struct {
+	ItemID                string     `json:"item_id"`
+	UserID                string     `json:"user_id"`
+	AccessToken           string     `json:"-"`
+	InstitutionID         string     `json:"institution_id"`
+	InstitutionName       string     `json:"institution_name"`
+	Status                string     `json:"status"`
+	ErrorCode             string     `json:"error_code"`
+	ErrorMessage          string     `json:"error_message"`
+	ConsentExpiresAt      *time.Time `json:"consent_expires_at"`
+	VerificationStatus    string     `json:"verification_status"`     // automated micro-deposit verification outcome
+	VerificationAccountID string     `json:"verification_account_id"` // Plaid account the verification applies to
+	AuthUpdatedAt         *time.Time `json:"auth_updated_at"`         // account/routing numbers changed, re-fetch before charging
+	LastWebhookCode       string     `json:"last_webhook_code"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+// IsUsable reports whether the Item's access token can still be used to move money.
+func (i *PlaidItem) IsUsable() bool {
+	return i.Status != PlaidItemStatusLoginRequired && i.Status != PlaidItemStatusPermissionRevoked
+}
+
+// PlaidWebhookKey is a JWK Plaid signs webhooks with, cached by key ID.
+type PlaidWebhookKey struct {
+	KeyID     string    `json:"kid"`
+	Alg       string    `json:"alg"`
+	Crv       string    `json:"crv"`
+	Kty       string    `json:"kty"`
+	Use       string    `json:"use"`
+	X         string    `json:"x"`
+	Y         string    `json:"y"`
+	CreatedAt int64     `json:"created_at"` // unix time
+	ExpiredAt *int64    `json:"expired_at"` // unix time, nil while the key is current
+	FetchedAt time.Time `json:"fetched_at"` // when we last fetched the key from Plaid
+}
+
+// PlaidAccount is one of the accounts under a PlaidItem. The user's default
+// account funds payments that don't name another one.
+type PlaidAccount struct {
+	AccountID       string    `json:"account_id"`
+	ItemID          string    `json:"item_id"`
+	UserID          string    `json:"user_id"`
+	Name            string    `json:"name"`
+	OfficialName    string    `json:"official_name"`
+	Mask            string    `json:"mask"`    // last 2-4 digits of the account number
+	Type            string    `json:"type"`    // depository, credit, loan, ...
+	Subtype         string    `json:"subtype"` // checking, savings, ...
+	IsDefault       bool      `json:"is_default"`
+	PaymentMethodID string    `json:"payment_method_id"` // Stripe payment method that debits the account
+	InstitutionName string    `json:"institution"`
+	ItemStatus      string    `json:"item_status"` // health of the owning item, see PlaidItemStatus*
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
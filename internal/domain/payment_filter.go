@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// PaymentFilter selects the payments to list. Zero fields don't filter.
+type PaymentFilter struct {
+	UserID           string
+	Statuses         []PaymentStatus
+	MinAmount        *int64 // in cents, inclusive
+	MaxAmount        *int64 // in cents, inclusive
+	Currency         string
+	CreatedFrom      *time.Time // inclusive
+	CreatedTo        *time.Time // exclusive
+	StripePaymentID  string
+	StripeCustomerID string
+}
+
+// PaymentCursor is the position after which the next page of payments starts: the
+// created_at and ID of the last payment of the previous page.
+type PaymentCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// PaymentPage is a page of payments, newest first.
+type PaymentPage struct {
+	Payments []*Payment
+	Next     *PaymentCursor // nil on the last page
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// PaymentRescheduleSignal moves the execution date of a scheduled PaymentWorkflow.
+// The workflow's current schedule is read back with the PaymentScheduleQuery.
+const (
+	PaymentRescheduleSignal = "payment-reschedule"
+	PaymentScheduleQuery    = "payment-schedule"
+)
+
+type PaymentRescheduleRequest struct {
+	ExecuteAt time.Time `json:"execute_at"`
+}
+
+// PaymentSchedule is when a scheduled payment will be charged.
+type PaymentSchedule struct {
+	ExecuteAt time.Time `json:"execute_at"` // zero for payments charged right away
+	Started   bool      `json:"started"`    // the execution date was reached, it can no longer move
+}
@@ -0,0 +1,85 @@
+package domain
+
+import "time"
+
+type PaymentStatus string
+
+const (
+	PaymentStatusScheduled         PaymentStatus = "scheduled"  // waiting for its execute_at date
+	PaymentStatusCreated           PaymentStatus = "created"    // recorded, nothing sent to Stripe yet
+	PaymentStatusOnHold            PaymentStatus = "on_hold"    // held for review, e.g. the balance check failed
+	PaymentStatusProcessing        PaymentStatus = "processing" // debit submitted, waiting for settlement
+	PaymentStatusSucceeded         PaymentStatus = "succeeded"
+	PaymentStatusFailed            PaymentStatus = "failed"
+	PaymentStatusReturned          PaymentStatus = "returned" // ACH debit returned after it had succeeded
+	PaymentStatusRefunded          PaymentStatus = "refunded"
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentStatusCanceled          PaymentStatus = "canceled"
+	PaymentStatusDisputed          PaymentStatus = "disputed"
+)
+
+/*
+paymentTransitions lists the statuses a payment may move to from each status.
+
+	scheduled          -> created, failed, canceled
+	created            -> on_hold, processing, succeeded, failed, canceled
+	on_hold            -> created (released), failed, canceled
+	processing         -> succeeded, failed, canceled
+	succeeded          -> returned, refunded, partially_refunded, disputed
+	partially_refunded -> refunded, succeeded (a refund failed), returned, disputed
+	refunded           -> partially_refunded, succeeded (a refund failed), returned, disputed
+	disputed           -> succeeded (dispute won), refunded, partially_refunded
+	failed, returned, canceled are final
+
+Staying in the same status is not a transition and is always allowed.
+*/
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusScheduled:         {PaymentStatusCreated, PaymentStatusFailed, PaymentStatusCanceled},
+	PaymentStatusCreated:           {PaymentStatusOnHold, PaymentStatusProcessing, PaymentStatusSucceeded, PaymentStatusFailed, PaymentStatusCanceled},
+	PaymentStatusOnHold:            {PaymentStatusCreated, PaymentStatusFailed, PaymentStatusCanceled},
+	PaymentStatusProcessing:        {PaymentStatusSucceeded, PaymentStatusFailed, PaymentStatusCanceled},
+	PaymentStatusSucceeded:         {PaymentStatusReturned, PaymentStatusRefunded, PaymentStatusPartiallyRefunded, PaymentStatusDisputed},
+	PaymentStatusPartiallyRefunded: {PaymentStatusRefunded, PaymentStatusSucceeded, PaymentStatusReturned, PaymentStatusDisputed},
+	PaymentStatusRefunded:          {PaymentStatusPartiallyRefunded, PaymentStatusSucceeded, PaymentStatusReturned, PaymentStatusDisputed},
+	PaymentStatusDisputed:          {PaymentStatusSucceeded, PaymentStatusRefunded, PaymentStatusPartiallyRefunded},
+	PaymentStatusFailed:            nil,
+	PaymentStatusReturned:          nil,
+	PaymentStatusCanceled:          nil,
+}
+
+// IsValid reports whether s is a known payment status.
+func (s PaymentStatus) IsValid() bool {
+	_, ok := paymentTransitions[s]
+	return ok
+}
+
+// IsFinal reports whether no further transitions are possible from s.
+func (s PaymentStatus) IsFinal() bool {
+	return s.IsValid() && len(paymentTransitions[s]) == 0
+}
+
+// CanTransitionTo reports whether a payment in status s may move to next.
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	if !next.IsValid() {
+		return false
+	}
+	if s == next {
+		return true
+	}
+	for _, allowed := range paymentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// PaymentStatusChange is one entry of a payment's status history.
+type PaymentStatusChange struct {
+	ID         int64         `json:"id"`
+	PaymentID  string        `json:"payment_id"`
+	FromStatus PaymentStatus `json:"from_status"` // empty for the initial status
+	ToStatus   PaymentStatus `json:"to_status"`
+	Reason     string        `json:"reason"` // what caused the change, e.g. a Stripe event ID
+	CreatedAt  time.Time     `json:"created_at"`
+}
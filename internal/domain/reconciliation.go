@@ -0,0 +1,226 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	ReconciliationRunStatusRunning   = "running"
+	ReconciliationRunStatusCompleted = "completed"
+	ReconciliationRunStatusFailed    = "failed"
+)
+
+// Discrepancy kinds found by a reconciliation run.
+const (
+	DiscrepancyMissingPayment     = "missing_payment"     // Stripe moved money for a PaymentIntent we have no payment for
+	DiscrepancyMissingInStripe    = "missing_in_stripe"   // the payment succeeded but Stripe has no charge for it
+	DiscrepancyAmountMismatch     = "amount_mismatch"     // Stripe charged a different amount than the payment
+	DiscrepancyStatusMismatch     = "status_mismatch"     // the payment's status contradicts what Stripe recorded
+	DiscrepancyPayoutFailed       = "payout_failed"       // a payout failed or was canceled
+	DiscrepancyPayoutUnreconciled = "payout_unreconciled" // a paid payout has no balance transaction in the period
+)
+
+// Stripe balance transaction types the reconciliation looks at.
+const (
+	StripeBalanceTransactionCharge        = "charge"
+	StripeBalanceTransactionPayment       = "payment"
+	StripeBalanceTransactionRefund        = "refund"
+	StripeBalanceTransactionPaymentRefund = "payment_refund"
+	StripeBalanceTransactionPaymentFailed = "payment_failure_refund" // an ACH debit returned after it was credited
+	StripeBalanceTransactionPayout        = "payout"
+)
+
+// StripeBalanceTransaction is a movement of funds in the Stripe balance.
+type StripeBalanceTransaction struct {
+	ID              string    `json:"id"`
+	Type            string    `json:"type"`
+	Status          string    `json:"status"` // pending or available
+	Amount          int64     `json:"amount"` // in cents, negative when funds left the balance
+	Fee             int64     `json:"fee"`
+	Net             int64     `json:"net"`
+	Currency        string    `json:"currency"`
+	SourceID        string    `json:"source_id"`         // charge, refund or payout the transaction belongs to
+	PaymentIntentID string    `json:"payment_intent_id"` // set for charges and refunds
+	CreatedAt       time.Time `json:"created_at"`
+	AvailableOn     time.Time `json:"available_on"`
+}
+
+type StripePayout struct {
+	ID                   string    `json:"id"`
+	Amount               int64     `json:"amount"` // in cents
+	Currency             string    `json:"currency"`
+	Status               string    `json:"status"` // pending, in_transit, paid, failed, canceled
+	BalanceTransactionID string    `json:"balance_transaction_id"`
+	FailureCode          string    `json:"failure_code"`
+	ArrivalDate          time.Time `json:"arrival_date"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+// ReconciliationRun is one comparison of our payments with Stripe for a period.
+type ReconciliationRun struct {
+	ID            string                `json:"id"`
+	WorkflowID    string                `json:"workflow_id"`
+	PeriodStart   time.Time             `json:"period_start"`
+	PeriodEnd     time.Time             `json:"period_end"` // exclusive
+	Status        string                `json:"status"`
+	Discrepancies int32                 `json:"discrepancies"`
+	Report        *ReconciliationReport `json:"report,omitempty"` // set once the run completed
+	Error         string                `json:"error,omitempty"`  // set when the run failed
+	StartedAt     time.Time             `json:"started_at"`
+	CompletedAt   *time.Time            `json:"completed_at"`
+}
+
+type ReconciliationReport struct {
+	BalanceTransactions int                          `json:"balance_transactions"`
+	Payouts             []*StripePayout              `json:"payouts"`
+	MatchedPayments     int                          `json:"matched_payments"`
+	Fees                int64                        `json:"fees"` // Stripe fees on the matched charges, in cents
+	Discrepancies       []*ReconciliationDiscrepancy `json:"discrepancies"`
+}
+
+type ReconciliationDiscrepancy struct {
+	Kind                 string        `json:"kind"`
+	PaymentID            string        `json:"payment_id,omitempty"`
+	StripePaymentID      string        `json:"stripe_payment_id,omitempty"`
+	BalanceTransactionID string        `json:"balance_transaction_id,omitempty"`
+	PayoutID             string        `json:"payout_id,omitempty"`
+	Status               PaymentStatus `json:"status,omitempty"` // our payment status
+	ExpectedAmount       int64         `json:"expected_amount,omitempty"`
+	ActualAmount         int64         `json:"actual_amount,omitempty"`
+	Detail               string        `json:"detail"`
+}
+
+/*
+Reconcile compares Stripe's balance transactions and payouts for a period with our payments.
+
+  - payments are the payments the transactions' PaymentIntents belong to, settled are
+    the payments that moved to succeeded during the period
+  - a charge must belong to a payment for the same amount, in a status where the money was collected
+  - a refund must belong to a payment that is refunded or partially refunded
+  - a returned debit must belong to a returned payment
+  - every payment that succeeded during the period must have a charge
+  - payouts must not fail and a paid payout must have its balance transaction in the period
+*/
+func Reconcile(payments, settled []*Payment, txns []*StripeBalanceTransaction, payouts []*StripePayout) *ReconciliationReport {
+	report := &ReconciliationReport{
+		BalanceTransactions: len(txns),
+		Payouts:             payouts,
+		Discrepancies:       []*ReconciliationDiscrepancy{},
+	}
+	add := func(d *ReconciliationDiscrepancy) {
+		report.Discrepancies = append(report.Discrepancies, d)
+	}
+
+	byIntent := make(map[string]*Payment, len(payments)+len(settled))
+	for _, list := range [][]*Payment{payments, settled} {
+		for _, p := range list {
+			if p.StripePaymentID != "" {
+				byIntent[p.StripePaymentID] = p
+			}
+		}
+	}
+
+	charged := make(map[string]bool)
+	txnIDs := make(map[string]bool, len(txns))
+	for _, txn := range txns {
+		txnIDs[txn.ID] = true
+		if txn.PaymentIntentID == "" {
+			continue
+		}
+
+		payment, ok := byIntent[txn.PaymentIntentID]
+		if !ok {
+			add(&ReconciliationDiscrepancy{
+				Kind:                 DiscrepancyMissingPayment,
+				StripePaymentID:      txn.PaymentIntentID,
+				BalanceTransactionID: txn.ID,
+				ActualAmount:         txn.Amount,
+				Detail:               fmt.Sprintf("Stripe %s has no payment", txn.Type),
+			})
+			continue
+		}
+
+		switch txn.Type {
+		case StripeBalanceTransactionCharge, StripeBalanceTransactionPayment:
+			charged[payment.ID] = true
+			report.MatchedPayments++
+			report.Fees += txn.Fee
+			if txn.Amount != payment.Amount {
+				add(txnDiscrepancy(DiscrepancyAmountMismatch, payment, txn, "charged amount differs from the payment"))
+			}
+			if !collected(payment.Status) {
+				add(txnDiscrepancy(DiscrepancyStatusMismatch, payment, txn, "Stripe collected the payment"))
+			}
+
+		case StripeBalanceTransactionRefund, StripeBalanceTransactionPaymentRefund:
+			if payment.Status != PaymentStatusRefunded && payment.Status != PaymentStatusPartiallyRefunded {
+				add(txnDiscrepancy(DiscrepancyStatusMismatch, payment, txn, "Stripe refunded the payment"))
+			}
+
+		case StripeBalanceTransactionPaymentFailed:
+			if payment.Status != PaymentStatusReturned {
+				add(txnDiscrepancy(DiscrepancyStatusMismatch, payment, txn, "Stripe reversed the debit"))
+			}
+		}
+	}
+
+	for _, p := range settled {
+		if !charged[p.ID] {
+			add(&ReconciliationDiscrepancy{
+				Kind:            DiscrepancyMissingInStripe,
+				PaymentID:       p.ID,
+				StripePaymentID: p.StripePaymentID,
+				Status:          p.Status,
+				ExpectedAmount:  p.Amount,
+				Detail:          "payment succeeded but Stripe has no charge for it in the period",
+			})
+		}
+	}
+
+	for _, payout := range payouts {
+		switch {
+		case payout.Status == "failed" || payout.Status == "canceled":
+			add(&ReconciliationDiscrepancy{
+				Kind:           DiscrepancyPayoutFailed,
+				PayoutID:       payout.ID,
+				ExpectedAmount: payout.Amount,
+				Detail:         fmt.Sprintf("payout %s: %s", payout.Status, payout.FailureCode),
+			})
+		case payout.Status == "paid" && !txnIDs[payout.BalanceTransactionID]:
+			add(&ReconciliationDiscrepancy{
+				Kind:                 DiscrepancyPayoutUnreconciled,
+				PayoutID:             payout.ID,
+				BalanceTransactionID: payout.BalanceTransactionID,
+				ExpectedAmount:       payout.Amount,
+				Detail:               "paid payout has no balance transaction in the period",
+			})
+		}
+	}
+
+	return report
+}
+
+func txnDiscrepancy(kind string, payment *Payment, txn *StripeBalanceTransaction, detail string) *ReconciliationDiscrepancy {
+	return &ReconciliationDiscrepancy{
+		Kind:                 kind,
+		PaymentID:            payment.ID,
+		StripePaymentID:      payment.StripePaymentID,
+		BalanceTransactionID: txn.ID,
+		Status:               payment.Status,
+		ExpectedAmount:       payment.Amount,
+		ActualAmount:         txn.Amount,
+		Detail:               detail,
+	}
+}
+
+// collected reports whether a payment in status s has had its funds collected at some point.
+func collected(s PaymentStatus) bool {
+	switch s {
+	case PaymentStatusSucceeded, PaymentStatusRefunded, PaymentStatusPartiallyRefunded,
+		PaymentStatusDisputed, PaymentStatusReturned:
+		return true
+	default:
+		return false
+	}
+}
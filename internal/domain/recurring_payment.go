@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	RecurringIntervalDaily   = "daily"
+	RecurringIntervalWeekly  = "weekly"
+	RecurringIntervalMonthly = "monthly"
+	RecurringIntervalYearly  = "yearly"
+)
+
+const (
+	RecurringPaymentStatusActive   = "active"
+	RecurringPaymentStatusPaused   = "paused"
+	RecurringPaymentStatusCanceled = "canceled"
+)
+
+// RecurringPayment charges a customer on a fixed calendar interval, starting at
+// AnchorAt and repeating on the same time of day, weekday, day of month or date
+// until EndAt. Each cycle runs its own PaymentWorkflow, started by a Temporal Schedule.
+type RecurringPayment struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	CustomerID      string     `json:"customer_id"` // Stripe customer ID
+	PaymentMethodID string     `json:"payment_method_id"`
+	PlaidAccountID  string     `json:"plaid_account_id"`
+	PlaidItemID     string     `json:"plaid_item_id"`
+	Amount          int64      `json:"amount"` // in cents
+	Currency        string     `json:"currency"`
+	Description     string     `json:"description"`
+	Interval        string     `json:"interval"`  // daily, weekly, monthly, yearly
+	AnchorAt        time.Time  `json:"anchor_at"` // first charge; later charges repeat its calendar position (UTC)
+	EndAt           *time.Time `json:"end_at"`    // optional, no charges after this time
+	Status          string     `json:"status"`
+	ScheduleID      string     `json:"schedule_id"` // Temporal Schedule starting the payments
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Validate checks the fields needed to build a schedule.
+func (r *RecurringPayment) Validate() error {
+	switch {
+	case r.Amount <= 0:
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidRecurringPayment)
+	case r.CustomerID == "" || r.PaymentMethodID == "":
+		return fmt.Errorf("%w: customer_id and payment_method_id are required", ErrInvalidRecurringPayment)
+	case r.AnchorAt.IsZero():
+		return fmt.Errorf("%w: anchor_at is required", ErrInvalidRecurringPayment)
+	case r.EndAt != nil && !r.EndAt.After(r.AnchorAt):
+		return fmt.Errorf("%w: end_at must be after anchor_at", ErrInvalidRecurringPayment)
+	}
+
+	switch r.Interval {
+	case RecurringIntervalDaily, RecurringIntervalWeekly:
+	case RecurringIntervalMonthly, RecurringIntervalYearly:
+		// Not every month has a 29th-31st, and a calendar schedule would skip those months
+		if r.AnchorAt.UTC().Day() > 28 {
+			return fmt.Errorf("%w: %s payments must be anchored on day 1-28", ErrInvalidRecurringPayment, r.Interval)
+		}
+	default:
+		return fmt.Errorf("%w: unknown interval %q", ErrInvalidRecurringPayment, r.Interval)
+	}
+	return nil
+}
+
+// NextRuns returns up to n charge times strictly after from, honouring EndAt.
+// It follows the same calendar rules as the Temporal Schedule, so it can be shown as a preview.
+func (r *RecurringPayment) NextRuns(from time.Time, n int) []time.Time {
+	anchor := r.AnchorAt.UTC()
+	runs := make([]time.Time, 0, n)
+	for cycle := 0; len(runs) < n; cycle++ {
+		next := r.cycleTime(anchor, cycle)
+		if r.EndAt != nil && next.After(*r.EndAt) {
+			break
+		}
+		if next.After(from) {
+			runs = append(runs, next)
+		}
+	}
+	return runs
+}
+
+func (r *RecurringPayment) cycleTime(anchor time.Time, cycle int) time.Time {
+	switch r.Interval {
+	case RecurringIntervalDaily:
+		return anchor.AddDate(0, 0, cycle)
+	case RecurringIntervalWeekly:
+		return anchor.AddDate(0, 0, 7*cycle)
+	case RecurringIntervalMonthly:
+		return anchor.AddDate(0, cycle, 0)
+	default:
+		return anchor.AddDate(cycle, 0, 0)
+	}
+}
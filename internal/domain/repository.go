@@ -1,16 +1,87 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 
 type Repository interface {
 	GetPlaidToken(ctx context.Context, userID string) (*PlaidToken, error)
-	StorePlaidToken(ctx context.Context, token PlaidToken) error
-	DeletePlaidToken(ctx context.Context, userID string) error
+	GetPlaidTokenForAccount(ctx context.Context, userID, accountID string) (*PlaidToken, error)
+	SavePlaidItem(ctx context.Context, item *PlaidItem, accounts []*PlaidAccount) error
+	DeletePlaidItem(ctx context.Context, userID, itemID string) error
+	ListPlaidAccounts(ctx context.Context, userID string) ([]*PlaidAccount, error)
+	SetDefaultPlaidAccount(ctx context.Context, userID, accountID string) error
+	SetPlaidAccountPaymentMethod(ctx context.Context, userID, accountID, paymentMethodID string) error
 	GetStripeCustomerByUserID(ctx context.Context, userID string)(*StripeCustomer, error) 
 	InsertStripeCustomer(ctx context.Context, customer *StripeCustomer) error 
 	InsertPayment(ctx context.Context, payment *Payment) error 
-	UpdatePaymentStatus(ctx context.Context, paymentID, status string) error
+	UpdatePaymentStatus(ctx context.Context, paymentID string, status PaymentStatus, reason string) error
+	UpdatePaymentStatuses(ctx context.Context, paymentID string, statuses []PaymentStatus, reason string) error
+	GetPaymentStatusHistory(ctx context.Context, paymentID string) ([]*PaymentStatusChange, error)
+	UpdatePaymentStripeID(ctx context.Context, paymentID, stripePaymentID, paymentMethodID string) error
+	UpdatePaymentExecuteAt(ctx context.Context, paymentID string, executeAt time.Time) error
+	UpdatePaymentExpectedSettlement(ctx context.Context, paymentID string, expectedAt time.Time) error
+	UpdatePaymentBalanceCheck(ctx context.Context, paymentID string, check *BalanceCheck) error
 	GetPaymentByID(ctx context.Context, paymentID string) (*Payment, error)
-	GetAllPayments(ctx context.Context) ([]*Payment, error)
+	GetPaymentByStripePaymentID(ctx context.Context, stripePaymentID string) (*Payment, error)
+	ListPayments(ctx context.Context, filter PaymentFilter, after *PaymentCursor, limit int32) (*PaymentPage, error)
+	CountPayments(ctx context.Context, filter PaymentFilter) (int64, error)
+	ClaimIdempotencyKey(ctx context.Context, key *IdempotencyKey) (bool, error)
+	SaveIdempotencyKeyResponse(ctx context.Context, key *IdempotencyKey) error
+	CreateRefund(ctx context.Context, refund *Refund) error
+	UpdateRefund(ctx context.Context, refund *Refund) error
+	GetRefundByID(ctx context.Context, refundID string) (*Refund, error)
+	GetRefundByStripeRefundID(ctx context.Context, stripeRefundID string) (*Refund, error)
+	GetRefundsByPaymentID(ctx context.Context, paymentID string) ([]*Refund, error)
+	SaveWebhookEvent(ctx context.Context, event *WebhookEvent) (bool, error)
+	GetWebhookEventByID(ctx context.Context, eventID string) (*WebhookEvent, error)
+	ListWebhookEvents(ctx context.Context, status string, limit int32) ([]*WebhookEvent, error)
+	MarkWebhookEventProcessing(ctx context.Context, eventID string) error
+	MarkWebhookEventProcessed(ctx context.Context, eventID string) error
+	MarkWebhookEventFailed(ctx context.Context, eventID string, reason string) error
+	ResetWebhookEvent(ctx context.Context, eventID string) error
+	GetPlaidItem(ctx context.Context, itemID string) (*PlaidItem, error)
+	UpdatePlaidItemStatus(ctx context.Context, itemID, status, errorCode, errorMessage, webhookCode string) error
+	UpdatePlaidItemConsentExpiration(ctx context.Context, itemID string, expiresAt time.Time) error
+	UpdatePlaidItemVerification(ctx context.Context, itemID, accountID, verificationStatus, webhookCode string) error
+	MarkPlaidItemAuthUpdated(ctx context.Context, itemID string) error
+	ClearStripeCustomerDefaultPayment(ctx context.Context, userID, paymentMethodID string) error
+	CreateACHReturn(ctx context.Context, achReturn *ACHReturn) (bool, error)
+	GetACHReturnByID(ctx context.Context, returnID string) (*ACHReturn, error)
+	GetACHReturnsByPaymentID(ctx context.Context, paymentID string) ([]*ACHReturn, error)
+	UpdateACHReturnAction(ctx context.Context, returnID, action, retryPaymentID string) error
+	CreateRecurringPayment(ctx context.Context, recurring *RecurringPayment) error
+	GetRecurringPaymentByID(ctx context.Context, recurringID string) (*RecurringPayment, error)
+	ListRecurringPayments(ctx context.Context, userID string) ([]*RecurringPayment, error)
+	UpdateRecurringPayment(ctx context.Context, recurring *RecurringPayment) error
+	UpdateRecurringPaymentStatus(ctx context.Context, recurringID, status string) error
+	DeleteRecurringPayment(ctx context.Context, recurringID string) error
+	GetPlaidWebhookKey(ctx context.Context, kid string) (*PlaidWebhookKey, error)
+	SavePlaidWebhookKey(ctx context.Context, key *PlaidWebhookKey) error
+	PostJournalEntry(ctx context.Context, entry *JournalEntry) (bool, error)
+	GetLedgerAccount(ctx context.Context, accountID string) (*LedgerAccount, error)
+	GetLedgerAccountBalance(ctx context.Context, accountID string, asOf time.Time) (*LedgerBalance, error)
+	GetUserLedgerBalances(ctx context.Context, userID string, asOf time.Time) ([]*LedgerBalance, error)
+	GetJournalEntriesByPaymentID(ctx context.Context, paymentID string) ([]*JournalEntry, error)
+	CreateReconciliationRun(ctx context.Context, run *ReconciliationRun) error
+	CompleteReconciliationRun(ctx context.Context, runID string, report *ReconciliationReport) error
+	FailReconciliationRun(ctx context.Context, runID, reason string) error
+	GetReconciliationRun(ctx context.Context, runID string) (*ReconciliationRun, error)
+	ListReconciliationRuns(ctx context.Context, limit int32) ([]*ReconciliationRun, error)
+	ListPaymentsByStripePaymentIDs(ctx context.Context, stripePaymentIDs []string) ([]*Payment, error)
+	ListPaymentsSucceededBetween(ctx context.Context, from, to time.Time) ([]*Payment, error)
+	SaveDispute(ctx context.Context, dispute *Dispute) (bool, error)
+	GetDispute(ctx context.Context, disputeID string) (*Dispute, error)
+	ListDisputes(ctx context.Context, status string, limit int32) ([]*Dispute, error)
+	GetDisputesByPaymentID(ctx context.Context, paymentID string) ([]*Dispute, error)
+	UpdateDisputeEvidence(ctx context.Context, disputeID, status string, submitted bool) error
+	MarkDisputeAlerted(ctx context.Context, disputeID string) error
+	CreateAPIKey(ctx context.Context, key *APIKey) error
+	GetAPIKey(ctx context.Context, keyID string) (*APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID string) error
+	TouchAPIKey(ctx context.Context, keyID string) error
 }
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/GalaDe/payments-service/internal/services/temporal/workflow"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+)
+
+/*
+
+Admin APIs
+
+
+| Endpoint                               | Description                                        |
+| -------------------------------------- | -------------------------------------------------- |
+| `GET  /admin/webhooks`                 | List stored webhook events by status               |
+| `POST /admin/webhooks/replay`          | Replay failed webhook events or specific event IDs |
+| `POST /admin/payments/{id}/review`     | Approve or reject a payment held for review        |
+| `GET  /admin/reconciliation-runs`      | List reconciliation runs, newest first             |
+| `POST /admin/reconciliation-runs`      | Reconcile a period with Stripe now                 |
+| `GET  /admin/reconciliation-runs/{id}` | Get a reconciliation run with its report           |
+
+
+*/
+
+const (
+	defaultWebhookListLimit = 50
+	maxWebhookListLimit     = 500
+
+	// Stripe is paged through in one activity, keep manual runs to about a month
+	maxReconciliationPeriod = 31 * 24 * time.Hour
+)
+
+type ReplayWebhooksRequest struct {
+	EventIDs []string `json:"event_ids"` // webhook_events IDs to replay
+	Status   string   `json:"status"`    // replay every event in this status, e.g. "failed"
+	Limit    int32    `json:"limit"`     // max events replayed by status, defaults to 50
+}
+
+/*
+	GET  /admin/webhooks?status=failed&limit=50
+*/
+func (h *HttpServer) ListWebhookEvents(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = domain.WebhookEventStatusFailed
+	}
+
+	limit, err := parseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+
+	events, err := h.repository.ListWebhookEvents(r.Context(), status, limit)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list webhook events")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, events)
+}
+
+/*
+	POST /admin/webhooks/replay
+
+	Resets each selected event to "received" and starts its WebhookEventWorkflow again.
+	Already processed events are re-applied, which is safe because processing is idempotent.
+*/
+func (h *HttpServer) ReplayWebhookEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req ReplayWebhooksRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if len(req.EventIDs) == 0 && req.Status == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Either event_ids or status is required")
+		return
+	}
+
+	eventIDs := req.EventIDs
+	if req.Status != "" {
+		limit := req.Limit
+		if limit <= 0 || limit > maxWebhookListLimit {
+			limit = defaultWebhookListLimit
+		}
+		events, err := h.repository.ListWebhookEvents(ctx, req.Status, limit)
+		if err != nil {
+			h.respondWithError(w, http.StatusInternalServerError, "Failed to list webhook events")
+			return
+		}
+		for _, e := range events {
+			eventIDs = append(eventIDs, e.ID)
+		}
+	}
+
+	replayed := make([]string, 0, len(eventIDs))
+	failed := make(map[string]string)
+	for _, id := range eventIDs {
+		if _, err := h.repository.GetWebhookEventByID(ctx, id); err != nil {
+			failed[id] = "webhook event not found"
+			continue
+		}
+		if err := h.repository.ResetWebhookEvent(ctx, id); err != nil {
+			failed[id] = "failed to reset webhook event"
+			continue
+		}
+		if err := h.startWebhookEventWorkflow(ctx, id); err != nil {
+			log.Printf("Failed to replay webhook event %s: %v", id, err)
+			failed[id] = "failed to start webhook workflow"
+			continue
+		}
+		replayed = append(replayed, id)
+	}
+
+	h.respondWithJSON(w, http.StatusAccepted, map[string]interface{}{
+		"replayed": replayed,
+		"failed":   failed,
+	})
+}
+
+func parseLimit(value string) (int32, error) {
+	if value == "" {
+		return defaultWebhookListLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if limit <= 0 || limit > maxWebhookListLimit {
+		return defaultWebhookListLimit, nil
+	}
+	return int32(limit), nil
+}
+
+type ReviewPaymentRequest struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason"` // stored in the payment's status history
+}
+
+/*
+	POST /admin/payments/{id}/review
+
+	Releases (approved) or fails (rejected) a payment put on hold by the balance check.
+	The PaymentWorkflow applies the decision; poll GET /payments/{id} for the outcome.
+*/
+func (h *HttpServer) ReviewPayment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	paymentID := chi.URLParam(r, "id")
+
+	var req ReviewPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	payment, err := h.repository.GetPaymentByID(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.respondWithError(w, http.StatusNotFound, "Payment not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to fetch payment")
+		return
+	}
+	if payment.Status != domain.PaymentStatusOnHold || payment.WorkflowID == "" {
+		h.respondWithError(w, http.StatusConflict, "Payment is "+string(payment.Status)+", only payments on hold can be reviewed")
+		return
+	}
+
+	decision := domain.PaymentReviewDecision{Approved: req.Approved, Reason: req.Reason}
+	if err := h.worker.SignalWorkflow(ctx, payment.WorkflowID, "", domain.PaymentReviewSignal, decision); err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			h.respondWithError(w, http.StatusConflict, "Payment workflow already finished")
+			return
+		}
+		log.Printf("Failed to signal review to workflow %s: %v", payment.WorkflowID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to review payment")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusAccepted, map[string]interface{}{"payment_id": payment.ID, "approved": req.Approved})
+}
+
+/*
+	GET  /admin/reconciliation-runs?limit=50
+*/
+func (h *HttpServer) ListReconciliationRuns(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+
+	runs, err := h.repository.ListReconciliationRuns(r.Context(), limit)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list reconciliation runs")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, runs)
+}
+
+/*
+	GET  /admin/reconciliation-runs/{id}
+*/
+func (h *HttpServer) GetReconciliationRun(w http.ResponseWriter, r *http.Request) {
+	runID := chi.URLParam(r, "id")
+
+	run, err := h.repository.GetReconciliationRun(r.Context(), runID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.respondWithError(w, http.StatusNotFound, "Reconciliation run not found")
+			return
+		}
+		log.Printf("Failed to get reconciliation run %s: %v", runID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to fetch reconciliation run")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, run)
+}
+
+type StartReconciliationRequest struct {
+	PeriodStart time.Time `json:"period_start"` // RFC 3339
+	PeriodEnd   time.Time `json:"period_end"`   // exclusive
+}
+
+/*
+	POST /admin/reconciliation-runs
+
+	Starts a ReconciliationWorkflow for the period, outside the daily schedule, e.g. to
+	re-check a day after fixing its discrepancies. Poll GET /admin/reconciliation-runs/{id}
+	for the report.
+*/
+func (h *HttpServer) StartReconciliationRun(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req StartReconciliationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if req.PeriodStart.IsZero() || !req.PeriodEnd.After(req.PeriodStart) {
+		h.respondWithError(w, http.StatusBadRequest, "period_start must be before period_end")
+		return
+	}
+	if req.PeriodEnd.Sub(req.PeriodStart) > maxReconciliationPeriod {
+		h.respondWithError(w, http.StatusBadRequest, "Reconciliation period must not exceed 31 days")
+		return
+	}
+
+	runID := uuid.NewString()
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        "reconciliation-" + runID,
+		TaskQueue: workflow.DefaultTaskQueue,
+	}
+	input := workflow.ReconciliationWorkflowInput{
+		RunID:       runID,
+		PeriodStart: req.PeriodStart.UTC(),
+		PeriodEnd:   req.PeriodEnd.UTC(),
+	}
+
+	we, err := h.worker.ExecuteWorkflow(ctx, workflowOptions, workflow.ReconciliationWorkflow, input)
+	if err != nil {
+		log.Printf("Failed to start reconciliation workflow: %v", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to start reconciliation")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusAccepted, map[string]interface{}{
+		"run_id":      runID,
+		"workflow_id": we.GetID(),
+	})
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/GalaDe/payments-service/internal/auth"
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+/*
+
+API key APIs
+
+
+| Endpoint                      | Description                                  |
+| ----------------------------- | -------------------------------------------- |
+| `POST   /admin/api-keys`      | Create an API key for an internal backend    |
+| `GET    /admin/api-keys`      | List API keys, newest first, without secrets |
+| `DELETE /admin/api-keys/{id}` | Revoke an API key                            |
+
+
+*/
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`   // the backend using the key
+	Scopes []string `json:"scopes"` // e.g. ["payments:read", "payments:write"]
+}
+
+/*
+	POST   /admin/api-keys
+
+	The response holds the key itself, which can't be retrieved again.
+*/
+func (h *HttpServer) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		h.respondWithError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if len(req.Scopes) == 0 {
+		h.respondWithError(w, http.StatusBadRequest, "At least one scope is required")
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !auth.IsKnownScope(scope) {
+			h.respondWithError(w, http.StatusBadRequest, "Unknown scope "+scope)
+			return
+		}
+	}
+
+	secret, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		log.Printf("Failed to generate API key: %v", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+
+	key := &domain.APIKey{
+		ID:         uuid.NewString(),
+		Name:       req.Name,
+		Prefix:     prefix,
+		SecretHash: hash,
+		Scopes:     req.Scopes,
+		CreatedBy:  principal(r).Actor(),
+	}
+	if err := h.repository.CreateAPIKey(r.Context(), key); err != nil {
+		log.Printf("Failed to create API key: %v", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+
+	log.Printf("API key %s (%s) created by %s with scopes %v", key.ID, key.Name, key.CreatedBy, key.Scopes)
+	h.respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"api_key": key,
+		"key":     secret,
+	})
+}
+
+/*
+	GET    /admin/api-keys
+*/
+func (h *HttpServer) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.repository.ListAPIKeys(r.Context())
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list API keys")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, keys)
+}
+
+/*
+	DELETE /admin/api-keys/{id}
+
+	Revoked keys are kept for auditing; requests using them are rejected right away.
+*/
+func (h *HttpServer) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(keyID); err != nil {
+		h.respondWithError(w, http.StatusNotFound, "API key not found")
+		return
+	}
+
+	if err := h.repository.RevokeAPIKey(r.Context(), keyID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.respondWithError(w, http.StatusNotFound, "API key not found or already revoked")
+			return
+		}
+		log.Printf("Failed to revoke API key %s: %v", keyID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to revoke API key")
+		return
+	}
+
+	log.Printf("API key %s revoked by %s", keyID, principal(r).Actor())
+	w.WriteHeader(http.StatusNoContent)
+}
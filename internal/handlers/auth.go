@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/GalaDe/payments-service/internal/auth"
+	"github.com/jackc/pgx/v4"
+)
+
+/*
+	Every route except the health check and the provider webhooks requires a bearer token:
+
+		Authorization: Bearer <JWT>
+		Authorization: Bearer psk_...    (API key of an internal backend)
+
+	The token's subject is the user the request acts for; user IDs and Stripe customer IDs are
+	never taken from the request. Resources of other users answer 404, as if they didn't exist.
+
+	A backend using an API key names the user it acts for in the X-On-Behalf-Of header. Each
+	route requires a scope (see RegisterRoutes); users have every scope but admin, API keys
+	only the ones they were created with.
+*/
+
+const onBehalfOfHeader = "X-On-Behalf-Of"
+
+// authenticate verifies the bearer token and stores the caller's principal in the request context.
+func (h *HttpServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer`)
+			h.respondWithError(w, http.StatusUnauthorized, "Missing bearer token")
+			return
+		}
+
+		var p *auth.Principal
+		var err error
+		if auth.IsAPIKey(token) {
+			p, err = h.verifyAPIKey(r.Context(), token)
+			if p != nil {
+				p.UserID = r.Header.Get(onBehalfOfHeader)
+			}
+		} else {
+			p, err = h.verifier.Verify(token)
+		}
+		if err != nil && !errors.Is(err, auth.ErrInvalidToken) {
+			// The key couldn't be checked, e.g. the database is down; the caller isn't at fault
+			h.respondWithProblem(w, r, err, "Failed to verify API key")
+			return
+		}
+		if err != nil {
+			log.Printf("Rejected token for %s %s: %v", r.Method, r.URL.Path, err)
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			h.respondWithError(w, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+	})
+}
+
+// verifyAPIKey looks the key up and records that it was used. Only a key that doesn't exist,
+// doesn't match or was revoked is an auth.ErrInvalidToken; failed lookups are returned as is.
+func (h *HttpServer) verifyAPIKey(ctx context.Context, token string) (*auth.Principal, error) {
+	prefix, err := auth.ParseAPIKey(token)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := h.repository.GetAPIKeyByPrefix(ctx, prefix)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: unknown API key %s", auth.ErrInvalidToken, prefix)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("look up API key %s: %w", prefix, err)
+	}
+	if !auth.APIKeyMatches(token, key.SecretHash) {
+		return nil, fmt.Errorf("%w: unknown API key %s", auth.ErrInvalidToken, prefix)
+	}
+	if key.IsRevoked() {
+		return nil, fmt.Errorf("%w: API key %s was revoked", auth.ErrInvalidToken, prefix)
+	}
+
+	if err := h.repository.TouchAPIKey(ctx, key.ID); err != nil {
+		log.Printf("Failed to record use of API key %s: %v", key.ID, err)
+	}
+	return &auth.Principal{Scopes: key.Scopes, APIKeyID: key.ID}, nil
+}
+
+/*
+	requireScope rejects callers without scope. It must run after authenticate.
+
+	Except on admin routes, the caller must also act for a user, which an API key only does
+	with the X-On-Behalf-Of header. Admins may act for nobody on read and admin routes, e.g.
+	to list every payment; routes that create or change a user's resources add requireUser.
+*/
+func (h *HttpServer) requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := principal(r)
+			if !p.Permits(scope) {
+				h.respondWithError(w, http.StatusForbidden, "Missing scope "+scope)
+				return
+			}
+			if p.UserID == "" && !p.IsAdmin() {
+				h.respondWithError(w, http.StatusBadRequest, onBehalfOfHeader+" header is required")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireUser rejects callers that don't act for a user, which requireScope lets admins do.
+// Without it an admin API key would link accounts and charge payments for user "".
+func (h *HttpServer) requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principal(r).UserID == "" {
+			h.respondWithError(w, http.StatusBadRequest, onBehalfOfHeader+" header is required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// principal returns the authenticated caller. Without one, e.g. on a route registered outside
+// the authenticated group, it is an empty principal that owns nothing.
+func principal(r *http.Request) *auth.Principal {
+	if p, ok := auth.PrincipalFromContext(r.Context()); ok {
+		return p
+	}
+	return &auth.Principal{}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
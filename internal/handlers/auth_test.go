@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GalaDe/payments-service/internal/auth"
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/jackc/pgx/v4"
+)
+
+// apiKeyRepo serves one API key, as GetAPIKeyByPrefix would after it was created, or fails
+// every lookup with err.
+type apiKeyRepo struct {
+	domain.Repository
+	key *domain.APIKey
+	err error
+}
+
+func (r *apiKeyRepo) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.key, nil
+}
+
+func (r *apiKeyRepo) TouchAPIKey(ctx context.Context, keyID string) error {
+	return nil
+}
+
+func newTestAPIKey(t *testing.T, scopes ...string) (string, *domain.APIKey) {
+	t.Helper()
+
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		t.Fatalf("NewAPIKey: %v", err)
+	}
+	return key, &domain.APIKey{ID: "key-1", Prefix: prefix, SecretHash: hash, Scopes: scopes}
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestRequireScope(t *testing.T) {
+	user := &auth.Principal{UserID: "user-1"}
+	adminUser := &auth.Principal{UserID: "user-1", Scopes: []string{auth.ScopeAdmin}}
+	backend := &auth.Principal{APIKeyID: "key-1", Scopes: []string{auth.ScopePaymentsRead, auth.ScopePaymentsWrite}}
+	backendForUser := &auth.Principal{UserID: "user-1", APIKeyID: "key-1", Scopes: []string{auth.ScopePaymentsRead, auth.ScopePaymentsWrite}}
+	admin := &auth.Principal{APIKeyID: "key-1", Scopes: []string{auth.ScopeAdmin}}
+	adminForUser := &auth.Principal{UserID: "user-1", APIKeyID: "key-1", Scopes: []string{auth.ScopeAdmin}}
+
+	// write is true for the groups that also use requireUser, see RegisterRoutes
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		scope     string
+		write     bool
+		want      int
+	}{
+		{name: "user reads", principal: user, scope: auth.ScopePaymentsRead, want: http.StatusOK},
+		{name: "user writes", principal: user, scope: auth.ScopePaymentsWrite, write: true, want: http.StatusOK},
+		{name: "user refunds", principal: user, scope: auth.ScopeRefundsWrite, write: true, want: http.StatusOK},
+		{name: "user on an admin route", principal: user, scope: auth.ScopeAdmin, want: http.StatusForbidden},
+		{name: "admin user on an admin route", principal: adminUser, scope: auth.ScopeAdmin, want: http.StatusOK},
+		{name: "backend for a user writes", principal: backendForUser, scope: auth.ScopePaymentsWrite, write: true, want: http.StatusOK},
+		{name: "backend for a user without the scope", principal: backendForUser, scope: auth.ScopeRefundsWrite, write: true, want: http.StatusForbidden},
+		{name: "backend for nobody reads", principal: backend, scope: auth.ScopePaymentsRead, want: http.StatusBadRequest},
+		{name: "backend for nobody writes", principal: backend, scope: auth.ScopePaymentsWrite, write: true, want: http.StatusBadRequest},
+		{name: "admin for nobody lists", principal: admin, scope: auth.ScopePaymentsRead, want: http.StatusOK},
+		{name: "admin for nobody on an admin route", principal: admin, scope: auth.ScopeAdmin, want: http.StatusOK},
+		{name: "admin for nobody writes", principal: admin, scope: auth.ScopePaymentsWrite, write: true, want: http.StatusBadRequest},
+		{name: "admin for nobody refunds", principal: admin, scope: auth.ScopeRefundsWrite, write: true, want: http.StatusBadRequest},
+		{name: "admin for a user writes", principal: adminForUser, scope: auth.ScopePaymentsWrite, write: true, want: http.StatusOK},
+		{name: "no principal", principal: nil, scope: auth.ScopePaymentsRead, want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &HttpServer{}
+			next := http.Handler(okHandler)
+			if tt.write {
+				next = h.requireUser(next)
+			}
+			handler := h.requireScope(tt.scope)(next)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.principal != nil {
+				r = r.WithContext(auth.WithPrincipal(r.Context(), tt.principal))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("%s with %+v = %d, want %d", tt.scope, tt.principal, w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteRoutesRequireUser(t *testing.T) {
+	key, apiKey := newTestAPIKey(t, auth.ScopeAdmin)
+	router := RegisterRoutes(&HttpServer{repository: &apiKeyRepo{key: apiKey}})
+
+	// An admin key without X-On-Behalf-Of is stopped before any handler runs
+	tests := []struct {
+		method, path string
+	}{
+		{http.MethodPost, "/plaid/exchange"},
+		{http.MethodPost, "/stripe/payment-method"},
+		{http.MethodPost, "/payments"},
+		{http.MethodPost, "/payments/payment-1/cancel"},
+		{http.MethodPost, "/recurring-payments"},
+		{http.MethodDelete, "/recurring-payments/recurring-1"},
+		{http.MethodPost, "/payments/payment-1/refunds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r.Header.Set("Authorization", "Bearer "+key)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("%s %s = %d, want %d", tt.method, tt.path, w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	key, apiKey := newTestAPIKey(t, auth.ScopePaymentsRead)
+	other, _ := newTestAPIKey(t, auth.ScopePaymentsRead)
+	revoked := *apiKey
+	revoked.RevokedAt = &apiKey.CreatedAt
+
+	// Only a key that doesn't exist, doesn't match or was revoked is the caller's fault
+	tests := []struct {
+		name  string
+		repo  *apiKeyRepo
+		token string
+		want  int
+	}{
+		{name: "valid", repo: &apiKeyRepo{key: apiKey}, token: key, want: http.StatusOK},
+		{name: "unknown", repo: &apiKeyRepo{err: pgx.ErrNoRows}, token: key, want: http.StatusUnauthorized},
+		{name: "other secret", repo: &apiKeyRepo{key: apiKey}, token: other, want: http.StatusUnauthorized},
+		{name: "revoked", repo: &apiKeyRepo{key: &revoked}, token: key, want: http.StatusUnauthorized},
+		{name: "malformed", repo: &apiKeyRepo{key: apiKey}, token: "psk_short", want: http.StatusUnauthorized},
+		{name: "lookup failed", repo: &apiKeyRepo{err: errors.New("connection refused")}, token: key, want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &HttpServer{repository: tt.repo}
+
+			r := httptest.NewRequest(http.MethodGet, "/payments", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			h.authenticate(okHandler).ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Fatalf("authenticate = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v4"
+)
+
+/*
+
+Disputes APIs
+
+
+| Endpoint                       | Description                                      |
+| ------------------------------ | ------------------------------------------------ |
+| `GET  /disputes`               | List disputes, nearest evidence deadline first   |
+| `GET  /disputes/{id}`          | Get a dispute                                    |
+| `POST /disputes/{id}/evidence` | Stage or submit evidence for a dispute to Stripe |
+| `GET  /payments/{id}/disputes` | List disputes raised against a payment           |
+
+
+*/
+
+// Stripe limits each text field of the evidence to 20,000 characters
+const maxDisputeEvidenceText = 20000
+
+/*
+	GET  /disputes?status=needs_response&limit=50
+*/
+func (h *HttpServer) ListDisputes(w http.ResponseWriter, r *http.Request) {
+	limit, err := parseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+
+	disputes, err := h.repository.ListDisputes(r.Context(), r.URL.Query().Get("status"), limit)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to list disputes")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, disputes)
+}
+
+/*
+	GET  /disputes/{id}
+*/
+func (h *HttpServer) GetDispute(w http.ResponseWriter, r *http.Request) {
+	dispute, err := h.repository.GetDispute(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.respondWithError(w, http.StatusNotFound, "Dispute not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to fetch dispute")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, dispute)
+}
+
+/*
+	GET  /payments/{id}/disputes
+*/
+func (h *HttpServer) GetPaymentDisputes(w http.ResponseWriter, r *http.Request) {
+	payment, ok := h.loadPayment(w, r)
+	if !ok {
+		return
+	}
+
+	disputes, err := h.repository.GetDisputesByPaymentID(r.Context(), payment.ID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve disputes")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, disputes)
+}
+
+/*
+	POST /disputes/{id}/evidence
+
+	Sends the evidence to Stripe. Files must already be uploaded to Stripe and are referenced
+	by their file ID. Without "submit" the evidence is only staged and can be replaced until it
+	is submitted; once submitted, the dispute no longer accepts evidence and its deadline alerts stop.
+*/
+func (h *HttpServer) SubmitDisputeEvidence(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	disputeID := chi.URLParam(r, "id")
+
+	var evidence domain.DisputeEvidence
+	if err := json.NewDecoder(r.Body).Decode(&evidence); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if msg := validateDisputeEvidence(&evidence); msg != "" {
+		h.respondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	dispute, err := h.repository.GetDispute(ctx, disputeID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.respondWithError(w, http.StatusNotFound, "Dispute not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to fetch dispute")
+		return
+	}
+
+	if !dispute.AwaitingEvidence() {
+		h.respondWithError(w, http.StatusConflict, "Dispute does not accept evidence in status "+dispute.Status)
+		return
+	}
+
+	updated, err := h.stripeService.UpdateDisputeEvidence(ctx, dispute.StripeDisputeID, &evidence)
+	if err != nil {
+		h.respondWithProblem(w, r, err, "Failed to send evidence to Stripe")
+		return
+	}
+
+	if err := h.repository.UpdateDisputeEvidence(ctx, dispute.ID, updated.Status, evidence.Submit); err != nil {
+		log.Printf("Failed to record evidence for dispute %s: %v", dispute.ID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Evidence was sent to Stripe but could not be recorded")
+		return
+	}
+
+	log.Printf("Sent evidence for dispute %s: submitted=%t files=%d", dispute.ID, evidence.Submit, len(evidence.Files()))
+
+	dispute, err = h.repository.GetDispute(ctx, dispute.ID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to fetch dispute")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, dispute)
+}
+
+// validateDisputeEvidence returns why the evidence is invalid, or "" if it is valid.
+func validateDisputeEvidence(e *domain.DisputeEvidence) string {
+	texts := []string{e.UncategorizedText, e.ProductDescription, e.CustomerName,
+		e.CustomerEmailAddress, e.ServiceDate, e.RefundRefusalExplanation}
+
+	empty := len(e.Files()) == 0
+	for _, text := range texts {
+		if len(text) > maxDisputeEvidenceText {
+			return "Evidence text is too long"
+		}
+		if text != "" {
+			empty = false
+		}
+	}
+	if empty {
+		return "Evidence is empty"
+	}
+
+	for _, file := range e.Files() {
+		if !strings.HasPrefix(file, "file_") {
+			return "Invalid file reference " + file
+		}
+	}
+	return ""
+}
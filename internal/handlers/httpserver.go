@@ -3,7 +3,9 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
+	"github.com/GalaDe/payments-service/internal/auth"
 	"github.com/GalaDe/payments-service/internal/domain"
 	"github.com/GalaDe/payments-service/internal/services/plaid"
 	"github.com/GalaDe/payments-service/internal/services/stripe"
@@ -18,16 +20,21 @@ type HttpServer struct {
 	repository    domain.Repository
 	plaidService  plaid.PlaidService
 	stripeService stripe.StripeService
+	verifier      *auth.JWTVerifier
+	// settlementTimeout is passed to every PaymentWorkflow started by the API
+	settlementTimeout time.Duration
 }
 
 func NewHttpServer(logger *zap.Logger, worker client.Client, repository domain.Repository,
-	plaidService plaid.PlaidService, stripeService stripe.StripeService) *HttpServer {
+	plaidService plaid.PlaidService, stripeService stripe.StripeService, verifier *auth.JWTVerifier, settlementTimeout time.Duration) *HttpServer {
 	return &HttpServer{
-		logger:        logger,
-		worker:        worker,
-		repository:    repository,
-		plaidService:  plaidService,
-		stripeService: stripeService,
+		logger:            logger,
+		worker:            worker,
+		repository:        repository,
+		plaidService:      plaidService,
+		stripeService:     stripeService,
+		verifier:          verifier,
+		settlementTimeout: settlementTimeout,
 	}
 }
 
@@ -36,7 +43,3 @@ func (h *HttpServer) respondWithJSON(w http.ResponseWriter, status int, payload
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(payload)
 }
-
-func (h *HttpServer) respondWithError(w http.ResponseWriter, status int, message string) {
-	h.respondWithJSON(w, status, map[string]string{"error": message})
-}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+/*
+
+Ledger APIs
+
+
+| Endpoint                             | Description                                                     |
+| ------------------------------------ | --------------------------------------------------------------- |
+| `GET  /ledger/balances`              | Balances of a user's ledger accounts, or of the system accounts |
+| `GET  /ledger/accounts/{id}/balance` | Balance of one ledger account                                   |
+| `GET  /payments/{id}/ledger`         | Journal entries posted for a payment                            |
+
+Balances take an optional `as_of` query parameter (RFC 3339) and default to now.
+
+*/
+
+/*
+	GET /ledger/balances?as_of=...
+
+	Returns the caller's accounts. Admins pass user_id to see a user's accounts; without it
+	they get the system accounts (stripe_balance, processing_fees).
+*/
+
+func (h *HttpServer) GetLedgerBalances(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := principal(r).UserID
+	if principal(r).IsAdmin() {
+		userID = r.URL.Query().Get("user_id")
+	}
+
+	asOf, ok := h.ledgerAsOf(w, r)
+	if !ok {
+		return
+	}
+
+	balances, err := h.repository.GetUserLedgerBalances(ctx, userID, asOf)
+	if err != nil {
+		log.Printf("Failed to get ledger balances for user %q: %v", userID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve ledger balances")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, balances)
+}
+
+/*
+	GET /ledger/accounts/{id}/balance?as_of=...
+*/
+
+func (h *HttpServer) GetLedgerAccountBalance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	accountID := chi.URLParam(r, "id")
+
+	asOf, ok := h.ledgerAsOf(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := uuid.Parse(accountID); err != nil {
+		h.respondWithProblem(w, r, domain.ErrLedgerAccountNotFound, "")
+		return
+	}
+
+	// System accounts have no user and are only visible to admins
+	account, err := h.repository.GetLedgerAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = domain.ErrLedgerAccountNotFound
+		}
+		h.respondWithProblem(w, r, err, "Failed to retrieve ledger account")
+		return
+	}
+	if !principal(r).CanAccess(account.UserID) {
+		h.respondWithProblem(w, r, domain.ErrLedgerAccountNotFound, "")
+		return
+	}
+
+	balance, err := h.repository.GetLedgerAccountBalance(ctx, accountID, asOf)
+	if err != nil {
+		log.Printf("Failed to get balance of ledger account %s: %v", accountID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve ledger balance")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, balance)
+}
+
+/*
+	GET /payments/{id}/ledger
+*/
+
+func (h *HttpServer) GetPaymentLedger(w http.ResponseWriter, r *http.Request) {
+	payment, ok := h.loadPayment(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := h.repository.GetJournalEntriesByPaymentID(r.Context(), payment.ID)
+	if err != nil {
+		log.Printf("Failed to get journal entries for payment %s: %v", payment.ID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve journal entries")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, entries)
+}
+
+// ledgerAsOf reads the as_of query parameter, defaulting to now.
+func (h *HttpServer) ledgerAsOf(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	raw := r.URL.Query().Get("as_of")
+	if raw == "" {
+		return time.Now().UTC(), true
+	}
+
+	asOf, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid as_of, expected an RFC 3339 timestamp")
+		return time.Time{}, false
+	}
+	return asOf.UTC(), true
+}
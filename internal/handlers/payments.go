@@ -1,17 +1,28 @@
 package handlers
 
 import (
-	"database/sql"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/GalaDe/payments-service/internal/calendar"
+	"github.com/GalaDe/payments-service/internal/domain"
 	"github.com/GalaDe/payments-service/internal/services/temporal/workflow"
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
-	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v4"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
 )
 
@@ -21,72 +32,227 @@ import (
 Payments APIs (Temporal-powered)
 
 
-| Endpoint              | Description                                     |
-| --------------------- | ----------------------------------------------- |
-| `POST /payments`      | Initiate a payment (starts a Temporal workflow) |
-| `GET  /payments/{id}` | Check payment status                            |
-| `GET  /payments`      | List user’s payments (optionally with filters)  |
+| Endpoint                         | Description                                     |
+| -------------------------------- | ----------------------------------------------- |
+| `POST /payments`                 | Initiate a payment (starts a Temporal workflow) |
+| `GET  /payments/{id}`            | Check payment status                            |
+| `GET  /payments`                 | List payments a page at a time, with filters    |
+| `GET  /payments/{id}/history`    | Status changes of a payment, oldest first       |
+| `POST /payments/{id}/cancel`     | Cancel a payment not yet submitted to ACH       |
+| `POST /payments/{id}/reschedule` | Move a scheduled payment to another date        |
 
 
 */
 
+// CreatePaymentRequest charges the authenticated user from one of their funding accounts,
+// through the Stripe payment method created for that account.
 type CreatePaymentRequest struct {
-	UserID          string `json:"user_id"`
-	CustomerID      string `json:"customer_id"`
-	PaymentMethodID string `json:"payment_method_id"`
-	Amount          int64  `json:"amount"`
-	Currency        string `json:"currency"`
-	Description     string `json:"description"`
+	PaymentMethodID string                    `json:"payment_method_id"` // optional: must be the funding account's payment method
+	PlaidAccountID  string                    `json:"plaid_account_id"`  // optional: funding account, defaults to the user's default account
+	Amount          int64                     `json:"amount"`
+	Currency        string                    `json:"currency"`
+	Description     string                    `json:"description"`
+	Mandate         *domain.MandateAcceptance `json:"mandate"`    // optional: online mandate acceptance details
+	ExecuteAt       *time.Time                `json:"execute_at"` // optional: charge on this date instead of right away
 }
 
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+
+	// Scheduled payments can be at most a year out
+	maxPaymentScheduleHorizon = 365 * 24 * time.Hour
+)
+
 /*
-POST /payments
+	POST /payments
+
+	Clients should send an Idempotency-Key header so a retry after a timeout can't charge twice:
+		1. The first request with a key stores the key, a hash of the body and the payment ID
+		2. A retry with the same body gets the stored response back (Idempotent-Replayed: true),
+		   or, if the first request never finished, resumes it with the same payment and workflow
+		3. Reusing a key with a different body is rejected with 422
+
+	The key also names the Temporal workflow and is sent to Stripe, so neither runs twice.
+	Requests without the header are not deduplicated.
+
+	With execute_at the payment is stored as scheduled and the workflow sleeps until that
+	date. A date on a weekend or Fed holiday moves to the next business day; the response
+	has the final date and the expected settlement date.
 */
 func (h *HttpServer) CreatePayment(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	userID := principal(r).UserID
 	var req CreatePaymentRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
-	if req.CustomerID == "" || req.PaymentMethodID == "" || req.Amount <= 0 || req.Currency == "" {
-		http.Error(w, "Missing or invalid fields", http.StatusBadRequest)
+	if req.Amount <= 0 || req.Currency == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Missing or invalid fields")
 		return
 	}
 
-	workflowID := fmt.Sprintf("payment-%s-%s", req.CustomerID, uuid.NewString())
+	var executeAt *time.Time
+	if req.ExecuteAt != nil {
+		scheduled, err := scheduledPaymentDate(*req.ExecuteAt)
+		if err != nil {
+			h.respondWithProblem(w, r, err, "Invalid execute_at")
+			return
+		}
+		executeAt = &scheduled
+	}
+
+	idempotencyKey := r.Header.Get(idempotencyKeyHeader)
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		h.respondWithError(w, http.StatusBadRequest, "Idempotency-Key is too long")
+		return
+	}
+
+	// Resolve the funding account up front so a bad account_id fails the request, not the workflow
+	plaidToken, err := h.getPlaidToken(ctx, userID, req.PlaidAccountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.respondWithError(w, http.StatusNotFound, "Funding account not found")
+			return
+		}
+		log.Printf("Failed to load funding account: %v", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to load funding account")
+		return
+	}
+
+	// The balance is checked on the funding account, so Stripe must debit that same account
+	paymentMethodID, err := fundingPaymentMethod(plaidToken, req.PaymentMethodID)
+	if err != nil {
+		h.respondWithProblem(w, r, err, "Invalid payment method")
+		return
+	}
+
+	customer, ok := h.checkPaymentMethod(w, r, userID, paymentMethodID)
+	if !ok {
+		return
+	}
+
+	paymentID := uuid.NewString()
+	key := &domain.IdempotencyKey{
+		UserID:      userID,
+		Key:         idempotencyKey,
+		RequestHash: hashRequest(&req),
+		PaymentID:   paymentID,
+	}
+	if idempotencyKey != "" {
+		created, err := h.repository.ClaimIdempotencyKey(ctx, key)
+		if err != nil {
+			h.respondWithProblem(w, r, err, "Failed to check Idempotency-Key")
+			return
+		}
+		if !created && key.ResponseStatus != 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(key.ResponseStatus)
+			w.Write(key.ResponseBody)
+			return
+		}
+		// New key, or the first request died before answering: continue with its payment
+		paymentID = key.PaymentID
+	} else {
+		key.Key = paymentID
+	}
+
+	workflowID := fmt.Sprintf("payment-%s-%s", customer.StripeCustomerID, key.Key)
 
 	workflowOptions := client.StartWorkflowOptions{
 		ID:        workflowID,
-		TaskQueue: "payment-task-queue",
+		TaskQueue: workflow.DefaultTaskQueue,
+		// A finished payment must not be started again by a late retry
+		WorkflowIDReusePolicy: enumspb.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
 	}
 
 	workflowInput := workflow.PaymentWorkflowInput{
-		UserID:          req.UserID,
-		CustomerID:      req.CustomerID,
-		PaymentMethodID: req.PaymentMethodID,
-		Amount:          req.Amount,
-		Currency:        req.Currency,
-		Description:     req.Description,
-		IdempotencyKey:  fmt.Sprintf("%s-%d", req.CustomerID, time.Now().UnixNano()), // Example key
+		PaymentID:         paymentID,
+		UserID:            userID,
+		CustomerID:        customer.StripeCustomerID,
+		PaymentMethodID:   paymentMethodID,
+		PlaidAccountID:    plaidToken.AccountID,
+		PlaidItemID:       plaidToken.ItemID,
+		Amount:            req.Amount,
+		Currency:          req.Currency,
+		Description:       req.Description,
+		Mandate:           req.Mandate.AcceptedBy(time.Now()),
+		SettlementTimeout: h.settlementTimeout,
+		IdempotencyKey:    workflowID,
+		ExecuteAt:         executeAt,
 	}
 
+	runID := ""
 	we, err := h.worker.ExecuteWorkflow(ctx, workflowOptions, workflow.PaymentWorkflow, workflowInput)
+	var alreadyStarted *serviceerror.WorkflowExecutionAlreadyStarted
+	switch {
+	case err == nil:
+		runID = we.GetRunID()
+	case errors.As(err, &alreadyStarted):
+		// An earlier attempt with this key started the workflow and it already finished
+		runID = alreadyStarted.RunId
+	default:
+		h.respondWithProblem(w, r, err, "Failed to start payment workflow")
+		return
+	}
+
+	log.Printf("Started payment workflow: payment_id=%s workflow_id=%s run_id=%s", paymentID, workflowID, runID)
+
+	body := map[string]string{
+		"payment_id":  paymentID,
+		"workflow_id": workflowID,
+		"run_id":      runID,
+		"status":      string(domain.PaymentStatusCreated),
+	}
+	submitAt := time.Now()
+	if executeAt != nil {
+		body["status"] = string(domain.PaymentStatusScheduled)
+		body["execute_at"] = executeAt.Format(time.RFC3339)
+		submitAt = *executeAt
+	}
+	body["expected_settlement_at"] = calendar.ExpectedSettlement(submitAt).UTC().Format(time.RFC3339)
+	response, err := json.Marshal(body)
 	if err != nil {
-		log.Printf("Failed to start payment workflow: %v", err)
-		http.Error(w, "Failed to start payment workflow: "+err.Error(), http.StatusInternalServerError)
+		h.respondWithProblem(w, r, err, "Failed to encode response")
 		return
 	}
 
-	log.Printf("Started payment workflow: workflow_id=%s run_id=%s", we.GetID(), we.GetRunID())
+	if idempotencyKey != "" {
+		key.ResponseStatus = http.StatusAccepted
+		key.ResponseBody = response
+		if err := h.repository.SaveIdempotencyKeyResponse(ctx, key); err != nil {
+			// A retry resumes the same workflow, so the response can still be answered
+			log.Printf("Failed to store response for idempotency key: %v", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	w.Write(response)
+}
 
-	json.NewEncoder(w).Encode(map[string]string{
-		"workflow_id": we.GetID(),
-		"run_id":      we.GetRunID(),
-		"status":      "started",
-	})
+// scheduledPaymentDate checks a requested execute_at and moves it to a business day.
+func scheduledPaymentDate(executeAt time.Time) (time.Time, error) {
+	now := time.Now()
+	if !executeAt.After(now) {
+		return time.Time{}, domain.Validation("invalid_execute_at", "execute_at must be in the future")
+	}
+	if executeAt.After(now.Add(maxPaymentScheduleHorizon)) {
+		return time.Time{}, domain.Validation("invalid_execute_at", "execute_at must be within a year")
+	}
+	return calendar.NextBusinessDay(executeAt.UTC()), nil
+}
+
+// hashRequest fingerprints the decoded request so a reused Idempotency-Key can be told
+// apart from a retry. Hashing the re-encoded struct ignores formatting and field order.
+func hashRequest(req *CreatePaymentRequest) string {
+	encoded, _ := json.Marshal(req)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
 }
 
 /*
@@ -94,42 +260,437 @@ func (h *HttpServer) CreatePayment(w http.ResponseWriter, r *http.Request) {
 */
 
 func (h *HttpServer) GetPaymentByID(w http.ResponseWriter, r *http.Request) {
+	payment, ok := h.loadPayment(w, r)
+	if !ok {
+		return
+	}
+
+	estimateSettlement(payment, time.Now())
+	json.NewEncoder(w).Encode(payment)
+}
+
+// loadPayment fetches the payment named in the URL, answering 404 if it doesn't exist or
+// belongs to another user.
+func (h *HttpServer) loadPayment(w http.ResponseWriter, r *http.Request) (*domain.Payment, bool) {
+	paymentID := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(paymentID); err != nil {
+		h.respondWithProblem(w, r, domain.ErrPaymentNotFound, "")
+		return nil, false
+	}
+
+	payment, err := h.repository.GetPaymentByID(r.Context(), paymentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = domain.ErrPaymentNotFound
+		}
+		h.respondWithProblem(w, r, err, "Failed to fetch payment "+paymentID)
+		return nil, false
+	}
+	if !principal(r).CanAccess(payment.UserID) {
+		h.respondWithProblem(w, r, domain.ErrPaymentNotFound, "")
+		return nil, false
+	}
+	return payment, true
+}
+
+type ListPaymentsResponse struct {
+	Payments   []*domain.Payment `json:"payments"`
+	NextCursor string            `json:"next_cursor,omitempty"` // pass as ?cursor= for the next page, empty on the last one
+	Total      *int64            `json:"total,omitempty"`       // with include_total=true
+}
+
+/*
+	GET  /payments?status=succeeded,refunded&currency=usd&limit=50&cursor=...&include_total=true
+
+	Lists the caller's payments, newest first; admins see every user's payments and may filter
+	by user_id. Filters:
+		user_id, stripe_payment_id, stripe_customer_id, currency    exact match
+		status                                                      comma-separated statuses
+		min_amount, max_amount                                      in cents, inclusive
+		created_from, created_to                                    RFC 3339, to is exclusive
+
+	Follow next_cursor with the same filters to get the next page. Counting the total is a
+	separate query, so it's only done with include_total=true.
+*/
+func (h *HttpServer) GetPayments(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	paymentID := mux.Vars(r)["id"]
+	query := r.URL.Query()
 
-	if paymentID == "" {
-		http.Error(w, "Missing payment ID", http.StatusBadRequest)
+	filter, err := parsePaymentFilter(r)
+	if err != nil {
+		h.respondWithProblem(w, r, err, "Invalid filter")
 		return
 	}
+	p := principal(r)
+	if !p.IsAdmin() {
+		if filter.UserID != "" && filter.UserID != p.UserID {
+			h.respondWithError(w, http.StatusForbidden, "Only admins can list other users' payments")
+			return
+		}
+		filter.UserID = p.UserID
+	}
 
-	payment, err := h.repository.GetPaymentByID(ctx, paymentID)
+	limit, err := parseLimit(query.Get("limit"))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "Payment not found", http.StatusNotFound)
+		h.respondWithError(w, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+	var after *domain.PaymentCursor
+	if c := query.Get("cursor"); c != "" {
+		if after, err = decodePaymentCursor(c); err != nil {
+			h.respondWithProblem(w, r, err, "Invalid cursor")
 			return
 		}
-		http.Error(w, "Failed to fetch payment: "+err.Error(), http.StatusInternalServerError)
+	}
+
+	page, err := h.repository.ListPayments(ctx, filter, after, limit)
+	if err != nil {
+		h.respondWithProblem(w, r, err, "Failed to retrieve payments")
 		return
 	}
+	now := time.Now()
+	for _, payment := range page.Payments {
+		estimateSettlement(payment, now)
+	}
 
-	json.NewEncoder(w).Encode(payment)
+	resp := ListPaymentsResponse{Payments: page.Payments}
+	if page.Next != nil {
+		resp.NextCursor = encodePaymentCursor(page.Next)
+	}
+	if query.Get("include_total") == "true" {
+		total, err := h.repository.CountPayments(ctx, filter)
+		if err != nil {
+			h.respondWithProblem(w, r, err, "Failed to count payments")
+			return
+		}
+		resp.Total = &total
+	}
+
+	h.respondWithJSON(w, http.StatusOK, resp)
+}
+
+func parsePaymentFilter(r *http.Request) (domain.PaymentFilter, error) {
+	query := r.URL.Query()
+	filter := domain.PaymentFilter{
+		UserID:           query.Get("user_id"),
+		Currency:         strings.ToLower(query.Get("currency")),
+		StripePaymentID:  query.Get("stripe_payment_id"),
+		StripeCustomerID: query.Get("stripe_customer_id"),
+	}
+
+	if statuses := query.Get("status"); statuses != "" {
+		for _, s := range strings.Split(statuses, ",") {
+			status := domain.PaymentStatus(strings.TrimSpace(s))
+			if !status.IsValid() {
+				return filter, domain.Validation("invalid_filter", "unknown status "+string(status))
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	var err error
+	if filter.MinAmount, err = parseAmountParam(query, "min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = parseAmountParam(query, "max_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return filter, domain.Validation("invalid_filter", "min_amount must not exceed max_amount")
+	}
+
+	if filter.CreatedFrom, err = parseTimeParam(query, "created_from"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = parseTimeParam(query, "created_to"); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func parseAmountParam(query url.Values, name string) (*int64, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	amount, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || amount < 0 {
+		return nil, domain.Validation("invalid_filter", name+" must be a non-negative amount in cents")
+	}
+	return &amount, nil
+}
+
+func parseTimeParam(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, domain.Validation("invalid_filter", name+" must be an RFC 3339 time")
+	}
+	return &t, nil
+}
+
+// Cursors are opaque to clients: the base64url encoded created_at and ID of the last payment.
+func encodePaymentCursor(c *domain.PaymentCursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.UTC().Format(time.RFC3339Nano) + "," + c.ID))
+}
+
+func decodePaymentCursor(value string) (*domain.PaymentCursor, error) {
+	invalid := domain.Validation("invalid_cursor", "cursor is invalid")
+
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, invalid
+	}
+	createdAt, id, found := strings.Cut(string(decoded), ",")
+	if !found {
+		return nil, invalid
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, invalid
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, invalid
+	}
+	return &domain.PaymentCursor{CreatedAt: t, ID: id}, nil
+}
+
+// estimateSettlement fills in expected_settlement_at for payments not submitted to ACH yet,
+// as if they were submitted at their execute_at date or now. Submitted payments keep the
+// date recorded by the workflow.
+func estimateSettlement(payment *domain.Payment, now time.Time) {
+	if payment.ExpectedSettlementAt != nil {
+		return
+	}
+	switch payment.Status {
+	case domain.PaymentStatusScheduled, domain.PaymentStatusCreated:
+		submitAt := now
+		if payment.ExecuteAt != nil && payment.ExecuteAt.After(now) {
+			submitAt = *payment.ExecuteAt
+		}
+		expected := calendar.ExpectedSettlement(submitAt).UTC()
+		payment.ExpectedSettlementAt = &expected
+	}
 }
 
 /*
-	GET  /payments
+	GET  /payments/{id}/history
 */
-func (h *HttpServer) GetPayments(w http.ResponseWriter, r *http.Request) {
+
+func (h *HttpServer) GetPaymentHistory(w http.ResponseWriter, r *http.Request) {
+	payment, ok := h.loadPayment(w, r)
+	if !ok {
+		return
+	}
+
+	history, err := h.repository.GetPaymentStatusHistory(r.Context(), payment.ID)
+	if err != nil {
+		log.Printf("Failed to get status history for payment %s: %v", payment.ID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve payment history")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, history)
+}
+
+type CancelPaymentRequest struct {
+	Reason string `json:"reason"` // optional, stored in the payment's status history
+}
+
+const (
+	cancelPaymentWait         = 10 * time.Second
+	cancelPaymentPollInterval = 250 * time.Millisecond
+)
+
+/*
+	POST /payments/{id}/cancel
+
+	1. Refuse payments that already reached a final outcome (409)
+	2. Signal the PaymentWorkflow, which cancels the PaymentIntent and marks the payment canceled
+	3. Wait briefly for the workflow's answer:
+		- 200 when the payment was canceled
+		- 409 when it was too late, e.g. the debit was already submitted to the ACH network
+		- 202 when the workflow hasn't answered yet; poll GET /payments/{id}
+*/
+func (h *HttpServer) CancelPayment(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	payments, err := h.repository.GetAllPayments(ctx)
+	var req CancelPaymentRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+	}
+
+	payment, ok := h.loadPayment(w, r)
+	if !ok {
+		return
+	}
+
+	if payment.Status == domain.PaymentStatusCanceled {
+		h.respondWithJSON(w, http.StatusOK, map[string]string{"payment_id": payment.ID, "status": string(payment.Status)})
+		return
+	}
+	if !payment.Status.CanTransitionTo(domain.PaymentStatusCanceled) || payment.WorkflowID == "" {
+		h.respondWithError(w, http.StatusConflict, "Payment is "+string(payment.Status)+" and can no longer be canceled")
+		return
+	}
+
+	err := h.worker.SignalWorkflow(ctx, payment.WorkflowID, "", domain.PaymentCancelSignal, domain.PaymentCancelRequest{Reason: req.Reason})
 	if err != nil {
-		http.Error(w, "Failed to retrieve payments: "+err.Error(), http.StatusInternalServerError)
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			h.respondWithError(w, http.StatusConflict, "Payment workflow already finished, the payment can no longer be canceled")
+			return
+		}
+		log.Printf("Failed to signal cancel to workflow %s: %v", payment.WorkflowID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to cancel payment")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(payments); err != nil {
-		http.Error(w, "Failed to encode response: "+err.Error(), http.StatusInternalServerError)
+	cancellation, err := h.awaitCancellation(ctx, payment.WorkflowID)
+	if err != nil {
+		log.Printf("Failed to query cancellation of workflow %s: %v", payment.WorkflowID, err)
+	}
+
+	switch cancellation.State {
+	case domain.PaymentCancellationCanceled:
+		h.respondWithJSON(w, http.StatusOK, map[string]string{"payment_id": payment.ID, "status": string(domain.PaymentStatusCanceled)})
+	case domain.PaymentCancellationRejected:
+		h.respondWithError(w, http.StatusConflict, "Payment can no longer be canceled: "+cancellation.Reason)
+	default:
+		h.respondWithJSON(w, http.StatusAccepted, map[string]string{"payment_id": payment.ID, "status": "cancel_requested"})
+	}
+}
+
+// awaitCancellation polls the workflow until it answered the cancel request or cancelPaymentWait passed.
+func (h *HttpServer) awaitCancellation(ctx context.Context, workflowID string) (domain.PaymentCancellation, error) {
+	var cancellation domain.PaymentCancellation
+
+	ctx, cancel := context.WithTimeout(ctx, cancelPaymentWait)
+	defer cancel()
+
+	ticker := time.NewTicker(cancelPaymentPollInterval)
+	defer ticker.Stop()
+
+	for {
+		value, err := h.worker.QueryWorkflow(ctx, workflowID, "", domain.PaymentCancellationQuery)
+		if err != nil {
+			return cancellation, err
+		}
+		if err := value.Get(&cancellation); err != nil {
+			return cancellation, err
+		}
+		if cancellation.State != "" && cancellation.State != domain.PaymentCancellationRequested {
+			return cancellation, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return cancellation, nil
+		case <-ticker.C:
+		}
 	}
 }
 
+type ReschedulePaymentRequest struct {
+	ExecuteAt time.Time `json:"execute_at"`
+}
+
+/*
+	POST /payments/{id}/reschedule
+
+	1. Only scheduled payments can move (409 otherwise)
+	2. Signal the PaymentWorkflow with the new date, moved to a business day
+	3. Wait briefly for the workflow to take it:
+	  - 200 with the new execute_at
+	  - 409 when the old date was reached first and the payment is already running
+	  - 202 when the workflow hasn't answered yet; poll GET /payments/{id}
+*/
+func (h *HttpServer) ReschedulePayment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req ReschedulePaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	executeAt, err := scheduledPaymentDate(req.ExecuteAt)
+	if err != nil {
+		h.respondWithProblem(w, r, err, "Invalid execute_at")
+		return
+	}
+
+	payment, ok := h.loadPayment(w, r)
+	if !ok {
+		return
+	}
+	if payment.Status != domain.PaymentStatusScheduled || payment.WorkflowID == "" {
+		h.respondWithError(w, http.StatusConflict, "Payment is "+string(payment.Status)+" and can no longer be rescheduled")
+		return
+	}
+
+	err = h.worker.SignalWorkflow(ctx, payment.WorkflowID, "", domain.PaymentRescheduleSignal, domain.PaymentRescheduleRequest{ExecuteAt: executeAt})
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			h.respondWithError(w, http.StatusConflict, "Payment workflow already finished, the payment can no longer be rescheduled")
+			return
+		}
+		log.Printf("Failed to signal reschedule to workflow %s: %v", payment.WorkflowID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to reschedule payment")
+		return
+	}
+
+	schedule, err := h.awaitReschedule(ctx, payment.WorkflowID, executeAt)
+	if err != nil {
+		log.Printf("Failed to query schedule of workflow %s: %v", payment.WorkflowID, err)
+	}
+
+	switch {
+	case schedule.ExecuteAt.Equal(executeAt):
+		h.respondWithJSON(w, http.StatusOK, map[string]string{
+			"payment_id": payment.ID,
+			"status":     string(domain.PaymentStatusScheduled),
+			"execute_at": executeAt.Format(time.RFC3339),
+		})
+	case schedule.Started:
+		h.respondWithError(w, http.StatusConflict, "Payment already started and can no longer be rescheduled")
+	default:
+		h.respondWithJSON(w, http.StatusAccepted, map[string]string{"payment_id": payment.ID, "status": "reschedule_requested"})
+	}
+}
+
+// awaitReschedule polls the workflow until it took executeAt, started the payment, or cancelPaymentWait passed.
+func (h *HttpServer) awaitReschedule(ctx context.Context, workflowID string, executeAt time.Time) (domain.PaymentSchedule, error) {
+	var schedule domain.PaymentSchedule
+
+	ctx, cancel := context.WithTimeout(ctx, cancelPaymentWait)
+	defer cancel()
+
+	ticker := time.NewTicker(cancelPaymentPollInterval)
+	defer ticker.Stop()
+
+	for {
+		value, err := h.worker.QueryWorkflow(ctx, workflowID, "", domain.PaymentScheduleQuery)
+		if err != nil {
+			return schedule, err
+		}
+		if err := value.Get(&schedule); err != nil {
+			return schedule, err
+		}
+		if schedule.ExecuteAt.Equal(executeAt) || schedule.Started {
+			return schedule, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return schedule, nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 
 	"github.com/GalaDe/payments-service/internal/domain"
-	"github.com/GalaDe/payments-service/internal/services/plaid"
-	"github.com/gorilla/mux"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v4"
 )
 
 type CreateProcessorTokenRequest struct {
@@ -16,7 +19,6 @@ type CreateProcessorTokenRequest struct {
 
 type ExchangeTokenRequest struct {
 	PublicToken string `json:"public_token"`
-	UserID      string `json:"user_id"`
 }
 
 type ExchangeTokenResponse struct {
@@ -31,9 +33,10 @@ type ExchangeTokenResponse struct {
 | ----------------------------- | ------------------------------------------- |
 | `POST /plaid/link-token`      | Create a link token for the frontend        |
 | `POST /plaid/exchange`        | Exchange a public token for an access token |
-| `GET  /plaid/accounts`        | Fetch linked bank accounts                  |
+| `GET  /plaid/accounts`        | Fetch all linked bank accounts              |
+| `POST /plaid/accounts/{id}/default` | Pick the account that funds payments  |
 | `POST /plaid/processor-token` | Create a processor token for Stripe         |
-| `DELETE /plaid/account/{id}`  | Unlink/delete a bank account                |
+| `DELETE /plaid/items/{id}`    | Unlink an item and all of its accounts      |
 
 */
 
@@ -42,25 +45,16 @@ type ExchangeTokenResponse struct {
 
 	POST /plaid/link-token
 
-	1. Accept a user_id (or derive from auth/session)
+	1. Take the user from the access token
 	2. Call CreateLinkToken from your PlaidService
 	3. Return a link_token in the response
 
 	Link token is a short live(30 min), single use per session.
 */
 func (h *HttpServer) CreateLinkToken(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		UserID string `json:"user_id"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == "" {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
-		return
-	}
-
-	token, err := h.plaidService.CreateLinkToken(r.Context(), req.UserID)
+	token, err := h.plaidService.CreateLinkToken(r.Context(), principal(r).UserID)
 	if err != nil {
-		http.Error(w, "Failed to create link token", http.StatusInternalServerError)
+		h.respondWithProblem(w, r, err, "Failed to create link token")
 		return
 	}
 
@@ -80,7 +74,9 @@ func (h *HttpServer) CreateLinkToken(w http.ResponseWriter, r *http.Request) {
 
 	1. access_token (used in API calls)
 	2. item_id (Plaid's internal reference)
-	3. account_id (the selected account)
+
+	and then stores the item with every account AccountsGet returns for it. The first
+	checking account becomes the user's default funding account if they had none.
 
 	ExchangePublicToken exchanges a short-lived public_token for a long lived access_token
 */
@@ -88,69 +84,122 @@ func (h *HttpServer) CreateLinkToken(w http.ResponseWriter, r *http.Request) {
 func (h *HttpServer) ExchangePublicToken(w http.ResponseWriter, r *http.Request) {
 	var req ExchangeTokenRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PublicToken == "" {
-		http.Error(w, "Invalid request", http.StatusBadRequest)
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+	ctx := r.Context()
+
+	resp, err := h.plaidService.ExchangePublicToken(ctx, req.PublicToken)
+	if err != nil {
+		h.respondWithProblem(w, r, err, "Failed to exchange token")
 		return
 	}
 
-	resp, err := h.plaidService.ExchangePublicToken(r.Context(), req.PublicToken)
+	itemAccounts, err := h.plaidService.GetItemAccounts(ctx, resp.AccessToken)
 	if err != nil {
-		http.Error(w, "Failed to exchange token", http.StatusInternalServerError)
+		h.respondWithProblem(w, r, err, "Failed to get accounts for item "+resp.ItemID)
 		return
 	}
 
-	plaidToken := domain.PlaidToken{
-		UserID:      req.UserID,
-		AccessToken: resp.AccessToken,
-		AccountID:   resp.AccountID,
-		ItemID:      resp.ItemID,
+	item := &domain.PlaidItem{
+		ItemID:          resp.ItemID,
+		UserID:          principal(r).UserID,
+		AccessToken:     resp.AccessToken,
+		InstitutionID:   itemAccounts.InstitutionID,
+		InstitutionName: itemAccounts.InstitutionName,
+	}
+	accounts := make([]*domain.PlaidAccount, 0, len(itemAccounts.Accounts))
+	for _, account := range itemAccounts.Accounts {
+		accounts = append(accounts, &domain.PlaidAccount{
+			AccountID:    account.AccountID,
+			Name:         account.Name,
+			OfficialName: account.OfficialName,
+			Mask:         account.Mask,
+			Type:         account.Type,
+			Subtype:      account.Subtype,
+		})
 	}
 
-	if err := h.repository.StorePlaidToken(r.Context(), plaidToken); err != nil {
-		http.Error(w, "Failed to save token", http.StatusInternalServerError)
+	if err := h.repository.SavePlaidItem(ctx, item, accounts); err != nil {
+		log.Printf("Failed to save plaid item %s: %v", resp.ItemID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to save token")
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":   "ok",
+		"item_id":  resp.ItemID,
+		"accounts": itemAccounts.Accounts,
+	})
+}
+
+type PlaidAccountResponse struct {
+	*domain.PlaidAccount
+	Balance *float64 `json:"balance,omitempty"`
 }
 
 /*
-	GET  /plaid/accounts
+	GET  /plaid/accounts?with_balance=true
+
+	Returns every account across all of the caller's items with mask, subtype and institution,
+	default funding account first. with_balance=true adds the live balance from Plaid.
 */
 func (h *HttpServer) GetPlaidAccounts(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	// Extract userID from query param or header
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "missing user_id", http.StatusBadRequest)
-		return
-	}
+	userID := principal(r).UserID
 
 	// Optional flag: ?with_balance=true
 	withBal := r.URL.Query().Get("with_balance") == "true"
 
-	token, err := h.repository.GetPlaidToken(ctx, userID)
+	accounts, err := h.repository.ListPlaidAccounts(ctx, userID)
 	if err != nil {
-		http.Error(w, "user has no linked account", http.StatusNotFound)
+		log.Printf("Failed to list plaid accounts: %v", err)
+		h.respondWithError(w, http.StatusInternalServerError, "failed to list accounts")
 		return
 	}
 
-	if withBal {
-		acc, err := h.plaidService.GetAccountWithBalance(ctx, token.AccessToken, token.AccountID)
-		if err != nil {
-			http.Error(w, "plaid balance error: "+err.Error(), http.StatusInternalServerError)
-			return
+	resp := make([]PlaidAccountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		accountResp := PlaidAccountResponse{PlaidAccount: account}
+		if withBal {
+			token, err := h.repository.GetPlaidTokenForAccount(ctx, userID, account.AccountID)
+			if err != nil {
+				h.respondWithError(w, http.StatusInternalServerError, "failed to load account token")
+				return
+			}
+			acc, err := h.plaidService.GetAccountWithBalance(ctx, token.AccessToken, token.AccountID)
+			if err != nil {
+				h.respondWithProblem(w, r, err, "Failed to get account balance")
+				return
+			}
+			accountResp.Balance = &acc.Balance
 		}
-		json.NewEncoder(w).Encode([]*plaid.AccountWithBalance{acc})
-		return
+		resp = append(resp, accountResp)
 	}
 
-	acc, err := h.plaidService.GetAccount(ctx, token.AccessToken, token.AccountID)
-	if err != nil {
-		http.Error(w, "plaid account error: "+err.Error(), http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(resp)
+}
+
+/*
+	POST /plaid/accounts/{id}/default
+
+	Makes one of the caller's accounts their default funding account for payments.
+*/
+func (h *HttpServer) SetDefaultPlaidAccount(w http.ResponseWriter, r *http.Request) {
+	accountID := chi.URLParam(r, "id")
+
+	if err := h.repository.SetDefaultPlaidAccount(r.Context(), principal(r).UserID, accountID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.respondWithError(w, http.StatusNotFound, "Account not found")
+			return
+		}
+		log.Printf("Failed to set default plaid account: %v", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to set default account")
 		return
 	}
-	json.NewEncoder(w).Encode([]*plaid.Account{acc})
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 /*
@@ -160,24 +209,26 @@ func (h *HttpServer) CreateProcessorTokenForStripe(w http.ResponseWriter, r *htt
 	ctx := r.Context()
 
 	var req struct {
-		UserID string `json:"user_id"`
+		AccountID string `json:"account_id"` // optional, defaults to the user's default account
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.UserID == "" {
-		http.Error(w, "Invalid request: missing user_id", http.StatusBadRequest)
-		return
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
 	}
 
-	// Fetch stored Plaid token from DB
-	plaidToken, err := h.repository.GetPlaidToken(ctx, req.UserID)
+	// Fetch stored Plaid token from DB, only ever one of the caller's accounts
+	plaidToken, err := h.getPlaidToken(ctx, principal(r).UserID, req.AccountID)
 	if err != nil {
-		http.Error(w, "Failed to get Plaid token: "+err.Error(), http.StatusNotFound)
+		h.respondWithProblem(w, r, err, "Failed to get Plaid token")
 		return
 	}
 
 	// Create Stripe bank account token using Plaid
 	stripeToken, err := h.plaidService.CreateStripeToken(ctx, plaidToken.AccessToken, plaidToken.AccountID)
 	if err != nil {
-		http.Error(w, "Failed to create Stripe token: "+err.Error(), http.StatusInternalServerError)
+		h.respondWithProblem(w, r, err, "Failed to create Stripe token")
 		return
 	}
 
@@ -187,27 +238,64 @@ func (h *HttpServer) CreateProcessorTokenForStripe(w http.ResponseWriter, r *htt
 }
 
 /*
-	DELETE /plaid/account/{id}
+	DELETE /plaid/items/{id}
 
-	Removes a user's linked Plaid account (and token) from both:
+	Removes a user's linked Plaid item (and all of its accounts) from both:
 
 		- Plaid (via ItemRemove)
-		- Database (via repository method like DeletePlaidToken)
+		- Database (via DeletePlaidItem)
 */
 
-func (h *HttpServer) DeletePlaidAccount(w http.ResponseWriter, r *http.Request) {
+func (h *HttpServer) DeletePlaidItem(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	userID := mux.Vars(r)["id"]
+	itemID := chi.URLParam(r, "id")
+
+	if itemID == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Missing item ID")
+		return
+	}
+
+	item, err := h.repository.GetPlaidItem(ctx, itemID)
+	if err != nil || !principal(r).CanAccess(item.UserID) {
+		h.respondWithError(w, http.StatusNotFound, "Item not found")
+		return
+	}
 
-	if userID == "" {
-		http.Error(w, "Missing user ID", http.StatusBadRequest)
+	if _, err := h.plaidService.DeletePlaidBankAccount(ctx, item.AccessToken); err != nil {
+		h.respondWithProblem(w, r, err, "Failed to remove Plaid item")
 		return
 	}
 
-	if err := h.repository.DeletePlaidToken(ctx, userID); err != nil {
-		http.Error(w, "Failed to delete Plaid token: "+err.Error(), http.StatusInternalServerError)
+	if err := h.repository.DeletePlaidItem(ctx, item.UserID, itemID); err != nil {
+		h.respondWithProblem(w, r, err, "Failed to delete Plaid item")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// getPlaidToken loads the credentials for accountID, or for the user's default
+// funding account when accountID is empty.
+func (h *HttpServer) getPlaidToken(ctx context.Context, userID, accountID string) (*domain.PlaidToken, error) {
+	if accountID == "" {
+		return h.repository.GetPlaidToken(ctx, userID)
+	}
+	return h.repository.GetPlaidTokenForAccount(ctx, userID, accountID)
+}
+
+/*
+fundingPaymentMethod returns the Stripe payment method that debits the funding account, so the
+balance check, the charge and the ledger all describe the same bank account. An empty
+paymentMethodID takes the account's; one that debits another account is rejected.
+*/
+func fundingPaymentMethod(plaidToken *domain.PlaidToken, paymentMethodID string) (string, error) {
+	switch {
+	case plaidToken.PaymentMethodID == "":
+		return "", domain.Validation("funding_account_not_linked",
+			"funding account has no payment method, create one with its plaid_account_id")
+	case paymentMethodID != "" && paymentMethodID != plaidToken.PaymentMethodID:
+		return "", domain.Validation("payment_method_mismatch",
+			"payment_method_id doesn't debit the funding account")
+	}
+	return plaidToken.PaymentMethodID, nil
+}
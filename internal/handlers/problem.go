@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/jackc/pgx/v4"
+)
+
+/*
+	Every error response is an RFC 7807 problem:
+
+		HTTP/1.1 404 Not Found
+		Content-Type: application/problem+json
+
+		{
+			"type": "urn:payments-service:problem:payment_not_found",
+			"title": "Not Found",
+			"status": 404,
+			"detail": "payment not found",
+			"instance": "/payments/0b6c...",
+			"code": "payment_not_found"
+		}
+
+	code is stable and meant for clients to switch on; type is derived from it. detail is for
+	humans and may change. Errors of Stripe and Plaid are never passed through verbatim.
+*/
+
+const problemTypePrefix = "urn:payments-service:problem:"
+
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+}
+
+// Codes of errors that aren't a domain.Error, by HTTP status.
+var statusCodes = map[int]string{
+	http.StatusBadRequest:          "invalid_request",
+	http.StatusUnauthorized:        "unauthenticated",
+	http.StatusForbidden:           "forbidden",
+	http.StatusNotFound:            "not_found",
+	http.StatusConflict:            "conflict",
+	http.StatusUnprocessableEntity: "unprocessable",
+	http.StatusServiceUnavailable:  "unavailable",
+	http.StatusInternalServerError: "internal_error",
+}
+
+var kindStatuses = map[domain.ErrorKind]int{
+	domain.KindNotFound:            http.StatusNotFound,
+	domain.KindConflict:            http.StatusConflict,
+	domain.KindValidation:          http.StatusBadRequest,
+	domain.KindUnprocessable:       http.StatusUnprocessableEntity,
+	domain.KindProviderDeclined:    http.StatusPaymentRequired,
+	domain.KindProviderUnavailable: http.StatusServiceUnavailable,
+	domain.KindInternal:            http.StatusInternalServerError,
+}
+
+/*
+	respondWithProblem answers with the problem matching err. For a domain.Error its code is
+	used; validation, unprocessable and conflict errors show the whole error, which only carries
+	our own messages, every other kind just its Message. Internal errors and errors with a cause
+	are logged. pgx.ErrNoRows answers 404. Other errors are logged and answer 500 with fallback
+	as the detail.
+*/
+func (h *HttpServer) respondWithProblem(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	p := Problem{Instance: r.URL.Path}
+
+	if e, ok := domain.AsError(err); ok {
+		p.Status = kindStatuses[e.Kind]
+		p.Code = e.Code
+		p.Detail = e.Message
+		switch e.Kind {
+		case domain.KindValidation, domain.KindUnprocessable, domain.KindConflict:
+			p.Detail = err.Error()
+		}
+		if e.Err != nil || e.Kind == domain.KindInternal {
+			log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+		}
+	} else if errors.Is(err, pgx.ErrNoRows) {
+		p.Status = http.StatusNotFound
+		p.Code = statusCodes[p.Status]
+		p.Detail = "Resource not found"
+	} else {
+		log.Printf("%s %s: %s: %v", r.Method, r.URL.Path, fallback, err)
+		p.Status = http.StatusInternalServerError
+		p.Code = statusCodes[p.Status]
+		p.Detail = fallback
+	}
+
+	h.writeProblem(w, p)
+}
+
+// respondWithError answers with a problem of the generic code for status.
+func (h *HttpServer) respondWithError(w http.ResponseWriter, status int, message string) {
+	code, ok := statusCodes[status]
+	if !ok {
+		code = strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	}
+	h.writeProblem(w, Problem{Status: status, Detail: message, Code: code})
+}
+
+func (h *HttpServer) writeProblem(w http.ResponseWriter, p Problem) {
+	p.Type = problemTypePrefix + p.Code
+	p.Title = http.StatusText(p.Status)
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,414 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/GalaDe/payments-service/internal/services/temporal/workflow"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+)
+
+/*
+
+Recurring Payments APIs (Temporal Schedules)
+
+
+| Endpoint                                  | Description                                          |
+| ----------------------------------------- | ---------------------------------------------------- |
+| `POST   /recurring-payments`              | Create a recurring payment and its schedule          |
+| `GET    /recurring-payments`              | List the caller's recurring payments                 |
+| `GET    /recurring-payments/{id}`         | Get a recurring payment                              |
+| `PATCH  /recurring-payments/{id}`         | Change amount, payment method, description or end_at |
+| `DELETE /recurring-payments/{id}`         | Cancel a recurring payment and delete its schedule   |
+| `POST   /recurring-payments/{id}/pause`   | Stop charging until resumed                          |
+| `POST   /recurring-payments/{id}/resume`  | Resume charging from the next cycle                  |
+| `GET    /recurring-payments/{id}/preview` | Upcoming charge dates (?count=, default 5)           |
+
+
+*/
+
+// CreateRecurringPaymentRequest charges the authenticated user, like CreatePaymentRequest.
+type CreateRecurringPaymentRequest struct {
+	PaymentMethodID string     `json:"payment_method_id"` // optional: must be the funding account's payment method
+	PlaidAccountID  string     `json:"plaid_account_id"`  // optional: funding account, defaults to the user's default account
+	Amount          int64      `json:"amount"`
+	Currency        string     `json:"currency"`
+	Description     string     `json:"description"`
+	Interval        string     `json:"interval"`  // daily, weekly, monthly, yearly
+	AnchorAt        time.Time  `json:"anchor_at"` // first charge, RFC 3339
+	EndAt           *time.Time `json:"end_at"`    // optional
+}
+
+// UpdateRecurringPaymentRequest only changes the fields that are set.
+type UpdateRecurringPaymentRequest struct {
+	Amount          *int64     `json:"amount"`
+	PaymentMethodID *string    `json:"payment_method_id"` // must be the funding account's payment method
+	Description     *string    `json:"description"`
+	EndAt           *time.Time `json:"end_at"`
+}
+
+const (
+	defaultRecurringPreviewCount = 5
+	maxRecurringPreviewCount     = 50
+)
+
+/*
+	POST /recurring-payments
+
+	1. Resolve the funding account and its payment method, like POST /payments
+	2. Store the recurring payment
+	3. Create the Temporal Schedule that starts a PaymentWorkflow every cycle;
+	   if that fails the stored row is removed again
+*/
+func (h *HttpServer) CreateRecurringPayment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := principal(r).UserID
+	var req CreateRecurringPaymentRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if req.Currency == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Missing or invalid fields")
+		return
+	}
+
+	plaidToken, err := h.getPlaidToken(ctx, userID, req.PlaidAccountID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.respondWithError(w, http.StatusNotFound, "Funding account not found")
+			return
+		}
+		log.Printf("Failed to load funding account: %v", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to load funding account")
+		return
+	}
+
+	paymentMethodID, err := fundingPaymentMethod(plaidToken, req.PaymentMethodID)
+	if err != nil {
+		h.respondWithProblem(w, r, err, "Invalid payment method")
+		return
+	}
+
+	customer, ok := h.checkPaymentMethod(w, r, userID, paymentMethodID)
+	if !ok {
+		return
+	}
+
+	id := uuid.NewString()
+	recurring := &domain.RecurringPayment{
+		ID:              id,
+		UserID:          userID,
+		CustomerID:      customer.StripeCustomerID,
+		PaymentMethodID: paymentMethodID,
+		PlaidAccountID:  plaidToken.AccountID,
+		PlaidItemID:     plaidToken.ItemID,
+		Amount:          req.Amount,
+		Currency:        req.Currency,
+		Description:     req.Description,
+		Interval:        req.Interval,
+		AnchorAt:        req.AnchorAt.UTC(),
+		EndAt:           req.EndAt,
+		Status:          domain.RecurringPaymentStatusActive,
+		ScheduleID:      workflow.RecurringPaymentScheduleID(id),
+	}
+	if err := recurring.Validate(); err != nil {
+		h.respondWithProblem(w, r, err, "Invalid recurring payment")
+		return
+	}
+	if !recurring.AnchorAt.After(time.Now()) {
+		h.respondWithError(w, http.StatusBadRequest, "anchor_at must be in the future")
+		return
+	}
+
+	if err := h.repository.CreateRecurringPayment(ctx, recurring); err != nil {
+		log.Printf("Failed to create recurring payment: %v", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to create recurring payment")
+		return
+	}
+
+	_, err = h.worker.ScheduleClient().Create(ctx, workflow.RecurringPaymentScheduleOptions(recurring, h.settlementTimeout))
+	if err != nil {
+		log.Printf("Failed to create schedule for recurring payment %s: %v", recurring.ID, err)
+		if err := h.repository.DeleteRecurringPayment(ctx, recurring.ID); err != nil {
+			log.Printf("Failed to remove recurring payment %s without schedule: %v", recurring.ID, err)
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to schedule recurring payment")
+		return
+	}
+
+	log.Printf("Created recurring payment: id=%s schedule_id=%s", recurring.ID, recurring.ScheduleID)
+	h.respondWithJSON(w, http.StatusCreated, recurring)
+}
+
+/*
+	GET /recurring-payments
+*/
+
+func (h *HttpServer) GetRecurringPayments(w http.ResponseWriter, r *http.Request) {
+	recurring, err := h.repository.ListRecurringPayments(r.Context(), principal(r).UserID)
+	if err != nil {
+		log.Printf("Failed to list recurring payments: %v", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve recurring payments")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, recurring)
+}
+
+/*
+	GET /recurring-payments/{id}
+*/
+
+func (h *HttpServer) GetRecurringPayment(w http.ResponseWriter, r *http.Request) {
+	recurring, ok := h.loadRecurringPayment(w, r)
+	if !ok {
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, recurring)
+}
+
+/*
+	PATCH /recurring-payments/{id}
+
+	The schedule is updated with the new values; cycles that already started keep theirs.
+*/
+func (h *HttpServer) UpdateRecurringPayment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req UpdateRecurringPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	recurring, ok := h.loadRecurringPayment(w, r)
+	if !ok {
+		return
+	}
+	if recurring.Status == domain.RecurringPaymentStatusCanceled {
+		h.respondWithError(w, http.StatusConflict, "Recurring payment is canceled")
+		return
+	}
+
+	if req.Amount != nil {
+		recurring.Amount = *req.Amount
+	}
+	if req.PaymentMethodID != nil && *req.PaymentMethodID != recurring.PaymentMethodID {
+		// Only a payment method re-created for the same funding account can replace it
+		plaidToken, err := h.repository.GetPlaidTokenForAccount(ctx, recurring.UserID, recurring.PlaidAccountID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				h.respondWithError(w, http.StatusNotFound, "Funding account not found")
+				return
+			}
+			h.respondWithProblem(w, r, err, "Failed to load funding account")
+			return
+		}
+		paymentMethodID, err := fundingPaymentMethod(plaidToken, *req.PaymentMethodID)
+		if err != nil {
+			h.respondWithProblem(w, r, err, "Invalid payment method")
+			return
+		}
+		if _, ok := h.checkPaymentMethod(w, r, recurring.UserID, paymentMethodID); !ok {
+			return
+		}
+		recurring.PaymentMethodID = paymentMethodID
+	}
+	if req.Description != nil {
+		recurring.Description = *req.Description
+	}
+	if req.EndAt != nil {
+		recurring.EndAt = req.EndAt
+	}
+	if err := recurring.Validate(); err != nil {
+		h.respondWithProblem(w, r, err, "Invalid recurring payment")
+		return
+	}
+
+	handle := h.worker.ScheduleClient().GetHandle(ctx, recurring.ScheduleID)
+	err := handle.Update(ctx, client.ScheduleUpdateOptions{
+		DoUpdate: func(input client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
+			schedule := workflow.RecurringPaymentSchedule(recurring, h.settlementTimeout)
+			// Keep the running state (paused, notes) as Temporal has it
+			schedule.State = input.Description.Schedule.State
+			return &client.ScheduleUpdate{Schedule: schedule}, nil
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to update schedule %s: %v", recurring.ScheduleID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to update recurring payment schedule")
+		return
+	}
+
+	if err := h.repository.UpdateRecurringPayment(ctx, recurring); err != nil {
+		log.Printf("Failed to update recurring payment %s: %v", recurring.ID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to update recurring payment")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, recurring)
+}
+
+/*
+	DELETE /recurring-payments/{id}
+
+	Deletes the schedule so no further cycles start; payments already started run to completion.
+	The recurring payment is kept as canceled for its payment history.
+*/
+func (h *HttpServer) DeleteRecurringPayment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	recurring, ok := h.loadRecurringPayment(w, r)
+	if !ok {
+		return
+	}
+
+	if recurring.Status != domain.RecurringPaymentStatusCanceled {
+		handle := h.worker.ScheduleClient().GetHandle(ctx, recurring.ScheduleID)
+		if err := handle.Delete(ctx); err != nil && !isScheduleNotFound(err) {
+			log.Printf("Failed to delete schedule %s: %v", recurring.ScheduleID, err)
+			h.respondWithError(w, http.StatusInternalServerError, "Failed to delete recurring payment schedule")
+			return
+		}
+	}
+
+	if err := h.repository.UpdateRecurringPaymentStatus(ctx, recurring.ID, domain.RecurringPaymentStatusCanceled); err != nil {
+		log.Printf("Failed to cancel recurring payment %s: %v", recurring.ID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to cancel recurring payment")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]string{"id": recurring.ID, "status": domain.RecurringPaymentStatusCanceled})
+}
+
+/*
+	POST /recurring-payments/{id}/pause
+*/
+
+func (h *HttpServer) PauseRecurringPayment(w http.ResponseWriter, r *http.Request) {
+	h.setRecurringPaymentPaused(w, r, true)
+}
+
+/*
+	POST /recurring-payments/{id}/resume
+
+	Cycles missed while paused are skipped, charging resumes with the next one.
+*/
+
+func (h *HttpServer) ResumeRecurringPayment(w http.ResponseWriter, r *http.Request) {
+	h.setRecurringPaymentPaused(w, r, false)
+}
+
+func (h *HttpServer) setRecurringPaymentPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	ctx := r.Context()
+
+	recurring, ok := h.loadRecurringPayment(w, r)
+	if !ok {
+		return
+	}
+	if recurring.Status == domain.RecurringPaymentStatusCanceled {
+		h.respondWithError(w, http.StatusConflict, "Recurring payment is canceled")
+		return
+	}
+
+	handle := h.worker.ScheduleClient().GetHandle(ctx, recurring.ScheduleID)
+	status := domain.RecurringPaymentStatusActive
+	var err error
+	if paused {
+		status = domain.RecurringPaymentStatusPaused
+		err = handle.Pause(ctx, client.SchedulePauseOptions{Note: "paused through the API"})
+	} else {
+		err = handle.Unpause(ctx, client.ScheduleUnpauseOptions{Note: "resumed through the API"})
+	}
+	if err != nil {
+		log.Printf("Failed to set paused=%t on schedule %s: %v", paused, recurring.ScheduleID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to update recurring payment schedule")
+		return
+	}
+
+	if err := h.repository.UpdateRecurringPaymentStatus(ctx, recurring.ID, status); err != nil {
+		log.Printf("Failed to update recurring payment %s: %v", recurring.ID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to update recurring payment")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]string{"id": recurring.ID, "status": status})
+}
+
+/*
+	GET /recurring-payments/{id}/preview?count=
+
+	Lists the next charge dates from the recurring payment's calendar. A paused recurring
+	payment shows the dates it would charge on once resumed.
+*/
+func (h *HttpServer) PreviewRecurringPayment(w http.ResponseWriter, r *http.Request) {
+	count := defaultRecurringPreviewCount
+	if raw := r.URL.Query().Get("count"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxRecurringPreviewCount {
+			h.respondWithError(w, http.StatusBadRequest, "count must be between 1 and "+strconv.Itoa(maxRecurringPreviewCount))
+			return
+		}
+		count = parsed
+	}
+
+	recurring, ok := h.loadRecurringPayment(w, r)
+	if !ok {
+		return
+	}
+
+	runs := []time.Time{}
+	if recurring.Status != domain.RecurringPaymentStatusCanceled {
+		runs = recurring.NextRuns(time.Now(), count)
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"id":        recurring.ID,
+		"status":    recurring.Status,
+		"next_runs": runs,
+	})
+}
+
+// loadRecurringPayment fetches the recurring payment named in the URL, answering 404 if it doesn't
+// exist or belongs to another user.
+func (h *HttpServer) loadRecurringPayment(w http.ResponseWriter, r *http.Request) (*domain.RecurringPayment, bool) {
+	recurringID := chi.URLParam(r, "id")
+	if _, err := uuid.Parse(recurringID); err != nil {
+		h.respondWithError(w, http.StatusNotFound, "Recurring payment not found")
+		return nil, false
+	}
+
+	recurring, err := h.repository.GetRecurringPaymentByID(r.Context(), recurringID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			h.respondWithError(w, http.StatusNotFound, "Recurring payment not found")
+			return nil, false
+		}
+		log.Printf("Failed to fetch recurring payment %s: %v", recurringID, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to fetch recurring payment")
+		return nil, false
+	}
+	if !principal(r).CanAccess(recurring.UserID) {
+		h.respondWithError(w, http.StatusNotFound, "Recurring payment not found")
+		return nil, false
+	}
+	return recurring, true
+}
+
+func isScheduleNotFound(err error) bool {
+	var notFound *serviceerror.NotFound
+	return errors.As(err, &notFound)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/GalaDe/payments-service/internal/services/temporal/workflow"
+	"github.com/stripe/stripe-go/v75"
+	"go.temporal.io/sdk/client"
+)
+
+/*
+
+Refunds APIs (Temporal-powered)
+
+
+| Endpoint                      | Description                                      |
+| ----------------------------- | ------------------------------------------------ |
+| `POST /payments/{id}/refunds` | Refund a payment in full or in part              |
+| `GET  /payments/{id}/refunds` | List refunds issued against a payment            |
+
+
+*/
+
+type CreateRefundRequest struct {
+	Amount int64  `json:"amount"` // in cents; omit or 0 to refund the remaining amount
+	Reason string `json:"reason"` // optional: duplicate, fraudulent, requested_by_customer
+}
+
+/*
+	POST /payments/{id}/refunds
+
+	1. Record a pending refund, rejecting amounts above what is left to refund
+	2. Start a RefundWorkflow that calls Stripe and stores the outcome
+	3. Return the refund ID so the client can poll GET /payments/{id}/refunds
+*/
+func (h *HttpServer) CreateRefund(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req CreateRefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if req.Amount < 0 || !isValidRefundReason(req.Reason) {
+		h.respondWithError(w, http.StatusBadRequest, "Missing or invalid fields")
+		return
+	}
+
+	payment, ok := h.loadPayment(w, r)
+	if !ok {
+		return
+	}
+
+	refund := &domain.Refund{
+		PaymentID: payment.ID,
+		Amount:    req.Amount,
+		Reason:    req.Reason,
+	}
+	if err := h.repository.CreateRefund(ctx, refund); err != nil {
+		h.respondWithProblem(w, r, err, "Failed to create refund")
+		return
+	}
+
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        "refund-" + refund.ID,
+		TaskQueue: workflow.DefaultTaskQueue,
+	}
+
+	workflowInput := workflow.RefundWorkflowInput{
+		RefundID:        refund.ID,
+		PaymentID:       payment.ID,
+		PaymentIntentID: payment.StripePaymentID,
+		Amount:          refund.Amount,
+		Reason:          refund.Reason,
+	}
+
+	we, err := h.worker.ExecuteWorkflow(ctx, workflowOptions, workflow.RefundWorkflow, workflowInput)
+	if err != nil {
+		log.Printf("Failed to start refund workflow: %v", err)
+		refund.Status = domain.RefundStatusFailed
+		refund.FailureReason = "failed to start refund workflow"
+		if err := h.repository.UpdateRefund(ctx, refund); err != nil {
+			log.Printf("Failed to mark refund %s as failed: %v", refund.ID, err)
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to start refund workflow")
+		return
+	}
+
+	log.Printf("Started refund workflow: refund_id=%s workflow_id=%s run_id=%s", refund.ID, we.GetID(), we.GetRunID())
+
+	h.respondWithJSON(w, http.StatusAccepted, map[string]interface{}{
+		"refund_id":   refund.ID,
+		"payment_id":  payment.ID,
+		"amount":      refund.Amount,
+		"workflow_id": we.GetID(),
+		"status":      refund.Status,
+	})
+}
+
+/*
+	GET  /payments/{id}/refunds
+*/
+func (h *HttpServer) GetRefunds(w http.ResponseWriter, r *http.Request) {
+	payment, ok := h.loadPayment(w, r)
+	if !ok {
+		return
+	}
+
+	refunds, err := h.repository.GetRefundsByPaymentID(r.Context(), payment.ID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve refunds")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, refunds)
+}
+
+func isValidRefundReason(reason string) bool {
+	switch stripe.RefundReason(reason) {
+	case "", stripe.RefundReasonDuplicate, stripe.RefundReasonFraudulent, stripe.RefundReasonRequestedByCustomer:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+/*
+
+ACH Returns APIs
+
+
+| Endpoint                      | Description                                              |
+| ----------------------------- | -------------------------------------------------------- |
+| `GET  /payments/{id}/returns` | List ACH returns for a payment and how they were handled |
+
+
+*/
+
+/*
+	GET /payments/{id}/returns
+*/
+
+func (h *HttpServer) GetACHReturns(w http.ResponseWriter, r *http.Request) {
+	payment, ok := h.loadPayment(w, r)
+	if !ok {
+		return
+	}
+
+	returns, err := h.repository.GetACHReturnsByPaymentID(r.Context(), payment.ID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to retrieve ACH returns")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, returns)
+}
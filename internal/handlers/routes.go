@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 
+	"github.com/GalaDe/payments-service/internal/auth"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -14,26 +15,88 @@ func RegisterRoutes(h *HttpServer) *chi.Mux {
 		w.Write([]byte("Payments service is running"))
 	})
 
-	// Plaid routes
-	r.Post("/plaid/link-token", h.CreateLinkToken)
-	r.Post("/plaid/exchange", h.ExchangePublicToken)
-	r.Get("/plaid/accounts", h.GetPlaidAccounts)
-	r.Post("/plaid/processor-token", h.CreateProcessorTokenForStripe)
-	r.Delete("/plaid/account/{id}", h.DeletePlaidAccount)
+	// Webhooks, authenticated by the provider's signature
+	r.Post("/webhook/plaid", h.PlaidWebhook)
+	r.Post("/webhook/stripe", h.StripeWebhook)
 
-	// Stripe routes
-	r.Post("/stripe/payment-method", h.CreateStripePaymentMethod)
-	r.Get("/stripe/payment-methods", h.GetStripePaymentMethod)
-	r.Delete("/stripe/payment-method/{id}", h.DeleteStripePaymentMethod)
+	r.Group(func(r chi.Router) {
+		r.Use(h.authenticate)
 
-	// Payment routes
-	r.Post("/payments", h.CreatePayment)
-	r.Get("/payments", h.GetPayments)
-	r.Get("/payments/{id}", h.GetPaymentByID)
+		r.Group(func(r chi.Router) {
+			r.Use(h.requireScope(auth.ScopePaymentsRead))
 
-	// Webhooks
-	r.Post("/webhook/plaid", h.PlaidWebhook)
-	r.Post("/webhook/stripe", h.StripeWebhook)
+			r.Get("/plaid/accounts", h.GetPlaidAccounts)
+			r.Get("/stripe/payment-methods", h.GetStripePaymentMethod)
+
+			r.Get("/payments", h.GetPayments)
+			r.Get("/payments/{id}", h.GetPaymentByID)
+			r.Get("/payments/{id}/history", h.GetPaymentHistory)
+			r.Get("/payments/{id}/refunds", h.GetRefunds)
+			r.Get("/payments/{id}/returns", h.GetACHReturns)
+			r.Get("/payments/{id}/disputes", h.GetPaymentDisputes)
+			r.Get("/payments/{id}/ledger", h.GetPaymentLedger)
+
+			r.Get("/recurring-payments", h.GetRecurringPayments)
+			r.Get("/recurring-payments/{id}", h.GetRecurringPayment)
+			r.Get("/recurring-payments/{id}/preview", h.PreviewRecurringPayment)
+
+			r.Get("/ledger/balances", h.GetLedgerBalances)
+			r.Get("/ledger/accounts/{id}/balance", h.GetLedgerAccountBalance)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.requireScope(auth.ScopePaymentsWrite), h.requireUser)
+
+			// Plaid routes
+			r.Post("/plaid/link-token", h.CreateLinkToken)
+			r.Post("/plaid/exchange", h.ExchangePublicToken)
+			r.Post("/plaid/accounts/{id}/default", h.SetDefaultPlaidAccount)
+			r.Post("/plaid/processor-token", h.CreateProcessorTokenForStripe)
+			r.Delete("/plaid/items/{id}", h.DeletePlaidItem)
+
+			// Stripe routes
+			r.Post("/stripe/payment-method", h.CreateStripePaymentMethod)
+			r.Delete("/stripe/payment-method/{id}", h.DeleteStripePaymentMethod)
+
+			// Payment routes
+			r.Post("/payments", h.CreatePayment)
+			r.Post("/payments/{id}/cancel", h.CancelPayment)
+			r.Post("/payments/{id}/reschedule", h.ReschedulePayment)
+
+			// Recurring payment routes
+			r.Post("/recurring-payments", h.CreateRecurringPayment)
+			r.Patch("/recurring-payments/{id}", h.UpdateRecurringPayment)
+			r.Delete("/recurring-payments/{id}", h.DeleteRecurringPayment)
+			r.Post("/recurring-payments/{id}/pause", h.PauseRecurringPayment)
+			r.Post("/recurring-payments/{id}/resume", h.ResumeRecurringPayment)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(h.requireScope(auth.ScopeRefundsWrite), h.requireUser)
+
+			r.Post("/payments/{id}/refunds", h.CreateRefund)
+		})
+
+		// Admin routes
+		r.Group(func(r chi.Router) {
+			r.Use(h.requireScope(auth.ScopeAdmin))
+
+			r.Get("/admin/webhooks", h.ListWebhookEvents)
+			r.Post("/admin/webhooks/replay", h.ReplayWebhookEvents)
+			r.Post("/admin/payments/{id}/review", h.ReviewPayment)
+			r.Get("/admin/reconciliation-runs", h.ListReconciliationRuns)
+			r.Post("/admin/reconciliation-runs", h.StartReconciliationRun)
+			r.Get("/admin/reconciliation-runs/{id}", h.GetReconciliationRun)
+			r.Post("/admin/api-keys", h.CreateAPIKey)
+			r.Get("/admin/api-keys", h.ListAPIKeys)
+			r.Delete("/admin/api-keys/{id}", h.RevokeAPIKey)
+
+			// Disputes are answered by the operators, not the paying user
+			r.Get("/disputes", h.ListDisputes)
+			r.Get("/disputes/{id}", h.GetDispute)
+			r.Post("/disputes/{id}/evidence", h.SubmitDisputeEvidence)
+		})
+	})
 
 	return r
 }
@@ -1,10 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
-	"github.com/gorilla/mux"
+	"github.com/GalaDe/payments-service/internal/domain"
+	stripesvc "github.com/GalaDe/payments-service/internal/services/stripe"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v4"
+	"github.com/stripe/stripe-go/v75"
 )
 
 /*
@@ -13,11 +20,16 @@ import (
 | `POST /stripe/payment-method`        | Create a payment method using Plaid processor token |
 | `GET  /stripe/payment-methods`       | List stored payment methods                         |
 | `DELETE /stripe/payment-method/{id}` | Delete a payment method                             |
+
+Payment methods belong to the caller's Stripe customer, which is created with their first one.
 */
 
+// CreatePaymentMethodRequest takes either a Plaid account or a processor token. Only a payment
+// method created from a Plaid account can fund payments, since the service can't tell which
+// account a processor token debits.
 type CreatePaymentMethodRequest struct {
 	ProcessorToken string `json:"processor_token"`
-	CustomerID     string `json:"customer_id"`
+	PlaidAccountID string `json:"plaid_account_id"` // the bank token is created from this account and the payment method bound to it
 }
 
 /*
@@ -25,19 +37,53 @@ type CreatePaymentMethodRequest struct {
 */
 
 func (h *HttpServer) CreateStripePaymentMethod(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := principal(r).UserID
+
 	var req CreatePaymentMethodRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ProcessorToken == "" || req.CustomerID == "" {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.ProcessorToken == "") == (req.PlaidAccountID == "") {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.PlaidAccountID != "" {
+		plaidToken, err := h.repository.GetPlaidTokenForAccount(ctx, userID, req.PlaidAccountID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				h.respondWithError(w, http.StatusNotFound, "Funding account not found")
+				return
+			}
+			h.respondWithProblem(w, r, err, "Failed to load funding account")
+			return
+		}
+		stripeToken, err := h.plaidService.CreateStripeToken(ctx, plaidToken.AccessToken, plaidToken.AccountID)
+		if err != nil {
+			h.respondWithProblem(w, r, err, "Failed to create Stripe token")
+			return
+		}
+		req.ProcessorToken = *stripeToken
+	}
+
+	customer, err := h.getOrCreateStripeCustomer(ctx, userID)
+	if err != nil {
+		h.respondWithProblem(w, r, err, "Failed to get Stripe customer")
 		return
 	}
 
 	// Call the service to create the payment method
-	pm, err := h.stripeService.CreatePaymentMethodFromBankToken(r.Context(), req.CustomerID, req.ProcessorToken)
+	pm, err := h.stripeService.CreatePaymentMethodFromBankToken(ctx, customer.StripeCustomerID, req.ProcessorToken)
 	if err != nil {
-		http.Error(w, "Stripe payment method creation failed: "+err.Error(), http.StatusInternalServerError)
+		h.respondWithProblem(w, r, err, "Stripe payment method creation failed")
 		return
 	}
 
+	if req.PlaidAccountID != "" {
+		if err := h.repository.SetPlaidAccountPaymentMethod(ctx, userID, req.PlaidAccountID, pm.ID); err != nil {
+			h.respondWithProblem(w, r, err, "Failed to link payment method to funding account")
+			return
+		}
+	}
+
 	json.NewEncoder(w).Encode(pm)
 }
 
@@ -46,16 +92,23 @@ func (h *HttpServer) CreateStripePaymentMethod(w http.ResponseWriter, r *http.Re
 */
 
 func (h *HttpServer) GetStripePaymentMethod(w http.ResponseWriter, r *http.Request) {
-	customerID := r.URL.Query().Get("customer_id")
-	if customerID == "" {
-		http.Error(w, "Missing required query parameter: customer_id", http.StatusBadRequest)
+	ctx := r.Context()
+
+	customer, err := h.repository.GetStripeCustomerByUserID(ctx, principal(r).UserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// No Stripe customer yet, so no payment methods either
+			h.respondWithJSON(w, http.StatusOK, []*stripe.PaymentMethod{})
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to get Stripe customer")
 		return
 	}
 
 	// Optional: filter by type (e.g., "us_bank_account")
-	paymentMethods, err := h.stripeService.GetCustomerPaymentMethods(r.Context(), customerID, "us_bank_account")
+	paymentMethods, err := h.stripeService.GetCustomerPaymentMethods(ctx, customer.StripeCustomerID, "us_bank_account")
 	if err != nil {
-		http.Error(w, "Failed to retrieve payment methods: "+err.Error(), http.StatusInternalServerError)
+		h.respondWithProblem(w, r, err, "Failed to retrieve payment methods")
 		return
 	}
 
@@ -70,18 +123,78 @@ func (h *HttpServer) GetStripePaymentMethod(w http.ResponseWriter, r *http.Reque
 
 func (h *HttpServer) DeleteStripePaymentMethod(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	paymentMethodID := mux.Vars(r)["payment_method_id"]
+	paymentMethodID := chi.URLParam(r, "id")
 
 	if paymentMethodID == "" {
-		http.Error(w, "Missing payment_method_id", http.StatusBadRequest)
+		h.respondWithError(w, http.StatusBadRequest, "Missing payment_method_id")
+		return
+	}
+
+	if _, ok := h.checkPaymentMethod(w, r, principal(r).UserID, paymentMethodID); !ok {
 		return
 	}
 
 	err := h.stripeService.DeleteStripePaymentMethod(ctx, paymentMethodID)
 	if err != nil {
-		http.Error(w, "Failed to delete Stripe payment method: "+err.Error(), http.StatusInternalServerError)
+		h.respondWithProblem(w, r, err, "Failed to delete Stripe payment method")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+/*
+userPaymentMethod returns the user's Stripe customer after checking that the payment method is
+attached to it. A payment method that doesn't exist, is detached or belongs to another customer
+is reported as domain.ErrPaymentMethodNotFound.
+*/
+func (h *HttpServer) userPaymentMethod(ctx context.Context, userID, paymentMethodID string) (*domain.StripeCustomer, error) {
+	customer, err := h.repository.GetStripeCustomerByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrPaymentMethodNotFound
+		}
+		return nil, err
+	}
+
+	pm, err := h.stripeService.RetrievePaymentMethod(ctx, paymentMethodID)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.HTTPStatusCode == http.StatusNotFound {
+			return nil, domain.ErrPaymentMethodNotFound
+		}
+		return nil, err
+	}
+	if pm.CustomerID == "" || pm.CustomerID != customer.StripeCustomerID {
+		return nil, domain.ErrPaymentMethodNotFound
+	}
+	return customer, nil
+}
+
+// checkPaymentMethod is userPaymentMethod for handlers, answering 404 for a payment method
+// that isn't the user's.
+func (h *HttpServer) checkPaymentMethod(w http.ResponseWriter, r *http.Request, userID, paymentMethodID string) (*domain.StripeCustomer, bool) {
+	customer, err := h.userPaymentMethod(r.Context(), userID, paymentMethodID)
+	if err != nil {
+		h.respondWithProblem(w, r, err, "Failed to check payment method")
+		return nil, false
+	}
+	return customer, true
+}
+
+// getOrCreateStripeCustomer returns the user's Stripe customer, creating it on first use.
+func (h *HttpServer) getOrCreateStripeCustomer(ctx context.Context, userID string) (*domain.StripeCustomer, error) {
+	customer, err := h.repository.GetStripeCustomerByUserID(ctx, userID)
+	if err == nil || !errors.Is(err, pgx.ErrNoRows) {
+		return customer, err
+	}
+
+	customer, err = h.stripeService.CreateStripeCustomer(&stripesvc.CreateStripeCustomerInput{UserID: &userID})
+	if err != nil {
+		return nil, fmt.Errorf("stripe customer creation failed: %w", err)
+	}
+	if err := h.repository.InsertStripeCustomer(ctx, customer); err != nil {
+		return nil, fmt.Errorf("failed to store stripe customer: %w", err)
+	}
+	return customer, nil
+}
@@ -1,72 +1,166 @@
 package handlers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 
-	"github.com/stripe/stripe-go/v75"
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/GalaDe/payments-service/internal/services/plaid"
+	"github.com/GalaDe/payments-service/internal/services/temporal/workflow"
+	"go.temporal.io/sdk/client"
 )
 
 /*
 
 | Endpoint               | Description                                            |
 | ---------------------- | ------------------------------------------------------ |
-| `POST /webhook/plaid`  | Receive events from Plaid (e.g., transactions updated) |
+| `POST /webhook/plaid`  | Receive events from Plaid (item errors, auth updates)  |
 | `POST /webhook/stripe` | Handle Stripe events (payment succeeded, failed, etc.) |
 
+Both endpoints only verify the provider signature (the plaid-verification JWT for Plaid,
+Stripe-Signature for Stripe) and store the raw event in the webhook_events inbox, then hand it
+to a WebhookEventWorkflow. Redelivered events are deduplicated on the provider event ID.
+Plaid events have none; they are deduplicated on the body hash and the time Plaid signed them,
+since Plaid sends the same body again for a new event, e.g. a second ITEM_LOGIN_REQUIRED.
+
 */
 
+const maxWebhookBodyBytes = int64(65536)
+
+/*
+	POST /webhook/plaid
+
+	1. Verify the plaid-verification JWT: ES256 signature, issued within the last 5 minutes,
+	   and its request_body_sha256 claim matching the raw body
+	2. Store the event and process it asynchronously (item health, auth updates)
+*/
 func (h *HttpServer) PlaidWebhook(w http.ResponseWriter, r *http.Request) {
-	var webhookEvent map[string]interface{}
+	r.Body = http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes)
 
-	if err := json.NewDecoder(r.Body).Decode(&webhookEvent); err != nil {
-		http.Error(w, "Invalid Plaid webhook payload", http.StatusBadRequest)
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.respondWithError(w, http.StatusServiceUnavailable, "Error reading webhook request")
 		return
 	}
 
-	// Example: Handle TRANSACTIONS_UPDATED
-	if webhookEvent["webhook_type"] == "TRANSACTIONS" && webhookEvent["webhook_code"] == "TRANSACTIONS_UPDATED" {
-		log.Printf("Plaid transactions updated: %+v", webhookEvent)
-		// Optionally: update local transaction cache, trigger downstream workflows, etc.
+	verification := r.Header.Get("Plaid-Verification")
+	verified, err := h.plaidService.VerifyWebhook(string(payload), map[string]string{
+		"plaid-verification": verification,
+	})
+	if err != nil || !verified {
+		log.Printf("Rejected Plaid webhook: %v", err)
+		h.respondWithError(w, http.StatusUnauthorized, "Invalid Plaid webhook signature")
+		return
+	}
+	issuedAt, err := plaid.WebhookIssuedAt(verification)
+	if err != nil {
+		h.respondWithError(w, http.StatusUnauthorized, "Invalid Plaid webhook signature")
+		return
+	}
+
+	var webhookEvent struct {
+		WebhookType string `json:"webhook_type"`
+		WebhookCode string `json:"webhook_code"`
+	}
+	if err := json.Unmarshal(payload, &webhookEvent); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid Plaid webhook payload")
+		return
+	}
+
+	event := &domain.WebhookEvent{
+		Provider:  domain.WebhookProviderPlaid,
+		EventID:   plaidWebhookEventID(payload, issuedAt),
+		EventType: webhookEvent.WebhookType + "." + webhookEvent.WebhookCode,
+		Payload:   payload,
+	}
+
+	if err := h.enqueueWebhookEvent(r.Context(), event); err != nil {
+		log.Printf("Failed to enqueue Plaid webhook: %v", err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to store Plaid webhook")
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
+/*
+	POST /webhook/stripe
+
+	1. Verify the Stripe-Signature header against the raw body, rejecting forged or stale events
+	2. Store the event and process it asynchronously
+*/
 func (h *HttpServer) StripeWebhook(w http.ResponseWriter, r *http.Request) {
-	const MaxBodyBytes = int64(65536)
-	r.Body = http.MaxBytesReader(w, r.Body, MaxBodyBytes)
+	r.Body = http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes)
 
 	payload, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "Error reading webhook request", http.StatusServiceUnavailable)
+		h.respondWithError(w, http.StatusServiceUnavailable, "Error reading webhook request")
 		return
 	}
 
-	event := stripe.Event{}
-	if err := json.Unmarshal(payload, &event); err != nil {
-		http.Error(w, "Invalid Stripe webhook payload", http.StatusBadRequest)
+	stripeEvent, err := h.stripeService.ConstructWebhookEvent(payload, r.Header.Get("Stripe-Signature"))
+	if err != nil {
+		log.Printf("Rejected Stripe webhook: %v", err)
+		h.respondWithError(w, http.StatusBadRequest, "Invalid Stripe webhook signature")
 		return
 	}
 
-	switch event.Type {
-	case "charge.succeeded":
-		var charge stripe.Charge
-		if err := json.Unmarshal(event.Data.Raw, &charge); err == nil {
-			log.Printf("Charge succeeded for: %s", charge.ID)
-			// Update payment status in DB
-		}
-	case "charge.failed":
-		var charge stripe.Charge
-		if err := json.Unmarshal(event.Data.Raw, &charge); err == nil {
-			log.Printf("Charge failed for: %s", charge.ID)
-			// Update payment status in DB
-		}
-	default:
-		log.Printf("Unhandled event type: %s", event.Type)
+	event := &domain.WebhookEvent{
+		Provider:  domain.WebhookProviderStripe,
+		EventID:   stripeEvent.ID,
+		EventType: string(stripeEvent.Type),
+		Payload:   payload,
+	}
+
+	if err := h.enqueueWebhookEvent(r.Context(), event); err != nil {
+		log.Printf("Failed to enqueue Stripe event %s (%s): %v", stripeEvent.ID, stripeEvent.Type, err)
+		h.respondWithError(w, http.StatusInternalServerError, "Failed to store Stripe webhook")
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// plaidWebhookEventID identifies a Plaid webhook, which carries no event ID, by its body hash
+// and the iat of its verified JWT. A delivery retried with the same token is a duplicate; the
+// same body signed at another time is a new event.
+func plaidWebhookEventID(payload []byte, issuedAt int64) string {
+	sum := sha256.Sum256(payload)
+	return fmt.Sprintf("%s:%d", hex.EncodeToString(sum[:]), issuedAt)
+}
+
+// enqueueWebhookEvent stores the event and starts its processing workflow.
+// Duplicates that were already picked up are acknowledged without starting
+// another run; an event stuck in "received" (e.g. the workflow failed to start
+// on the first delivery) is started again.
+func (h *HttpServer) enqueueWebhookEvent(ctx context.Context, event *domain.WebhookEvent) error {
+	created, err := h.repository.SaveWebhookEvent(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	if !created && event.Status != domain.WebhookEventStatusReceived {
+		log.Printf("Duplicate %s webhook event %s ignored", event.Provider, event.EventID)
+		return nil
+	}
+
+	return h.startWebhookEventWorkflow(ctx, event.ID)
+}
+
+func (h *HttpServer) startWebhookEventWorkflow(ctx context.Context, eventID string) error {
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        workflow.WebhookEventWorkflowID(eventID),
+		TaskQueue: workflow.DefaultTaskQueue,
+	}
+
+	if _, err := h.worker.ExecuteWorkflow(ctx, workflowOptions, workflow.WebhookEventWorkflow, eventID); err != nil {
+		return fmt.Errorf("failed to start webhook workflow for event %s: %w", eventID, err)
+	}
+	return nil
+}
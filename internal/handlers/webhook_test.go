@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/GalaDe/payments-service/internal/services/webhook"
+)
+
+func TestPlaidWebhookEventID(t *testing.T) {
+	loginRequired := []byte(`{"webhook_type":"ITEM","webhook_code":"ERROR","item_id":"item-1","error":{"error_code":"ITEM_LOGIN_REQUIRED"}}`)
+	repaired := []byte(`{"webhook_type":"ITEM","webhook_code":"LOGIN_REPAIRED","item_id":"item-1"}`)
+
+	tests := []struct {
+		name       string
+		a, b       []byte
+		iatA, iatB int64
+		same       bool
+	}{
+		{name: "redelivery of the same token", a: loginRequired, b: loginRequired, iatA: 1740830400, iatB: 1740830400, same: true},
+		{name: "same body signed later", a: loginRequired, b: loginRequired, iatA: 1740830400, iatB: 1740834000},
+		{name: "different body, same second", a: loginRequired, b: repaired, iatA: 1740830400, iatB: 1740830400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := plaidWebhookEventID(tt.a, tt.iatA), plaidWebhookEventID(tt.b, tt.iatB)
+			if (a == b) != tt.same {
+				t.Fatalf("event IDs %q and %q: same = %v, want %v", a, b, a == b, tt.same)
+			}
+		})
+	}
+}
+
+// itemStatusRepo records the Plaid item statuses the processor writes; nothing else is called.
+type itemStatusRepo struct {
+	domain.Repository
+	statuses []string
+}
+
+func (r *itemStatusRepo) UpdatePlaidItemStatus(ctx context.Context, itemID, status, errorCode, errorMessage, webhookCode string) error {
+	r.statuses = append(r.statuses, status)
+	return nil
+}
+
+func TestPlaidItemHealthReplay(t *testing.T) {
+	loginRequired := []byte(`{"webhook_type":"ITEM","webhook_code":"ERROR","item_id":"item-1","error":{"error_code":"ITEM_LOGIN_REQUIRED","error_message":"the login details of this item have changed"}}`)
+	repaired := []byte(`{"webhook_type":"ITEM","webhook_code":"LOGIN_REPAIRED","item_id":"item-1"}`)
+
+	deliveries := []struct {
+		payload  []byte
+		issuedAt int64
+	}{
+		{loginRequired, 1740830400},
+		{loginRequired, 1740830400}, // Plaid retried the first delivery
+		{repaired, 1740834000},
+		{loginRequired, 1740916800}, // the user's bank password changed again
+	}
+
+	repo := &itemStatusRepo{}
+	processor := webhook.NewProcessor(repo, nil)
+	inbox := make(map[string]bool)
+	for _, d := range deliveries {
+		id := plaidWebhookEventID(d.payload, d.issuedAt)
+		if inbox[id] {
+			continue
+		}
+		inbox[id] = true
+
+		event := &domain.WebhookEvent{Provider: domain.WebhookProviderPlaid, EventID: id, Payload: d.payload}
+		if _, err := processor.Process(context.Background(), event); err != nil {
+			t.Fatalf("Process: %v", err)
+		}
+	}
+
+	want := []string{domain.PlaidItemStatusLoginRequired, domain.PlaidItemStatusHealthy, domain.PlaidItemStatusLoginRequired}
+	if len(repo.statuses) != len(want) {
+		t.Fatalf("item statuses %v, want %v", repo.statuses, want)
+	}
+	for i := range want {
+		if repo.statuses[i] != want[i] {
+			t.Fatalf("item statuses %v, want %v", repo.statuses, want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package plaid
+
+import (
+	"errors"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/plaid/plaid-go/v12/plaid"
+)
+
+// ErrBankAccountNotFound is returned when an account isn't part of the Plaid item.
+var ErrBankAccountNotFound = domain.NotFound("bank_account_not_found", "bank account not found")
+
+/*
+fromPlaidError maps an error of the Plaid SDK onto the domain errors the API reports,
+keeping it in the chain. Plaid's messages aren't stable and are only logged.
+*/
+func fromPlaidError(err error) error {
+	var apiErr plaid.GenericOpenAPIError
+	if !errors.As(err, &apiErr) {
+		return domain.ProviderUnavailable("plaid_unavailable", "Plaid is unavailable, try again later", err)
+	}
+	plaidErr, parseErr := plaid.ToPlaidError(apiErr)
+	if parseErr != nil {
+		// Not a Plaid error body, e.g. an HTML page from a proxy
+		return domain.ProviderUnavailable("plaid_unavailable", "Plaid is unavailable, try again later", err)
+	}
+
+	switch plaidErr.ErrorType {
+	case plaid.PLAIDERRORTYPE_ITEM_ERROR:
+		if plaidErr.ErrorCode == "ITEM_LOGIN_REQUIRED" {
+			// The user must go through Plaid Link in update mode
+			return domain.ProviderDeclined("bank_login_required", "The bank requires the user to log in again", err)
+		}
+		return domain.ProviderDeclined("bank_account_unusable", "The linked bank account can't be used", err)
+	case plaid.PLAIDERRORTYPE_INVALID_INPUT:
+		// e.g. an expired public token or a removed item's access token
+		return &domain.Error{Kind: domain.KindValidation, Code: "plaid_invalid_input", Message: "Plaid rejected the bank account credentials", Err: err}
+	case plaid.PLAIDERRORTYPE_INSTITUTION_ERROR:
+		return domain.ProviderUnavailable("bank_unavailable", "The bank is unavailable, try again later", err)
+	case plaid.PLAIDERRORTYPE_RATE_LIMIT_EXCEEDED, plaid.PLAIDERRORTYPE_API_ERROR:
+		return domain.ProviderUnavailable("plaid_unavailable", "Plaid is unavailable, try again later", err)
+	default:
+		return domain.ProviderDeclined("plaid_request_declined", "Plaid declined the request", err)
+	}
+}
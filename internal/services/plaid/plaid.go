@@ -5,6 +5,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
@@ -15,6 +16,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/GalaDe/payments-service/internal/domain"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/plaid/plaid-go/v12/plaid"
 	"go.uber.org/zap"
@@ -26,6 +28,7 @@ const (
 
 type Plaid struct {
 	client *plaid.APIClient
+	keys   *WebhookKeyCache
 }
 
 const (
@@ -40,6 +43,7 @@ type PlaidService interface {
 	ExchangePublicToken(ctx context.Context, publicToken string) (*ExchangeTokenResponse, error)
 	CreateProcessorToken(ctx context.Context, accessToken, accountID string) (string, error)
 	GetAccount(ctx context.Context, accessToken, accountID string) (*Account, error)
+	GetItemAccounts(ctx context.Context, accessToken string) (*ItemAccounts, error)
 	GetAccountWithBalance(ctx context.Context, accessToken, accountID string) (*AccountWithBalance, error)
 	CreatePlaidBankAccount(ctx context.Context) (*CreatePlaidBankAccountResponse, error)
 	DeletePlaidBankAccount(ctx context.Context, accessToken string) (*string, error)
@@ -65,7 +69,9 @@ func New(opts *PlaidOpts) PlaidService {
 
 	client := plaid.NewAPIClient(config)
 
-	return &Plaid{client}
+	p := &Plaid{client: client}
+	p.keys = NewWebhookKeyCache(p.fetchWebhookKey, opts.KeyStore)
+	return p
 }
 
 // CreateLinkToken generates a new Plaid Link token for the specified user ID.
@@ -89,7 +95,7 @@ func (p *Plaid) CreateLinkToken(ctx context.Context, userID string) (string, err
 
 	res, _, err := p.client.PlaidApi.LinkTokenCreate(ctx).LinkTokenCreateRequest(*req).Execute()
 	if err != nil {
-		return "", err
+		return "", fromPlaidError(err)
 	}
 
 	return res.GetLinkToken(), nil
@@ -104,7 +110,7 @@ func (p *Plaid) ExchangePublicToken(ctx context.Context, publicToken string) (*E
 	exchangeReq := plaid.NewItemPublicTokenExchangeRequest(publicToken)
 	res, _, err := p.client.PlaidApi.ItemPublicTokenExchange(ctx).ItemPublicTokenExchangeRequest(*exchangeReq).Execute()
 	if err != nil {
-		return nil, err
+		return nil, fromPlaidError(err)
 	}
 
 	return &ExchangeTokenResponse{
@@ -119,7 +125,7 @@ func (p *Plaid) CreateProcessorToken(ctx context.Context, accessToken, accountID
 	request := plaid.NewProcessorTokenCreateRequest(accessToken, accountID, PlaidTokenProcessorIdentifier)
 	processorTokenCreateResp, _, err := p.client.PlaidApi.ProcessorTokenCreate(ctx).ProcessorTokenCreateRequest(*request).Execute()
 	if err != nil {
-		return "", err
+		return "", fromPlaidError(err)
 	}
 	return processorTokenCreateResp.ProcessorToken, nil
 }
@@ -128,22 +134,84 @@ func (p *Plaid) CreateProcessorToken(ctx context.Context, accessToken, accountID
 func (p *Plaid) GetAccount(ctx context.Context, accessToken, accountID string) (*Account, error) {
 	accountRequest := plaid.NewAccountsGetRequest(accessToken)
 	accountsGetResp, _, err := p.client.PlaidApi.AccountsGet(ctx).AccountsGetRequest(*accountRequest).Execute()
+	if err != nil {
+		return nil, fromPlaidError(err)
+	}
+	account, err := findAccount(accountsGetResp.GetAccounts(), accountID)
 	if err != nil {
 		return nil, err
 	}
-	accounts := accountsGetResp.GetAccounts()
-	institutionID := accountsGetResp.Item.InstitutionId.Get()
+
 	var institution *string
-	if institutionID != nil {
-		institutionRequest := plaid.NewInstitutionsGetByIdRequest(*institutionID, []plaid.CountryCode{plaid.COUNTRYCODE_US})
-		instiutionGetResp, _, err := p.client.PlaidApi.InstitutionsGetById(ctx).InstitutionsGetByIdRequest(*institutionRequest).Execute()
+	if institutionID := accountsGetResp.Item.InstitutionId.Get(); institutionID != nil {
+		name, err := p.getInstitutionName(ctx, *institutionID)
 		if err != nil {
 			return nil, err
 		}
-		institution = &instiutionGetResp.Institution.Name
+		institution = &name
 	}
 
-	return &Account{Name: accounts[0].Name, Subtype: string(*accounts[0].Subtype.Get()), Mask: string(*accounts[0].Mask.Get()), Type: string(*accounts[0].Type.Ptr()), Institution: institution}, nil
+	a := toAccount(account)
+	a.Institution = institution
+	return a, nil
+}
+
+// GetItemAccounts fetches every account under an Item along with its institution,
+// so all of them can be stored after the user links a bank through Plaid Link.
+func (p *Plaid) GetItemAccounts(ctx context.Context, accessToken string) (*ItemAccounts, error) {
+	accountRequest := plaid.NewAccountsGetRequest(accessToken)
+	accountsGetResp, _, err := p.client.PlaidApi.AccountsGet(ctx).AccountsGetRequest(*accountRequest).Execute()
+	if err != nil {
+		return nil, fromPlaidError(err)
+	}
+
+	item := accountsGetResp.GetItem()
+	result := &ItemAccounts{ItemID: item.GetItemId()}
+	if institutionID := item.InstitutionId.Get(); institutionID != nil {
+		name, err := p.getInstitutionName(ctx, *institutionID)
+		if err != nil {
+			return nil, err
+		}
+		result.InstitutionID = *institutionID
+		result.InstitutionName = name
+	}
+
+	for _, account := range accountsGetResp.GetAccounts() {
+		result.Accounts = append(result.Accounts, toAccount(account))
+	}
+	return result, nil
+}
+
+func (p *Plaid) getInstitutionName(ctx context.Context, institutionID string) (string, error) {
+	institutionRequest := plaid.NewInstitutionsGetByIdRequest(institutionID, []plaid.CountryCode{plaid.COUNTRYCODE_US})
+	institutionGetResp, _, err := p.client.PlaidApi.InstitutionsGetById(ctx).InstitutionsGetByIdRequest(*institutionRequest).Execute()
+	if err != nil {
+		return "", fromPlaidError(err)
+	}
+	return institutionGetResp.Institution.Name, nil
+}
+
+func findAccount(accounts []plaid.AccountBase, accountID string) (plaid.AccountBase, error) {
+	for _, account := range accounts {
+		if account.AccountId == accountID {
+			return account, nil
+		}
+	}
+	return plaid.AccountBase{}, fmt.Errorf("account %s not found on item: %w", accountID, ErrBankAccountNotFound)
+}
+
+func toAccount(account plaid.AccountBase) *Account {
+	a := &Account{
+		AccountID:    account.AccountId,
+		Name:         account.Name,
+		OfficialName: account.GetOfficialName(),
+		Mask:         account.GetMask(),
+		Type:         string(account.Type),
+	}
+	if subtype := account.Subtype.Get(); subtype != nil {
+		a.Subtype = string(*subtype)
+	}
+	return a
 }
 
 // GetAccountWithBalance fetches a Plaid bank account and returns the current available balance
@@ -151,7 +219,7 @@ func (p *Plaid) GetAccountWithBalance(ctx context.Context, accessToken, accountI
 	request := plaid.NewAccountsBalanceGetRequest(accessToken)
 	resp, _, err := p.client.PlaidApi.AccountsBalanceGet(ctx).AccountsBalanceGetRequest(*request).Execute()
 	if err != nil {
-		return nil, err
+		return nil, fromPlaidError(err)
 	}
 
 	accounts := resp.GetAccounts()
@@ -160,8 +228,11 @@ func (p *Plaid) GetAccountWithBalance(ctx context.Context, accessToken, accountI
 		return nil, errors.New("no accounts found for accessToken")
 	}
 
-	account := accounts[0]
-	a := &Account{Name: account.Name, Subtype: string(*accounts[0].Subtype.Get())}
+	account, err := findAccount(accounts, accountID)
+	if err != nil {
+		return nil, err
+	}
+	a := toAccount(account)
 
 	balance := account.Balances.GetAvailable()
 	if balance == 0 {
@@ -218,7 +289,7 @@ func (p *Plaid) CreatePlaidBankAccount(ctx context.Context) (*CreatePlaidBankAcc
 	).Execute()
 	if err != nil {
 		log.Printf("error calling out to plaid, %v", err)
-		return nil, err
+		return nil, fromPlaidError(err)
 	}
 
 	publicToken := sandboxPublicTokenResp.GetPublicToken()
@@ -228,7 +299,7 @@ func (p *Plaid) CreatePlaidBankAccount(ctx context.Context) (*CreatePlaidBankAcc
 		*plaid.NewItemPublicTokenExchangeRequest(publicToken),
 	).Execute()
 	if err != nil {
-		return nil, err
+		return nil, fromPlaidError(err)
 	}
 
 	accessToken := exchangePublicTokenResp.GetAccessToken()
@@ -238,7 +309,7 @@ func (p *Plaid) CreatePlaidBankAccount(ctx context.Context) (*CreatePlaidBankAcc
 		*plaid.NewAccountsGetRequest(accessToken),
 	).Execute()
 	if err != nil {
-		return nil, err
+		return nil, fromPlaidError(err)
 	}
 
 	// Get Items
@@ -246,7 +317,7 @@ func (p *Plaid) CreatePlaidBankAccount(ctx context.Context) (*CreatePlaidBankAcc
 		*plaid.NewItemGetRequest(accessToken),
 	).Execute()
 	if err != nil {
-		return nil, err
+		return nil, fromPlaidError(err)
 	}
 
 	accountID := accountsGetResp.GetAccounts()[0].GetAccountId()
@@ -265,7 +336,7 @@ func (p *Plaid) DeletePlaidBankAccount(ctx context.Context, accessToken string)
 
 	itemRemoveResp, _, err := p.client.PlaidApi.ItemRemove(ctx).ItemRemoveRequest(*request).Execute()
 	if err != nil {
-		return nil, err
+		return nil, fromPlaidError(err)
 	}
 	return &itemRemoveResp.RequestId, nil
 }
@@ -275,7 +346,7 @@ func (p *Plaid) IsBalanceCheckSupported(ctx context.Context, accessToken, accoun
 	accountRequest := plaid.NewAccountsGetRequest(accessToken)
 	accountsGetResp, _, err := p.client.PlaidApi.AccountsGet(ctx).AccountsGetRequest(*accountRequest).Execute()
 	if err != nil {
-		return false, err
+		return false, fromPlaidError(err)
 	}
 
 	if len(accountsGetResp.Item.AvailableProducts) == 0 {
@@ -298,7 +369,7 @@ func (p *Plaid) CreateStripeToken(ctx context.Context, accessToken, accountID st
 	stripeTokenResp, _, err := p.client.PlaidApi.ProcessorStripeBankAccountTokenCreate(ctx).ProcessorStripeBankAccountTokenCreateRequest(*request).Execute()
 
 	if err != nil {
-		return nil, err
+		return nil, fromPlaidError(err)
 	}
 	return &stripeTokenResp.StripeBankAccountToken, nil
 }
@@ -308,7 +379,7 @@ func (p *Plaid) GetWebhookVerification(ctx context.Context, req *plaid.WebhookVe
 
 	webhookResp, _, err := p.client.PlaidApi.WebhookVerificationKeyGet(ctx).WebhookVerificationKeyGetRequest(*req).Execute()
 	if err != nil {
-		return nil, err
+		return nil, fromPlaidError(err)
 	}
 	key := webhookResp.GetKey()
 	return &key, err
@@ -318,6 +389,9 @@ func (p *Plaid) VerifyWebhook(webhookBody string, headers map[string]string) (bo
 	ctx := context.Background()
 
 	tokenString := headers["plaid-verification"]
+	if tokenString == "" {
+		return false, errors.New("verify webhook: missing plaid-verification header")
+	}
 	token, parts, err := p.parseToken(tokenString)
 
 	if err != nil || !p.validateAlgorithm(token) {
@@ -325,15 +399,18 @@ func (p *Plaid) VerifyWebhook(webhookBody string, headers map[string]string) (bo
 		return false, err
 	}
 
-	keyCache := make(map[string]plaid.JWKPublicKey)
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return false, errors.New("verify webhook: missing kid header")
+	}
 
-	isRefreshed, err := p.refreshKeys(ctx, token.Header["kid"].(string), keyCache)
+	key, err := p.keys.Key(ctx, kid)
 	if err != nil {
-		zap.L().Error(fmt.Sprintf("verify webhook: refresh keys failed: %v", err))
-		return isRefreshed, err
+		zap.L().Error(fmt.Sprintf("verify webhook: get key failed: %v", err))
+		return false, err
 	}
 
-	if !p.validateToken(token, parts, keyCache) {
+	if !p.validateToken(parts, key) {
 		zap.L().Error("verify webhook: validate token failed")
 		return false, err
 	}
@@ -343,10 +420,27 @@ func (p *Plaid) VerifyWebhook(webhookBody string, headers map[string]string) (bo
 		return false, err
 	}
 
-	sha256Value := token.Claims.(jwt.MapClaims)["request_body_sha256"].(string)
+	sha256Value, ok := token.Claims.(jwt.MapClaims)["request_body_sha256"].(string)
+	if !ok {
+		return false, errors.New("verify webhook: missing request_body_sha256 claim")
+	}
 	sha256Body := p.computeSHA256(webhookBody)
 
-	return sha256Body == sha256Value, err
+	return subtle.ConstantTimeCompare([]byte(sha256Body), []byte(sha256Value)) == 1, nil
+}
+
+// WebhookIssuedAt returns the iat claim of a plaid-verification JWT, in unix seconds. The
+// signature is not checked again, so only pass it a token VerifyWebhook accepted.
+func WebhookIssuedAt(tokenString string) (int64, error) {
+	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+	if err != nil {
+		return 0, err
+	}
+	iat, ok := token.Claims.(jwt.MapClaims)["iat"].(float64)
+	if !ok {
+		return 0, errors.New("plaid webhook: missing iat claim")
+	}
+	return int64(iat), nil
 }
 
 func (p *Plaid) parseToken(tokenString string) (*jwt.Token, []string, error) {
@@ -357,45 +451,36 @@ func (p *Plaid) validateAlgorithm(token *jwt.Token) bool {
 	return token.Method.Alg() == "ES256"
 }
 
-func (p *Plaid) refreshKeys(ctx context.Context, kid string, keyCache map[string]plaid.JWKPublicKey) (bool, error) {
-	var err error
-	if _, found := keyCache[kid]; !found {
-		kidsToUpdate := make([]string, 0)
-
-		for k, v := range keyCache {
-			if v.ExpiredAt.Get() == nil {
-				kidsToUpdate = append(kidsToUpdate, k)
-			}
-		}
-
-		kidsToUpdate = append(kidsToUpdate, kid)
-
-		for _, k := range kidsToUpdate {
-			webhookRequest := *plaid.NewWebhookVerificationKeyGetRequest(k)
-			webhookResponse, err := p.GetWebhookVerification(ctx, &webhookRequest)
-			if err != nil {
-				zap.L().Error(fmt.Sprintf("get webhook verification failed: %v", err))
-				return false, err
-			}
-			keyCache[k] = *webhookResponse
-		}
+// fetchWebhookKey loads a webhook verification key from Plaid, backing the WebhookKeyCache.
+func (p *Plaid) fetchWebhookKey(ctx context.Context, kid string) (*domain.PlaidWebhookKey, error) {
+	request := plaid.NewWebhookVerificationKeyGetRequest(kid)
+	jwk, err := p.GetWebhookVerification(ctx, request)
+	if err != nil {
+		return nil, err
 	}
-	return true, err
-}
 
-func (p *Plaid) validateToken(token *jwt.Token, parts []string, keyCache map[string]plaid.JWKPublicKey) bool {
-	kid := token.Header["kid"].(string)
-	if _, found := keyCache[kid]; !found {
-		return false
+	key := &domain.PlaidWebhookKey{
+		KeyID:     jwk.Kid,
+		Alg:       jwk.Alg,
+		Crv:       jwk.Crv,
+		Kty:       jwk.Kty,
+		Use:       jwk.Use,
+		X:         jwk.X,
+		Y:         jwk.Y,
+		CreatedAt: int64(jwk.CreatedAt),
 	}
+	if expiredAt := jwk.ExpiredAt.Get(); expiredAt != nil {
+		expired := int64(*expiredAt)
+		key.ExpiredAt = &expired
+	}
+	return key, nil
+}
 
-	key := keyCache[kid]
-
-	if key.ExpiredAt.Get() != nil {
+func (p *Plaid) validateToken(parts []string, key *domain.PlaidWebhookKey) bool {
+	publicKey, err := p.createPublicKey(key)
+	if err != nil {
 		return false
 	}
-
-	publicKey := p.createPublicKey(key)
 	tokenVerification := jwt.SigningMethodES256.Verify(
 		parts[0]+"."+parts[1],
 		parts[2],
@@ -405,22 +490,30 @@ func (p *Plaid) validateToken(token *jwt.Token, parts []string, keyCache map[str
 	return tokenVerification == nil
 }
 
-func (p *Plaid) createPublicKey(key plaid.JWKPublicKey) *ecdsa.PublicKey {
+func (p *Plaid) createPublicKey(key *domain.PlaidWebhookKey) (*ecdsa.PublicKey, error) {
+	x, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(key.X, "="))
+	if err != nil {
+		return nil, err
+	}
+	y, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(key.Y, "="))
+	if err != nil {
+		return nil, err
+	}
+
 	publicKey := new(ecdsa.PublicKey)
 	publicKey.Curve = elliptic.P256()
-	x, _ := base64.URLEncoding.DecodeString(key.X + "=")
-	xc := new(big.Int)
-	publicKey.X = xc.SetBytes(x)
-	y, _ := base64.URLEncoding.DecodeString(key.Y + "=")
-	yc := new(big.Int)
-	publicKey.Y = yc.SetBytes(y)
-	return publicKey
+	publicKey.X = new(big.Int).SetBytes(x)
+	publicKey.Y = new(big.Int).SetBytes(y)
+	return publicKey, nil
 }
 
 // Ensure webhook is not older than 5 minutes
 func (p *Plaid) checkTimestamp(token *jwt.Token) bool {
 	claims := token.Claims.(jwt.MapClaims)
-	iat := claims["iat"].(float64)
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return false
+	}
 	timeSinceIat := float64(time.Now().Unix()) - iat
 	return timeSinceIat <= 300
 }
@@ -6,6 +6,8 @@ type PlaidOpts struct {
 	ClientSecret string `json:"secret"`
 	Environment  string `json:"environment"`
 	Version      string `json:"version"`
+	// KeyStore optionally persists webhook verification keys, see WebhookKeyCache
+	KeyStore WebhookKeyStore `json:"-"`
 }
 
 type ExchangeTokenRequest struct {
@@ -20,11 +22,21 @@ type ExchangeTokenResponse struct {
 }
 
 type Account struct {
-    Name        string  `json:"name"`
-    Subtype     string  `json:"subtype"`
-    Mask        string  `json:"mask"`
-    Type        string  `json:"type"`
-    Institution *string `json:"institution"`
+    AccountID    string  `json:"account_id"`
+    Name         string  `json:"name"`
+    OfficialName string  `json:"official_name"`
+    Subtype      string  `json:"subtype"`
+    Mask         string  `json:"mask"`
+    Type         string  `json:"type"`
+    Institution  *string `json:"institution"`
+}
+
+// ItemAccounts is every account under a Plaid Item, as returned by AccountsGet.
+type ItemAccounts struct {
+    ItemID          string     `json:"item_id"`
+    InstitutionID   string     `json:"institution_id"`
+    InstitutionName string     `json:"institution_name"`
+    Accounts        []*Account `json:"accounts"`
 }
 
 type AccountWithBalance struct {
@@ -0,0 +1,199 @@
+package plaid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	// webhookKeyMaxAge bounds how long a current key is trusted before it is fetched
+	// again, so an expired_at set by Plaid after a rotation is eventually seen.
+	webhookKeyMaxAge = 24 * time.Hour
+	// webhookKeyMissCooldown stops unknown kids (e.g. forged tokens) from turning
+	// every request into a call to Plaid.
+	webhookKeyMissCooldown = time.Minute
+)
+
+var (
+	ErrWebhookKeyExpired  = errors.New("plaid webhook key expired")
+	ErrWebhookKeyNotFound = errors.New("plaid webhook key not found")
+)
+
+// WebhookKeyStore persists webhook verification keys so they survive restarts and
+// are shared between instances. domain.Repository satisfies it.
+type WebhookKeyStore interface {
+	GetPlaidWebhookKey(ctx context.Context, kid string) (*domain.PlaidWebhookKey, error)
+	SavePlaidWebhookKey(ctx context.Context, key *domain.PlaidWebhookKey) error
+}
+
+// WebhookKeyFetcher loads a key from Plaid's /webhook_verification_key/get.
+type WebhookKeyFetcher func(ctx context.Context, kid string) (*domain.PlaidWebhookKey, error)
+
+/*
+WebhookKeyCache is a process-wide, concurrency-safe cache of Plaid webhook verification keys.
+
+ 1. Keys are remembered by kid, in memory and, when a store is configured, in Postgres
+ 2. A key with expired_at in the past is never used to verify a webhook
+ 3. An unknown kid triggers one refresh: the kid is fetched and the current keys we hold
+    are fetched again, since a new kid usually means Plaid rotated its keys
+ 4. Concurrent requests for the same unknown kid share that refresh
+*/
+type WebhookKeyCache struct {
+	fetch WebhookKeyFetcher
+	store WebhookKeyStore
+
+	mu     sync.RWMutex
+	keys   map[string]*domain.PlaidWebhookKey
+	misses map[string]time.Time
+
+	refreshMu sync.Mutex
+	now       func() time.Time
+}
+
+// NewWebhookKeyCache builds a cache on top of fetch. store is optional.
+func NewWebhookKeyCache(fetch WebhookKeyFetcher, store WebhookKeyStore) *WebhookKeyCache {
+	return &WebhookKeyCache{
+		fetch:  fetch,
+		store:  store,
+		keys:   make(map[string]*domain.PlaidWebhookKey),
+		misses: make(map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+// Key returns the key for kid, refreshing it from the store or Plaid when it is
+// unknown or due for a re-check. It fails with ErrWebhookKeyExpired for expired keys.
+func (c *WebhookKeyCache) Key(ctx context.Context, kid string) (*domain.PlaidWebhookKey, error) {
+	if key, ok := c.cached(kid); ok {
+		return c.usable(key)
+	}
+
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	// Another request may have refreshed the key while we waited
+	if key, ok := c.cached(kid); ok {
+		return c.usable(key)
+	}
+	if c.recentlyMissed(kid) {
+		return nil, ErrWebhookKeyNotFound
+	}
+
+	key, err := c.refresh(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	return c.usable(key)
+}
+
+// cached returns the in-memory key for kid unless it is stale.
+func (c *WebhookKeyCache) cached(kid string) (*domain.PlaidWebhookKey, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key, ok := c.keys[kid]
+	if !ok || c.isStale(key) {
+		return nil, false
+	}
+	return key, true
+}
+
+func (c *WebhookKeyCache) recentlyMissed(kid string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	missedAt, ok := c.misses[kid]
+	return ok && c.now().Sub(missedAt) < webhookKeyMissCooldown
+}
+
+// refresh loads kid from the store, falling back to Plaid. A kid Plaid has not
+// told us about before also re-checks the other current keys we hold.
+func (c *WebhookKeyCache) refresh(ctx context.Context, kid string) (*domain.PlaidWebhookKey, error) {
+	if key := c.loadStored(ctx, kid); key != nil {
+		c.remember(key)
+		return key, nil
+	}
+
+	key, err := c.fetchAndSave(ctx, kid)
+	if err != nil {
+		c.mu.Lock()
+		c.misses[kid] = c.now()
+		c.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s: %v", ErrWebhookKeyNotFound, kid, err)
+	}
+
+	for _, current := range c.currentKeys(kid) {
+		if _, err := c.fetchAndSave(ctx, current); err != nil {
+			zap.L().Warn(fmt.Sprintf("refresh plaid webhook key %s failed: %v", current, err))
+		}
+	}
+
+	return key, nil
+}
+
+func (c *WebhookKeyCache) loadStored(ctx context.Context, kid string) *domain.PlaidWebhookKey {
+	if c.store == nil {
+		return nil
+	}
+	key, err := c.store.GetPlaidWebhookKey(ctx, kid)
+	if err != nil || c.isStale(key) {
+		return nil
+	}
+	return key
+}
+
+func (c *WebhookKeyCache) fetchAndSave(ctx context.Context, kid string) (*domain.PlaidWebhookKey, error) {
+	key, err := c.fetch(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	key.FetchedAt = c.now()
+	c.remember(key)
+
+	if c.store != nil {
+		if err := c.store.SavePlaidWebhookKey(ctx, key); err != nil {
+			zap.L().Warn(fmt.Sprintf("save plaid webhook key %s failed: %v", kid, err))
+		}
+	}
+	return key, nil
+}
+
+func (c *WebhookKeyCache) remember(key *domain.PlaidWebhookKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.keys[key.KeyID] = key
+	delete(c.misses, key.KeyID)
+}
+
+// currentKeys lists the cached kids, other than except, that Plaid has not expired yet.
+func (c *WebhookKeyCache) currentKeys(except string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	kids := make([]string, 0, len(c.keys))
+	for kid, key := range c.keys {
+		if kid != except && key.ExpiredAt == nil {
+			kids = append(kids, kid)
+		}
+	}
+	return kids
+}
+
+func (c *WebhookKeyCache) usable(key *domain.PlaidWebhookKey) (*domain.PlaidWebhookKey, error) {
+	if key.ExpiredAt != nil && *key.ExpiredAt <= c.now().Unix() {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookKeyExpired, key.KeyID)
+	}
+	return key, nil
+}
+
+// isStale reports whether a current key is due for a re-check. Expired keys stay expired.
+func (c *WebhookKeyCache) isStale(key *domain.PlaidWebhookKey) bool {
+	return key.ExpiredAt == nil && c.now().Sub(key.FetchedAt) > webhookKeyMaxAge
+}
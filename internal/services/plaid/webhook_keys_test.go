@@ -0,0 +1,313 @@
+package plaid
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// fakeKeys stands in for Plaid's /webhook_verification_key/get and counts calls per kid.
+type fakeKeys struct {
+	mu      sync.Mutex
+	keys    map[string]*domain.PlaidWebhookKey
+	calls   map[string]int
+	release chan struct{} // when set, fetches wait for it to be closed
+}
+
+func newFakeKeys(keys ...*domain.PlaidWebhookKey) *fakeKeys {
+	f := &fakeKeys{keys: make(map[string]*domain.PlaidWebhookKey), calls: make(map[string]int)}
+	for _, key := range keys {
+		f.keys[key.KeyID] = key
+	}
+	return f
+}
+
+func (f *fakeKeys) fetch(ctx context.Context, kid string) (*domain.PlaidWebhookKey, error) {
+	if f.release != nil {
+		<-f.release
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls[kid]++
+	key, ok := f.keys[kid]
+	if !ok {
+		return nil, errors.New("INVALID_WEBHOOK_VERIFICATION_KEY_ID")
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (f *fakeKeys) callCount(kid string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[kid]
+}
+
+// testClock is a settable time source for WebhookKeyCache.now.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestCache(fetcher *fakeKeys) (*WebhookKeyCache, *testClock) {
+	clock := &testClock{now: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+	cache := NewWebhookKeyCache(fetcher.fetch, nil)
+	cache.now = clock.Now
+	return cache, clock
+}
+
+func TestWebhookKeyCacheServesCachedKey(t *testing.T) {
+	fetcher := newFakeKeys(&domain.PlaidWebhookKey{KeyID: "kid-1"})
+	cache, clock := newTestCache(fetcher)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		key, err := cache.Key(ctx, "kid-1")
+		if err != nil {
+			t.Fatalf("Key: %v", err)
+		}
+		if key.KeyID != "kid-1" {
+			t.Fatalf("Key returned %q, want kid-1", key.KeyID)
+		}
+	}
+	if got := fetcher.callCount("kid-1"); got != 1 {
+		t.Fatalf("fetched kid-1 %d times, want 1", got)
+	}
+
+	// A current key is checked again once it is older than webhookKeyMaxAge
+	clock.Advance(webhookKeyMaxAge + time.Second)
+	if _, err := cache.Key(ctx, "kid-1"); err != nil {
+		t.Fatalf("Key after max age: %v", err)
+	}
+	if got := fetcher.callCount("kid-1"); got != 2 {
+		t.Fatalf("fetched kid-1 %d times after max age, want 2", got)
+	}
+}
+
+func TestWebhookKeyCacheRejectsExpiredKey(t *testing.T) {
+	fetcher := newFakeKeys()
+	cache, clock := newTestCache(fetcher)
+
+	tests := []struct {
+		name      string
+		expiredAt int64
+		wantErr   error
+	}{
+		{name: "expired an hour ago", expiredAt: clock.Now().Add(-time.Hour).Unix(), wantErr: ErrWebhookKeyExpired},
+		{name: "expires right now", expiredAt: clock.Now().Unix(), wantErr: ErrWebhookKeyExpired},
+		{name: "expires in an hour", expiredAt: clock.Now().Add(time.Hour).Unix()},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kid := string(rune('a' + i))
+			expiredAt := tt.expiredAt
+			fetcher.mu.Lock()
+			fetcher.keys[kid] = &domain.PlaidWebhookKey{KeyID: kid, ExpiredAt: &expiredAt}
+			fetcher.mu.Unlock()
+
+			_, err := cache.Key(context.Background(), kid)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Key error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookKeyCacheRefreshesOnceForUnknownKid(t *testing.T) {
+	fetcher := newFakeKeys(&domain.PlaidWebhookKey{KeyID: "old"}, &domain.PlaidWebhookKey{KeyID: "new"})
+	cache, _ := newTestCache(fetcher)
+	ctx := context.Background()
+
+	if _, err := cache.Key(ctx, "old"); err != nil {
+		t.Fatalf("Key(old): %v", err)
+	}
+
+	// Plaid rotated its keys: the new kid is fetched and the old one re-checked
+	fetcher.mu.Lock()
+	expiredAt := int64(1)
+	fetcher.keys["old"].ExpiredAt = &expiredAt
+	fetcher.mu.Unlock()
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.Key(ctx, "new"); err != nil {
+			t.Fatalf("Key(new): %v", err)
+		}
+	}
+	if got := fetcher.callCount("new"); got != 1 {
+		t.Fatalf("fetched new %d times, want 1", got)
+	}
+	if got := fetcher.callCount("old"); got != 2 {
+		t.Fatalf("fetched old %d times, want 2", got)
+	}
+	if _, err := cache.Key(ctx, "old"); !errors.Is(err, ErrWebhookKeyExpired) {
+		t.Fatalf("Key(old) after rotation error = %v, want %v", err, ErrWebhookKeyExpired)
+	}
+}
+
+func TestWebhookKeyCacheHonorsMissCooldown(t *testing.T) {
+	fetcher := newFakeKeys()
+	cache, clock := newTestCache(fetcher)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.Key(ctx, "forged"); !errors.Is(err, ErrWebhookKeyNotFound) {
+			t.Fatalf("Key error = %v, want %v", err, ErrWebhookKeyNotFound)
+		}
+	}
+	if got := fetcher.callCount("forged"); got != 1 {
+		t.Fatalf("fetched forged %d times within the cooldown, want 1", got)
+	}
+
+	clock.Advance(webhookKeyMissCooldown)
+	if _, err := cache.Key(ctx, "forged"); !errors.Is(err, ErrWebhookKeyNotFound) {
+		t.Fatalf("Key error = %v, want %v", err, ErrWebhookKeyNotFound)
+	}
+	if got := fetcher.callCount("forged"); got != 2 {
+		t.Fatalf("fetched forged %d times after the cooldown, want 2", got)
+	}
+
+	// A kid Plaid starts serving is usable as soon as the cooldown is over
+	fetcher.mu.Lock()
+	fetcher.keys["forged"] = &domain.PlaidWebhookKey{KeyID: "forged"}
+	fetcher.mu.Unlock()
+	clock.Advance(webhookKeyMissCooldown)
+	if _, err := cache.Key(ctx, "forged"); err != nil {
+		t.Fatalf("Key after the key appeared: %v", err)
+	}
+}
+
+func TestWebhookKeyCacheConcurrentLookupsRefreshOnce(t *testing.T) {
+	fetcher := newFakeKeys(&domain.PlaidWebhookKey{KeyID: "kid-1"})
+	fetcher.release = make(chan struct{})
+	cache, _ := newTestCache(fetcher)
+
+	const lookups = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, lookups)
+	for i := 0; i < lookups; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := cache.Key(context.Background(), "kid-1")
+			errs <- err
+		}()
+	}
+
+	// Let the lookups pile up behind the first fetch before Plaid answers
+	time.Sleep(20 * time.Millisecond)
+	close(fetcher.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Key: %v", err)
+		}
+	}
+	if got := fetcher.callCount("kid-1"); got != 1 {
+		t.Fatalf("fetched kid-1 %d times, want 1", got)
+	}
+}
+
+// signWebhook signs a Plaid-Verification JWT for body with priv, as Plaid does.
+func signWebhook(t *testing.T, priv *ecdsa.PrivateKey, kid, body string, iat time.Time) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iat":                 iat.Unix(),
+		"request_body_sha256": (&Plaid{}).computeSHA256(body),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(priv)
+	if err != nil {
+		t.Fatalf("sign webhook: %v", err)
+	}
+	return signed
+}
+
+func newWebhookKey(t *testing.T, kid string) (*ecdsa.PrivateKey, *domain.PlaidWebhookKey) {
+	t.Helper()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return priv, &domain.PlaidWebhookKey{
+		KeyID: kid,
+		Alg:   "ES256",
+		Crv:   "P-256",
+		Kty:   "EC",
+		Use:   "sig",
+		X:     base64.RawURLEncoding.EncodeToString(priv.X.FillBytes(make([]byte, 32))),
+		Y:     base64.RawURLEncoding.EncodeToString(priv.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	priv, key := newWebhookKey(t, "kid-1")
+	other, _ := newWebhookKey(t, "kid-1")
+	p := &Plaid{keys: NewWebhookKeyCache(newFakeKeys(key).fetch, nil)}
+
+	const body = `{"webhook_type":"ITEM","webhook_code":"ERROR","item_id":"item-1"}`
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		token string
+		body  string
+		want  bool
+	}{
+		{name: "valid", token: signWebhook(t, priv, "kid-1", body, now), body: body, want: true},
+		{name: "bad signature", token: signWebhook(t, other, "kid-1", body, now), body: body},
+		{name: "bad body hash", token: signWebhook(t, priv, "kid-1", body, now), body: body + " "},
+		{name: "stale iat", token: signWebhook(t, priv, "kid-1", body, now.Add(-10*time.Minute)), body: body},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, _ := p.VerifyWebhook(tt.body, map[string]string{"plaid-verification": tt.token})
+			if ok != tt.want {
+				t.Fatalf("VerifyWebhook = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookIssuedAt(t *testing.T) {
+	priv, _ := newWebhookKey(t, "kid-1")
+	iat := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	got, err := WebhookIssuedAt(signWebhook(t, priv, "kid-1", "{}", iat))
+	if err != nil {
+		t.Fatalf("WebhookIssuedAt: %v", err)
+	}
+	if got != iat.Unix() {
+		t.Fatalf("WebhookIssuedAt = %d, want %d", got, iat.Unix())
+	}
+
+	if _, err := WebhookIssuedAt("not-a-jwt"); err == nil {
+		t.Fatal("WebhookIssuedAt accepted a malformed token")
+	}
+}
@@ -0,0 +1,108 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/stripe/stripe-go/v75"
+	"github.com/stripe/stripe-go/v75/balancetransaction"
+	"github.com/stripe/stripe-go/v75/payout"
+)
+
+/*
+ListBalanceTransactions returns the balance transactions created in [from, to).
+
+The source object is expanded so charges and refunds carry the PaymentIntent they
+belong to, which is how they are matched to payments.
+*/
+func (s *stripeImpl) ListBalanceTransactions(ctx context.Context, from, to time.Time) ([]*domain.StripeBalanceTransaction, error) {
+	stripe.Key = s.Config.AppKey
+
+	params := &stripe.BalanceTransactionListParams{
+		CreatedRange: &stripe.RangeQueryParams{
+			GreaterThanOrEqual: from.Unix(),
+			LesserThan:         to.Unix(),
+		},
+	}
+	params.AddExpand("data.source")
+	params.Context = ctx
+
+	iter := balancetransaction.List(params)
+
+	var result []*domain.StripeBalanceTransaction
+	for iter.Next() {
+		result = append(result, balanceTransactionFromStripe(iter.BalanceTransaction()))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list Stripe balance transactions: %w", fromStripeError(err))
+	}
+
+	return result, nil
+}
+
+// ListPayouts returns the payouts created in [from, to).
+func (s *stripeImpl) ListPayouts(ctx context.Context, from, to time.Time) ([]*domain.StripePayout, error) {
+	stripe.Key = s.Config.AppKey
+
+	params := &stripe.PayoutListParams{
+		CreatedRange: &stripe.RangeQueryParams{
+			GreaterThanOrEqual: from.Unix(),
+			LesserThan:         to.Unix(),
+		},
+	}
+	params.Context = ctx
+
+	iter := payout.List(params)
+
+	var result []*domain.StripePayout
+	for iter.Next() {
+		p := iter.Payout()
+		out := &domain.StripePayout{
+			ID:          p.ID,
+			Amount:      p.Amount,
+			Currency:    string(p.Currency),
+			Status:      string(p.Status),
+			FailureCode: string(p.FailureCode),
+			ArrivalDate: time.Unix(p.ArrivalDate, 0).UTC(),
+			CreatedAt:   time.Unix(p.Created, 0).UTC(),
+		}
+		if p.BalanceTransaction != nil {
+			out.BalanceTransactionID = p.BalanceTransaction.ID
+		}
+		result = append(result, out)
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list Stripe payouts: %w", fromStripeError(err))
+	}
+
+	return result, nil
+}
+
+func balanceTransactionFromStripe(txn *stripe.BalanceTransaction) *domain.StripeBalanceTransaction {
+	out := &domain.StripeBalanceTransaction{
+		ID:          txn.ID,
+		Type:        string(txn.Type),
+		Status:      string(txn.Status),
+		Amount:      txn.Amount,
+		Fee:         txn.Fee,
+		Net:         txn.Net,
+		Currency:    string(txn.Currency),
+		CreatedAt:   time.Unix(txn.Created, 0).UTC(),
+		AvailableOn: time.Unix(txn.AvailableOn, 0).UTC(),
+	}
+
+	if src := txn.Source; src != nil {
+		out.SourceID = src.ID
+		switch {
+		case src.Charge != nil && src.Charge.PaymentIntent != nil:
+			out.PaymentIntentID = src.Charge.PaymentIntent.ID
+		case src.Refund != nil && src.Refund.PaymentIntent != nil:
+			out.PaymentIntentID = src.Refund.PaymentIntent.ID
+		}
+	}
+	return out
+}
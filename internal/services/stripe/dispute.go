@@ -0,0 +1,73 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/stripe/stripe-go/v75"
+	"github.com/stripe/stripe-go/v75/dispute"
+)
+
+/*
+UpdateDisputeEvidence sends evidence for a dispute to Stripe. Unless evidence.Submit is set,
+Stripe only stages it, and it can be changed until it is submitted; Stripe usually accepts
+a single submission. Only fields that are set are sent.
+*/
+func (s *stripeImpl) UpdateDisputeEvidence(ctx context.Context, stripeDisputeID string, evidence *domain.DisputeEvidence) (*domain.Dispute, error) {
+	stripe.Key = s.Config.AppKey
+
+	d, err := dispute.Update(stripeDisputeID, &stripe.DisputeParams{
+		Evidence: &stripe.DisputeEvidenceParams{
+			UncategorizedText:        optionalString(evidence.UncategorizedText),
+			ProductDescription:       optionalString(evidence.ProductDescription),
+			CustomerName:             optionalString(evidence.CustomerName),
+			CustomerEmailAddress:     optionalString(evidence.CustomerEmailAddress),
+			ServiceDate:              optionalString(evidence.ServiceDate),
+			RefundRefusalExplanation: optionalString(evidence.RefundRefusalExplanation),
+			UncategorizedFile:        optionalString(evidence.UncategorizedFile),
+			Receipt:                  optionalString(evidence.Receipt),
+			CustomerCommunication:    optionalString(evidence.CustomerCommunication),
+			ServiceDocumentation:     optionalString(evidence.ServiceDocumentation),
+		},
+		Submit: stripe.Bool(evidence.Submit),
+		Params: stripe.Params{
+			Context: ctx,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update evidence of dispute %s: %w", stripeDisputeID, fromStripeError(err))
+	}
+
+	return DisputeFromStripe(d), nil
+}
+
+// DisputeFromStripe maps a Stripe dispute. PaymentID and StripeEventID are left
+// for the caller to fill in.
+func DisputeFromStripe(d *stripe.Dispute) *domain.Dispute {
+	out := &domain.Dispute{
+		StripeDisputeID: d.ID,
+		Amount:          d.Amount,
+		Currency:        string(d.Currency),
+		Reason:          string(d.Reason),
+		Status:          string(d.Status),
+		CreatedAt:       time.Unix(d.Created, 0).UTC(),
+	}
+	if d.Charge != nil {
+		out.StripeChargeID = d.Charge.ID
+	}
+	// DueBy is 0 when the bank doesn't accept a response
+	if d.EvidenceDetails != nil && d.EvidenceDetails.DueBy > 0 {
+		dueBy := time.Unix(d.EvidenceDetails.DueBy, 0).UTC()
+		out.EvidenceDueBy = &dueBy
+	}
+	return out
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return stripe.String(s)
+}
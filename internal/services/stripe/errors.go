@@ -0,0 +1,40 @@
+package stripe
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	"github.com/stripe/stripe-go/v75"
+)
+
+/*
+fromStripeError maps an error of the Stripe SDK onto the domain errors the API reports.
+The Stripe error stays in the chain, so callers can still errors.As it; its message,
+which may name our account or request parameters, is never shown to API callers.
+*/
+func fromStripeError(err error) error {
+	var stripeErr *stripe.Error
+	if !errors.As(err, &stripeErr) {
+		// The request never got an answer, e.g. a timeout
+		return domain.ProviderUnavailable("stripe_unavailable", "Stripe is unavailable, try again later", err)
+	}
+
+	switch {
+	case stripeErr.Type == stripe.ErrorTypeCard || stripeErr.DeclineCode != "":
+		return domain.ProviderDeclined("payment_method_declined", "The payment method was declined", err)
+	case stripeErr.HTTPStatusCode == http.StatusNotFound || stripeErr.Code == stripe.ErrorCodeResourceMissing:
+		return &domain.Error{Kind: domain.KindNotFound, Code: "stripe_resource_not_found", Message: "Stripe resource not found", Err: err}
+	case stripeErr.Type == stripe.ErrorTypeIdempotency, stripeErr.HTTPStatusCode == http.StatusConflict,
+		stripeErr.Code == stripe.ErrorCodePaymentIntentUnexpectedState:
+		return &domain.Error{Kind: domain.KindConflict, Code: "stripe_conflict", Message: "Stripe can't apply the request in the resource's current state", Err: err}
+	case stripeErr.HTTPStatusCode == http.StatusTooManyRequests || stripeErr.Code == stripe.ErrorCodeRateLimit:
+		return domain.ProviderUnavailable("stripe_rate_limited", "Stripe is rate limiting requests, try again later", err)
+	case stripeErr.HTTPStatusCode >= http.StatusInternalServerError, stripeErr.Type == stripe.ErrorTypeAPI,
+		// Our credentials were rejected, nothing the caller can fix
+		stripeErr.HTTPStatusCode == http.StatusUnauthorized, stripeErr.HTTPStatusCode == http.StatusForbidden:
+		return domain.ProviderUnavailable("stripe_unavailable", "Stripe is unavailable, try again later", err)
+	default:
+		return domain.ProviderDeclined("stripe_request_declined", "Stripe declined the request", err)
+	}
+}
@@ -2,17 +2,22 @@ package stripe
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/GalaDe/payments-service/internal/domain"
 	"github.com/google/uuid"
 	"github.com/guregu/null"
 	"github.com/stripe/stripe-go/v75"
 
-	"github.com/stripe/stripe-go/v75/charge"
 	"github.com/stripe/stripe-go/v75/customer"
+	"github.com/stripe/stripe-go/v75/paymentintent"
 	"github.com/stripe/stripe-go/v75/paymentmethod"
+	"github.com/stripe/stripe-go/v75/refund"
 	"github.com/stripe/stripe-go/v75/token"
+	"github.com/stripe/stripe-go/v75/webhook"
 )
 
 type stripeImpl struct {
@@ -20,9 +25,10 @@ type stripeImpl struct {
 }
 
 type StripeConfig struct {
-	AppKey      string `json:"AppKey"`
-	WebhookKey  string `json:"WebhookKey"`
-	Environment string `json:"Environment"`
+	AppKey           string        `json:"AppKey"`
+	WebhookKey       string        `json:"WebhookKey"`
+	WebhookTolerance time.Duration `json:"WebhookTolerance"` // max age of a signed webhook, defaults to 5 minutes
+	Environment      string        `json:"Environment"`
 }
 
 type StripeService interface {
@@ -31,21 +37,22 @@ type StripeService interface {
 	GetCustomerPaymentMethods(ctx context.Context, customerID string, paymentType string) ([]*stripe.PaymentMethod, error)
 	UpdateDefaultStripePaymentMethod(ctx context.Context, input *UpdateDefaultStripePaymentMethodInput) error
 	DeleteStripePaymentMethod(ctx context.Context, paymentMethodID string) error
-	CreateACHCharge(ctx context.Context, input *CreateACHChargeInput) (*ACHCharge, error)
+	CreateACHPaymentIntent(ctx context.Context, input *domain.CreateACHChargeInput) (*domain.ACHCharge, error)
+	GetACHPaymentIntent(ctx context.Context, paymentIntentID string) (*domain.ACHCharge, error)
+	CancelACHPaymentIntent(ctx context.Context, paymentIntentID string) (*domain.ACHCharge, error)
+	CreateRefund(ctx context.Context, input *domain.CreateRefundInput) (*domain.ACHRefund, error)
 	RetrieveStripeToken(ctx context.Context, tokenID string) (*stripe.Token, error)
+	ConstructWebhookEvent(payload []byte, signatureHeader string) (*stripe.Event, error)
 	RetrievePaymentMethod(ctx context.Context, paymentMethodID string) (*domain.PaymentMethod, error)
+	ListBalanceTransactions(ctx context.Context, from, to time.Time) ([]*domain.StripeBalanceTransaction, error)
+	ListPayouts(ctx context.Context, from, to time.Time) ([]*domain.StripePayout, error)
+	UpdateDisputeEvidence(ctx context.Context, stripeDisputeID string, evidence *domain.DisputeEvidence) (*domain.Dispute, error)
 }
 
 func NewStripe(config *StripeConfig) StripeService {
 	return &stripeImpl{Config: config}
 }
 
-type ACHCharge struct {
-	ID     string
-	Amount int
-	Status string
-}
-
 type CreateStripeCustomerInput struct {
 	UserID *string `json:"user_id"`
 	Email  *string `json:"email"`
@@ -56,12 +63,6 @@ type UpdateDefaultStripePaymentMethodInput struct {
 	PaymentMethodID string `json:"payment_method_id"`
 }
 
-type CreateACHChargeInput struct {
-	CustomerID     string `json:"CustomerID"`
-	Amount         int64  `json:"Amount"`
-	IdempotencyKey string `json:"IdempotencyKey"`
-}
-
 // createStripeCustomer function represents the user in the Stripe system.
 func (s *stripeImpl) CreateStripeCustomer(input *CreateStripeCustomerInput) (*domain.StripeCustomer, error) {
 	stripe.Key = s.Config.AppKey
@@ -80,7 +81,7 @@ func (s *stripeImpl) CreateStripeCustomer(input *CreateStripeCustomerInput) (*do
 
 	stripeCustomer, err := customer.New(params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Stripe customer: %w", err)
+		return nil, fmt.Errorf("failed to create Stripe customer: %w", fromStripeError(err))
 	}
 
 	return &domain.StripeCustomer{
@@ -105,7 +106,7 @@ func (s *stripeImpl) CreatePaymentMethodFromBankToken(ctx context.Context, custo
 
 	pm, err := paymentmethod.New(params)
 	if err != nil {
-		return nil, fmt.Errorf("stripe: failed to create payment method: %w", err)
+		return nil, fmt.Errorf("stripe: failed to create payment method: %w", fromStripeError(err))
 	}
 
 	return &domain.PaymentMethod{
@@ -133,7 +134,7 @@ func (s *stripeImpl) GetCustomerPaymentMethods(ctx context.Context, customerID s
 	}
 
 	if err := iter.Err(); err != nil {
-		return nil, fmt.Errorf("failed to list Stripe payment methods: %w", err)
+		return nil, fmt.Errorf("failed to list Stripe payment methods: %w", fromStripeError(err))
 	}
 
 	return result, nil
@@ -151,7 +152,7 @@ func (s *stripeImpl) UpdateDefaultStripePaymentMethod(ctx context.Context, input
 
 	_, err := customer.Update(input.CustomerID, customerParams)
 	if err != nil {
-		return fmt.Errorf("failed to set default payment method for customer %s: %w", input.CustomerID, err)
+		return fmt.Errorf("failed to set default payment method for customer %s: %w", input.CustomerID, fromStripeError(err))
 	}
 
 	return nil
@@ -162,37 +163,236 @@ func (s *stripeImpl) DeleteStripePaymentMethod(ctx context.Context, paymentMetho
 
 	_, err := paymentmethod.Detach(paymentMethodID, nil)
 	if err != nil {
-		return fmt.Errorf("failed to detach payment method: %w", err)
+		return fmt.Errorf("failed to detach payment method: %w", fromStripeError(err))
 	}
 	return nil
 }
 
-func (s *stripeImpl) CreateACHCharge(ctx context.Context, input *CreateACHChargeInput) (*ACHCharge, error) {
+/*
+CreateACHPaymentIntent debits a customer's bank account through ACH Direct Debit.
+
+The PaymentIntent is created and confirmed in one call against the given us_bank_account
+payment method, together with the mandate the customer accepted. A confirmed ACH intent
+normally lands in "processing" and only moves to "succeeded" (or back to
+"requires_payment_method" on failure) once the debit settles, which Stripe reports via webhooks.
+*/
+func (s *stripeImpl) CreateACHPaymentIntent(ctx context.Context, input *domain.CreateACHChargeInput) (*domain.ACHCharge, error) {
+	stripe.Key = s.Config.AppKey
+
+	if input.PaymentMethodID == "" {
+		return nil, errors.New("stripe: payment method is required for ACH payment intent")
+	}
+	if input.Mandate == nil || input.Mandate.AcceptedAt == 0 {
+		return nil, errors.New("stripe: mandate acceptance time is required for ACH payment intent")
+	}
+
+	currency := strings.ToLower(input.Currency)
+	if currency == "" {
+		currency = string(stripe.CurrencyUSD)
+	}
+
+	description := input.Description
+	if description == "" {
+		description = "ACH charge for customer " + input.CustomerID
+	}
+
+	params := &stripe.PaymentIntentParams{
+		Amount:             stripe.Int64(input.Amount), // amount in cents
+		Currency:           stripe.String(currency),
+		Customer:           stripe.String(input.CustomerID),
+		PaymentMethod:      stripe.String(input.PaymentMethodID),
+		PaymentMethodTypes: stripe.StringSlice([]string{string(stripe.PaymentMethodTypeUSBankAccount)}),
+		Description:        stripe.String(description),
+		Confirm:            stripe.Bool(true),
+		MandateData:        mandateDataParams(input.Mandate),
+		Params: stripe.Params{
+			IdempotencyKey: stripe.String(input.IdempotencyKey),
+			Context:        ctx,
+		},
+	}
+	if input.PaymentID != "" {
+		params.AddMetadata("payment_id", input.PaymentID)
+	}
+
+	pi, err := paymentintent.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("stripe payment intent error: %w", fromStripeError(err))
+	}
+
+	return achChargeFromPaymentIntent(pi), nil
+}
+
+// GetACHPaymentIntent fetches the current state of an ACH PaymentIntent.
+func (s *stripeImpl) GetACHPaymentIntent(ctx context.Context, paymentIntentID string) (*domain.ACHCharge, error) {
+	stripe.Key = s.Config.AppKey
+
+	pi, err := paymentintent.Get(paymentIntentID, &stripe.PaymentIntentParams{
+		Params: stripe.Params{
+			Context: ctx,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve payment intent: %w", fromStripeError(err))
+	}
+
+	return achChargeFromPaymentIntent(pi), nil
+}
+
+/*
+CancelACHPaymentIntent cancels an ACH PaymentIntent that has not been submitted to the ACH network yet.
+
+Stripe refuses to cancel an intent once the debit is on its way (or settled); that is reported as
+domain.ErrPaymentNotCancelable. Canceling an already canceled intent returns it unchanged.
+*/
+func (s *stripeImpl) CancelACHPaymentIntent(ctx context.Context, paymentIntentID string) (*domain.ACHCharge, error) {
+	stripe.Key = s.Config.AppKey
+
+	pi, err := paymentintent.Cancel(paymentIntentID, &stripe.PaymentIntentCancelParams{
+		CancellationReason: stripe.String(string(stripe.PaymentIntentCancellationReasonRequestedByCustomer)),
+		Params: stripe.Params{
+			Context: ctx,
+		},
+	})
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodePaymentIntentUnexpectedState {
+			current, getErr := s.GetACHPaymentIntent(ctx, paymentIntentID)
+			if getErr == nil && current.Status == domain.PaymentStatusCanceled {
+				return current, nil
+			}
+			return nil, fmt.Errorf("payment intent %s: %w", paymentIntentID, domain.ErrPaymentNotCancelable)
+		}
+		return nil, fmt.Errorf("failed to cancel payment intent: %w", fromStripeError(err))
+	}
+
+	return achChargeFromPaymentIntent(pi), nil
+}
+
+// mandateDataParams builds the customer acceptance sent with the confirmation.
+// Without online details the mandate is recorded as accepted offline. Everything comes
+// from mandate, so a retry of the same confirmation sends the same parameters.
+func mandateDataParams(mandate *domain.MandateAcceptance) *stripe.PaymentIntentMandateDataParams {
+	acceptance := &stripe.PaymentIntentMandateDataCustomerAcceptanceParams{
+		AcceptedAt: stripe.Int64(mandate.AcceptedAt),
+		Type:       stripe.String("offline"),
+		Offline:    &stripe.PaymentIntentMandateDataCustomerAcceptanceOfflineParams{},
+	}
+
+	if mandate.IPAddress != "" && mandate.UserAgent != "" {
+		acceptance.Type = stripe.String("online")
+		acceptance.Offline = nil
+		acceptance.Online = &stripe.PaymentIntentMandateDataCustomerAcceptanceOnlineParams{
+			IPAddress: stripe.String(mandate.IPAddress),
+			UserAgent: stripe.String(mandate.UserAgent),
+		}
+	}
+
+	return &stripe.PaymentIntentMandateDataParams{CustomerAcceptance: acceptance}
+}
+
+func achChargeFromPaymentIntent(pi *stripe.PaymentIntent) *domain.ACHCharge {
+	charge := &domain.ACHCharge{
+		ID:           pi.ID,
+		Amount:       pi.Amount,
+		Currency:     string(pi.Currency),
+		Status:       PaymentStatusFromIntent(pi.Status),
+		StripeStatus: string(pi.Status),
+		CreatedAt:    pi.Created,
+	}
+	if pi.PaymentMethod != nil {
+		charge.PaymentMethodID = pi.PaymentMethod.ID
+	}
+	if pi.LastPaymentError != nil {
+		charge.FailureMessage = pi.LastPaymentError.Msg
+	}
+	return charge
+}
+
+/*
+PaymentStatusFromIntent maps a PaymentIntent status onto our payment statuses.
+
+	processing, requires_confirmation, requires_action, requires_capture -> processing
+	succeeded                                                             -> succeeded
+	requires_payment_method (the debit failed or was rejected)            -> failed
+	canceled                                                              -> canceled
+*/
+func PaymentStatusFromIntent(status stripe.PaymentIntentStatus) domain.PaymentStatus {
+	switch status {
+	case stripe.PaymentIntentStatusSucceeded:
+		return domain.PaymentStatusSucceeded
+	case stripe.PaymentIntentStatusRequiresPaymentMethod:
+		return domain.PaymentStatusFailed
+	case stripe.PaymentIntentStatusCanceled:
+		return domain.PaymentStatusCanceled
+	default:
+		return domain.PaymentStatusProcessing
+	}
+}
+
+// PaymentStatusFromCharge maps a charge status onto our payment statuses.
+func PaymentStatusFromCharge(status stripe.ChargeStatus) domain.PaymentStatus {
+	switch status {
+	case stripe.ChargeStatusSucceeded:
+		return domain.PaymentStatusSucceeded
+	case stripe.ChargeStatusFailed:
+		return domain.PaymentStatusFailed
+	default:
+		return domain.PaymentStatusProcessing
+	}
+}
+
+/*
+CreateRefund returns all or part of a PaymentIntent to the customer.
+
+The idempotency key makes retried calls return the same refund instead of refunding twice.
+ACH refunds usually come back as "pending" and settle a few business days later.
+*/
+func (s *stripeImpl) CreateRefund(ctx context.Context, input *domain.CreateRefundInput) (*domain.ACHRefund, error) {
 	stripe.Key = s.Config.AppKey
 
-	chargeParams := &stripe.ChargeParams{
-		Amount:      &input.Amount, // amount in cents
-		Currency:    stripe.String(string(stripe.CurrencyUSD)),
-		Customer:    stripe.String(input.CustomerID),
-		Description: stripe.String("ACH charge for user " + input.CustomerID),
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(input.PaymentIntentID),
+		Amount:        stripe.Int64(input.Amount),
 		Params: stripe.Params{
 			IdempotencyKey: stripe.String(input.IdempotencyKey),
 			Context:        ctx,
 		},
 	}
+	if input.Reason != "" {
+		params.Reason = stripe.String(input.Reason)
+	}
+	if input.RefundID != "" {
+		params.AddMetadata("refund_id", input.RefundID)
+	}
 
-	result, err := charge.New(chargeParams)
+	result, err := refund.New(params)
 	if err != nil {
-		return nil, fmt.Errorf("stripe charge error: %w", err)
+		return nil, fmt.Errorf("stripe refund error: %w", fromStripeError(err))
 	}
 
-	return &ACHCharge{
-		ID:     result.ID,
-		Amount: int(result.Amount),
-		Status: string(result.Status),
+	return &domain.ACHRefund{
+		ID:            result.ID,
+		Amount:        result.Amount,
+		Status:        RefundStatusFromStripe(result.Status),
+		FailureReason: string(result.FailureReason),
 	}, nil
 }
 
+// RefundStatusFromStripe maps a Stripe refund status onto our refund statuses.
+// requires_action is treated as pending since the refund has not failed yet.
+func RefundStatusFromStripe(status stripe.RefundStatus) string {
+	switch status {
+	case stripe.RefundStatusSucceeded:
+		return domain.RefundStatusSucceeded
+	case stripe.RefundStatusFailed:
+		return domain.RefundStatusFailed
+	case stripe.RefundStatusCanceled:
+		return domain.RefundStatusCanceled
+	default:
+		return domain.RefundStatusPending
+	}
+}
+
 // This function will return the underlying information that is associated with a stripe token
 // In our case this is usually a bank token.
 // IMPORTANT NOTE: Due to the limitations of how Plaid + Stripe interact with each other in
@@ -210,7 +410,7 @@ func (s *stripeImpl) RetrieveStripeToken(ctx context.Context, tokenID string) (*
 
 	token, err := token.Get(tokenID, &stripe.TokenParams{})
 	if err != nil {
-		return nil, err
+		return nil, fromStripeError(err)
 	}
 
 	// This code is only executed when using the sandbox environment
@@ -239,14 +439,49 @@ func (s *stripeImpl) RetrievePaymentMethod(ctx context.Context, paymentMethodID
 		},
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve payment method: %w", err)
+		return nil, fmt.Errorf("failed to retrieve payment method: %w", fromStripeError(err))
 	}
-	return &domain.PaymentMethod{
-		ID:         pm.ID,
-		CustomerID: stripe.StringValue(&pm.Customer.ID),
-		Type:       string(pm.Type),
-		BankName:   pm.USBankAccount.BankName,
-		Last4:      pm.USBankAccount.Last4,
-		IsDefault:  false,
-	}, nil
+	method := &domain.PaymentMethod{
+		ID:        pm.ID,
+		Type:      string(pm.Type),
+		IsDefault: false,
+	}
+	// Detached payment methods no longer have a customer
+	if pm.Customer != nil {
+		method.CustomerID = pm.Customer.ID
+	}
+	if pm.USBankAccount != nil {
+		method.BankName = pm.USBankAccount.BankName
+		method.Last4 = pm.USBankAccount.Last4
+	}
+	return method, nil
+}
+
+/*
+ConstructWebhookEvent verifies the Stripe-Signature header against the raw request body and
+returns the parsed event.
+
+Events are rejected when no signature matches the configured webhook secret or when the signed
+timestamp is older than the configured tolerance, which protects against forged and replayed events.
+The event API version is not enforced since the account's webhook version can differ from the SDK's.
+*/
+func (s *stripeImpl) ConstructWebhookEvent(payload []byte, signatureHeader string) (*stripe.Event, error) {
+	if s.Config.WebhookKey == "" {
+		return nil, errors.New("stripe: webhook secret is not configured")
+	}
+
+	tolerance := s.Config.WebhookTolerance
+	if tolerance <= 0 {
+		tolerance = webhook.DefaultTolerance
+	}
+
+	event, err := webhook.ConstructEventWithOptions(payload, signatureHeader, s.Config.WebhookKey, webhook.ConstructEventOptions{
+		Tolerance:                tolerance,
+		IgnoreAPIVersionMismatch: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("stripe: invalid webhook signature: %w", err)
+	}
+
+	return &event, nil
 }
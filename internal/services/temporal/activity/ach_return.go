@@ -0,0 +1,130 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+)
+
+const (
+	HandleACHReturnActivity       = "HandleACHReturnActivity"
+	UpdateACHReturnActionActivity = "UpdateACHReturnActionActivity"
+)
+
+/*
+	Decides what to do about a returned ACH debit:
+		- Hard returns (account closed, not authorized, ...) detach the Stripe payment method and
+		  clear it as the customer's default so it is never debited again.
+		- Retryable returns (insufficient or uncollected funds) get a retry plan while the payment
+		  has re-presentments left. The workflow waits and starts the retry itself.
+		- Anything else is only recorded.
+
+	The chosen action is stored on the return, so a repeated call after it was handled is a no-op.
+*/
+
+type HandleACHReturnOutput struct {
+	Action string
+	Retry  *ACHRetryPlan // set when the debit should be presented again
+}
+
+// ACHRetryPlan carries what the workflow needs to re-present a returned debit.
+type ACHRetryPlan struct {
+	RetryOfPaymentID string // the original payment, shared by every retry
+	Attempt          int32
+	UserID           string
+	CustomerID       string
+	PaymentMethodID  string
+	PlaidAccountID   string
+	PlaidItemID      string
+	Amount           int64
+	Currency         string
+}
+
+func (a *TemporalActivityPort) handleACHReturnActivity(ctx context.Context, returnID string) (*HandleACHReturnOutput, error) {
+	achReturn, err := a.repository.GetACHReturnByID(ctx, returnID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ACH return %s: %w", returnID, err)
+	}
+	if achReturn.Action != domain.ACHReturnActionPending {
+		return &HandleACHReturnOutput{Action: achReturn.Action}, nil
+	}
+
+	payment, err := a.repository.GetPaymentByID(ctx, achReturn.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment %s: %w", achReturn.PaymentID, err)
+	}
+
+	code, _ := domain.LookupACHReturnCode(achReturn.ReturnCode)
+
+	output := &HandleACHReturnOutput{Action: domain.ACHReturnActionNone}
+	switch {
+	case code.DisablesPaymentMethod:
+		if err := a.disablePaymentMethod(ctx, payment); err != nil {
+			return nil, err
+		}
+		output.Action = domain.ACHReturnActionPaymentMethodDisabled
+
+	case achReturn.Retryable && payment.Attempt > domain.MaxACHRetryAttempts:
+		output.Action = domain.ACHReturnActionRetryLimitReached
+
+	case achReturn.Retryable:
+		retryOf := payment.RetryOfPaymentID
+		if retryOf == "" {
+			retryOf = payment.ID
+		}
+		output.Action = domain.ACHReturnActionRetryScheduled
+		output.Retry = &ACHRetryPlan{
+			RetryOfPaymentID: retryOf,
+			Attempt:          payment.Attempt + 1,
+			UserID:           payment.UserID,
+			CustomerID:       payment.StripeCustomerID,
+			PaymentMethodID:  payment.PaymentMethodID,
+			PlaidAccountID:   payment.PlaidAccountID,
+			PlaidItemID:      payment.PlaidItemID,
+			Amount:           payment.Amount,
+			Currency:         payment.Currency,
+		}
+	}
+
+	if err := a.repository.UpdateACHReturnAction(ctx, returnID, output.Action, ""); err != nil {
+		return nil, fmt.Errorf("failed to update ACH return %s: %w", returnID, err)
+	}
+	return output, nil
+}
+
+// disablePaymentMethod detaches the debited payment method in Stripe and drops it as the
+// customer's default. Already detached payment methods are skipped so retries are safe.
+func (a *TemporalActivityPort) disablePaymentMethod(ctx context.Context, payment *domain.Payment) error {
+	if payment.PaymentMethodID == "" {
+		return nil
+	}
+
+	pm, err := a.stripe.RetrievePaymentMethod(ctx, payment.PaymentMethodID)
+	if err != nil {
+		return err
+	}
+	if pm.CustomerID != "" {
+		if err := a.stripe.DeleteStripePaymentMethod(ctx, payment.PaymentMethodID); err != nil {
+			return err
+		}
+	}
+
+	if err := a.repository.ClearStripeCustomerDefaultPayment(ctx, payment.UserID, payment.PaymentMethodID); err != nil {
+		return fmt.Errorf("failed to clear default payment method for user %s: %w", payment.UserID, err)
+	}
+	return nil
+}
+
+type UpdateACHReturnActionInput struct {
+	ACHReturnID    string
+	Action         string
+	RetryPaymentID string
+}
+
+func (a *TemporalActivityPort) updateACHReturnActionActivity(ctx context.Context, input UpdateACHReturnActionInput) error {
+	if err := a.repository.UpdateACHReturnAction(ctx, input.ACHReturnID, input.Action, input.RetryPaymentID); err != nil {
+		return fmt.Errorf("failed to update ACH return %s: %w", input.ACHReturnID, err)
+	}
+	return nil
+}
@@ -2,13 +2,18 @@ package activity
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/GalaDe/payments-service/internal/domain"
 	"github.com/GalaDe/payments-service/internal/services/plaid"
 	"github.com/GalaDe/payments-service/internal/services/stripe"
+	"github.com/GalaDe/payments-service/internal/services/webhook"
+	"github.com/jackc/pgx/v4"
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/worker"
 )
 
@@ -17,14 +22,19 @@ type TemporalActivityPort struct {
 	stripe         stripe.StripeService
 	plaid          plaid.PlaidService
 	temporalClient client.Client
+	webhooks       *webhook.Processor
+	balanceCheck   domain.BalanceCheckPolicy
 }
 
-func NewTemporalActivityPort(repository domain.Repository, stripe stripe.StripeService, plaid plaid.PlaidService, temporalClient client.Client) *TemporalActivityPort {
+func NewTemporalActivityPort(repository domain.Repository, stripe stripe.StripeService, plaid plaid.PlaidService, temporalClient client.Client,
+	balanceCheck domain.BalanceCheckPolicy) *TemporalActivityPort {
 	return &TemporalActivityPort{
 		repository,
 		stripe,
 		plaid,
 		temporalClient,
+		webhook.NewProcessor(repository, temporalClient),
+		balanceCheck,
 	}
 }
 
@@ -32,14 +42,37 @@ const (
 	EnsureDefaultPaymentMethodActivity = "EnsureDefaultPaymentMethodActivity"
 	EnsurePlaidAccountActivity         = "EnsurePlaidAccountActivity"
 	GetOrCreateStripeCustomerActivity  = "GetOrCreateStripeCustomerActivity"
-	CreateACHCharge                    = "CreateACHCharge"
+	CreateACHPaymentIntent             = "CreateACHPaymentIntent"
+	GetACHPaymentIntent                = "GetACHPaymentIntent"
+	CancelACHPaymentIntentActivity     = "CancelACHPaymentIntentActivity"
+	CreatePaymentRecordActivity        = "CreatePaymentRecordActivity"
+	AttachStripePaymentActivity        = "AttachStripePaymentActivity"
+	UpdatePaymentStatusActivity        = "UpdatePaymentStatusActivity"
+	UpdatePaymentExecuteAtActivity     = "UpdatePaymentExecuteAtActivity"
 )
 
 func (a *TemporalActivityPort) RegisterActivities(w worker.ActivityRegistry) {
 	w.RegisterActivityWithOptions(a.ensureDefaultPaymentMethodActivity, activity.RegisterOptions{Name: EnsureDefaultPaymentMethodActivity})
 	w.RegisterActivityWithOptions(a.ensurePlaidAccountActivity, activity.RegisterOptions{Name: EnsurePlaidAccountActivity})
 	w.RegisterActivityWithOptions(a.getOrCreateStripeCustomerActivity, activity.RegisterOptions{Name: GetOrCreateStripeCustomerActivity})
-	w.RegisterActivityWithOptions(a.stripe.CreateACHCharge, activity.RegisterOptions{Name: CreateACHCharge})
+	w.RegisterActivityWithOptions(a.stripe.CreateACHPaymentIntent, activity.RegisterOptions{Name: CreateACHPaymentIntent})
+	w.RegisterActivityWithOptions(a.stripe.GetACHPaymentIntent, activity.RegisterOptions{Name: GetACHPaymentIntent})
+	w.RegisterActivityWithOptions(a.cancelACHPaymentIntentActivity, activity.RegisterOptions{Name: CancelACHPaymentIntentActivity})
+	w.RegisterActivityWithOptions(a.createPaymentRecordActivity, activity.RegisterOptions{Name: CreatePaymentRecordActivity})
+	w.RegisterActivityWithOptions(a.attachStripePaymentActivity, activity.RegisterOptions{Name: AttachStripePaymentActivity})
+	w.RegisterActivityWithOptions(a.updatePaymentStatusActivity, activity.RegisterOptions{Name: UpdatePaymentStatusActivity})
+	w.RegisterActivityWithOptions(a.updatePaymentExecuteAtActivity, activity.RegisterOptions{Name: UpdatePaymentExecuteAtActivity})
+	w.RegisterActivityWithOptions(a.checkBalanceActivity, activity.RegisterOptions{Name: CheckBalanceActivity})
+	w.RegisterActivityWithOptions(a.stripe.CreateRefund, activity.RegisterOptions{Name: CreateStripeRefund})
+	w.RegisterActivityWithOptions(a.updateRefundActivity, activity.RegisterOptions{Name: UpdateRefundActivity})
+	w.RegisterActivityWithOptions(a.processWebhookEventActivity, activity.RegisterOptions{Name: ProcessWebhookEventActivity})
+	w.RegisterActivityWithOptions(a.handleACHReturnActivity, activity.RegisterOptions{Name: HandleACHReturnActivity})
+	w.RegisterActivityWithOptions(a.updateACHReturnActionActivity, activity.RegisterOptions{Name: UpdateACHReturnActionActivity})
+	w.RegisterActivityWithOptions(a.startReconciliationRunActivity, activity.RegisterOptions{Name: StartReconciliationRunActivity})
+	w.RegisterActivityWithOptions(a.reconcileStripeActivity, activity.RegisterOptions{Name: ReconcileStripeActivity})
+	w.RegisterActivityWithOptions(a.failReconciliationRunActivity, activity.RegisterOptions{Name: FailReconciliationRunActivity})
+	w.RegisterActivityWithOptions(a.getDisputeActivity, activity.RegisterOptions{Name: GetDisputeActivity})
+	w.RegisterActivityWithOptions(a.alertDisputeDeadlineActivity, activity.RegisterOptions{Name: AlertDisputeDeadlineActivity})
 }
 
 /*
@@ -67,7 +100,7 @@ func (a *TemporalActivityPort) ensureDefaultPaymentMethodActivity(ctx context.Co
 	// If already has default payment method, return it
 	if customer.DefaultPaymentID.Valid {
 		pm, err := a.stripe.RetrievePaymentMethod(ctx, customer.DefaultPaymentID.String)
-		if err == nil {
+		if err == nil && pm.CustomerID != "" {
 			return pm, nil
 		}
 	}
@@ -97,28 +130,53 @@ func (a *TemporalActivityPort) ensureDefaultPaymentMethodActivity(ctx context.Co
 }
 
 /*
-	Check if the user has a Plaid account linked in your database (e.g., plaid_tokens table):
+	Check if the user has a Plaid account linked in your database (plaid_accounts / plaid_items tables):
 		- If yes → return access token + account ID.
 		- If no → return an error or trigger a setup flow (e.g., send link_token back to frontend).
 
 */
 
+type EnsurePlaidAccountInput struct {
+	UserID    string
+	AccountID string // optional, defaults to the user's default account
+}
+
 type EnsurePlaidAccountOutput struct {
 	AccessToken string
 	AccountID   string
+	ItemID      string
 }
 
-func (a *TemporalActivityPort) ensurePlaidAccountActivity(ctx context.Context, userID string) (*EnsurePlaidAccountOutput, error) {
-	// Step 1: Query your DB for plaid_tokens
-	token, err := a.repository.GetPlaidToken(ctx, userID)
+func (a *TemporalActivityPort) ensurePlaidAccountActivity(ctx context.Context, input EnsurePlaidAccountInput) (*EnsurePlaidAccountOutput, error) {
+	userID := input.UserID
+
+	// Step 1: Query your DB for the linked account
+	var token *domain.PlaidToken
+	var err error
+	if input.AccountID != "" {
+		token, err = a.repository.GetPlaidTokenForAccount(ctx, userID, input.AccountID)
+	} else {
+		token, err = a.repository.GetPlaidToken(ctx, userID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("plaid account not linked for user %s: %w", userID, err)
 	}
 
-	// Step 2: Return accessToken and accountID
+	// Step 2: Refuse Items Plaid told us are broken, retrying won't help until the user relinks
+	item, err := a.repository.GetPlaidItem(ctx, token.ItemID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	if item != nil && !item.IsUsable() {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("plaid item %s for user %s is %s", token.ItemID, userID, item.Status), "PlaidItemUnusable", nil)
+	}
+
+	// Step 3: Return accessToken and accountID
 	return &EnsurePlaidAccountOutput{
 		AccessToken: token.AccessToken,
 		AccountID:   token.AccountID,
+		ItemID:      token.ItemID,
 	}, nil
 }
 
@@ -164,3 +222,112 @@ func (a *TemporalActivityPort) getOrCreateStripeCustomerActivity(ctx context.Con
 
 	return newCustomer, nil
 }
+
+/*
+	Persists the payment row before any money moves so the payment can be polled via GET /payments/{id}.
+	The ID is generated by the caller, which keeps the insert idempotent across activity retries.
+*/
+
+type CreatePaymentRecordInput struct {
+	PaymentID          string
+	WorkflowID         string
+	UserID             string
+	PlaidAccountID     string
+	PlaidItemID        string
+	StripeCustomerID   string
+	Amount             int64
+	Currency           string
+	RetryOfPaymentID   string // set when re-presenting a returned debit
+	Attempt            int32
+	RecurringPaymentID string     // set when started by a recurring payment's schedule
+	ExecuteAt          *time.Time // set for payments scheduled for a later date
+}
+
+func (a *TemporalActivityPort) createPaymentRecordActivity(ctx context.Context, input CreatePaymentRecordInput) error {
+	status := domain.PaymentStatusCreated
+	if input.ExecuteAt != nil {
+		status = domain.PaymentStatusScheduled
+	}
+
+	err := a.repository.InsertPayment(ctx, &domain.Payment{
+		ID:                 input.PaymentID,
+		UserID:             input.UserID,
+		Amount:             input.Amount,
+		Currency:           input.Currency,
+		PlaidAccountID:     input.PlaidAccountID,
+		PlaidItemID:        input.PlaidItemID,
+		StripeCustomerID:   input.StripeCustomerID,
+		Status:             status,
+		WorkflowID:         input.WorkflowID,
+		RetryOfPaymentID:   input.RetryOfPaymentID,
+		Attempt:            input.Attempt,
+		RecurringPaymentID: input.RecurringPaymentID,
+		ExecuteAt:          input.ExecuteAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert payment %s: %w", input.PaymentID, err)
+	}
+	return nil
+}
+
+type UpdatePaymentExecuteAtInput struct {
+	PaymentID string
+	ExecuteAt time.Time
+}
+
+func (a *TemporalActivityPort) updatePaymentExecuteAtActivity(ctx context.Context, input UpdatePaymentExecuteAtInput) error {
+	if err := a.repository.UpdatePaymentExecuteAt(ctx, input.PaymentID, input.ExecuteAt); err != nil {
+		return fmt.Errorf("failed to reschedule payment %s: %w", input.PaymentID, err)
+	}
+	return nil
+}
+
+type AttachStripePaymentInput struct {
+	PaymentID            string
+	StripePaymentID      string
+	PaymentMethodID      string
+	ExpectedSettlementAt *time.Time // set when the debit was submitted and is waiting to settle
+}
+
+func (a *TemporalActivityPort) attachStripePaymentActivity(ctx context.Context, input AttachStripePaymentInput) error {
+	if err := a.repository.UpdatePaymentStripeID(ctx, input.PaymentID, input.StripePaymentID, input.PaymentMethodID); err != nil {
+		return fmt.Errorf("failed to attach stripe payment %s to payment %s: %w", input.StripePaymentID, input.PaymentID, err)
+	}
+	if input.ExpectedSettlementAt != nil {
+		if err := a.repository.UpdatePaymentExpectedSettlement(ctx, input.PaymentID, *input.ExpectedSettlementAt); err != nil {
+			return fmt.Errorf("failed to set expected settlement of payment %s: %w", input.PaymentID, err)
+		}
+	}
+	return nil
+}
+
+/*
+	Cancels the PaymentIntent of a payment the customer asked to cancel. Stripe refusing
+	because the debit was already submitted is final, so it is not retried.
+*/
+
+func (a *TemporalActivityPort) cancelACHPaymentIntentActivity(ctx context.Context, paymentIntentID string) (*domain.ACHCharge, error) {
+	charge, err := a.stripe.CancelACHPaymentIntent(ctx, paymentIntentID)
+	if errors.Is(err, domain.ErrPaymentNotCancelable) {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), "PaymentNotCancelable", err)
+	}
+	return charge, err
+}
+
+type UpdatePaymentStatusInput struct {
+	PaymentID string
+	Status    domain.PaymentStatus
+	Reason    string // recorded in the payment's status history
+}
+
+func (a *TemporalActivityPort) updatePaymentStatusActivity(ctx context.Context, input UpdatePaymentStatusInput) error {
+	err := a.repository.UpdatePaymentStatus(ctx, input.PaymentID, input.Status, input.Reason)
+	if errors.Is(err, domain.ErrInvalidPaymentTransition) {
+		// The state machine won't change its mind on retry
+		return temporal.NewNonRetryableApplicationError(err.Error(), "InvalidPaymentTransition", err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update payment %s status to %s: %w", input.PaymentID, input.Status, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+)
+
+const CheckBalanceActivity = "CheckBalanceActivity"
+
+/*
+	Checks the funding account's real-time Plaid balance before a payment is charged, so
+	accounts that clearly can't cover it aren't debited only to come back as R01 returns.
+
+	The check passes when the available balance covers the amount plus the configured
+	buffer. The snapshot is stored on the payment; the configured mode tells the workflow
+	whether a failed check fails the payment or holds it for review.
+
+	Institutions without the balance product and Plaid errors don't block the payment,
+	they are recorded as unsupported or unavailable.
+*/
+
+type CheckBalanceInput struct {
+	PaymentID      string
+	UserID         string
+	PlaidAccountID string
+	Amount         int64
+}
+
+type CheckBalanceOutput struct {
+	Mode  string               // configured balance check mode
+	Check *domain.BalanceCheck // nil when the check is turned off
+}
+
+func (a *TemporalActivityPort) checkBalanceActivity(ctx context.Context, input CheckBalanceInput) (*CheckBalanceOutput, error) {
+	output := &CheckBalanceOutput{Mode: a.balanceCheck.Mode}
+	if a.balanceCheck.Mode == domain.BalanceCheckModeOff {
+		return output, nil
+	}
+
+	var token *domain.PlaidToken
+	var err error
+	if input.PlaidAccountID != "" {
+		token, err = a.repository.GetPlaidTokenForAccount(ctx, input.UserID, input.PlaidAccountID)
+	} else {
+		token, err = a.repository.GetPlaidToken(ctx, input.UserID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("plaid account not linked for user %s: %w", input.UserID, err)
+	}
+
+	check := &domain.BalanceCheck{
+		Required:  input.Amount + a.balanceCheck.Buffer,
+		CheckedAt: time.Now().UTC(),
+	}
+	check.Result = a.readBalance(ctx, token.AccessToken, token.AccountID, check)
+
+	if err := a.repository.UpdatePaymentBalanceCheck(ctx, input.PaymentID, check); err != nil {
+		return nil, fmt.Errorf("failed to record balance check for payment %s: %w", input.PaymentID, err)
+	}
+
+	output.Check = check
+	return output, nil
+}
+
+// readBalance fills in the available balance and returns the check's result.
+func (a *TemporalActivityPort) readBalance(ctx context.Context, accessToken, accountID string, check *domain.BalanceCheck) string {
+	supported, err := a.plaid.IsBalanceCheckSupported(ctx, accessToken, accountID)
+	if err != nil {
+		return domain.BalanceCheckUnavailable
+	}
+	if !supported {
+		return domain.BalanceCheckUnsupported
+	}
+
+	account, err := a.plaid.GetAccountWithBalance(ctx, accessToken, accountID)
+	if err != nil {
+		return domain.BalanceCheckUnavailable
+	}
+
+	// Plaid reports balances in dollars
+	check.Available = int64(math.Round(account.Balance * 100))
+	if check.Available < check.Required {
+		return domain.BalanceCheckInsufficient
+	}
+	return domain.BalanceCheckPassed
+}
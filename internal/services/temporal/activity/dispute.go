@@ -0,0 +1,55 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+)
+
+const (
+	GetDisputeActivity           = "GetDisputeActivity"
+	AlertDisputeDeadlineActivity = "AlertDisputeDeadlineActivity"
+)
+
+func (a *TemporalActivityPort) getDisputeActivity(ctx context.Context, disputeID string) (*domain.Dispute, error) {
+	dispute, err := a.repository.GetDispute(ctx, disputeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dispute %s: %w", disputeID, err)
+	}
+	return dispute, nil
+}
+
+/*
+	Raises an alert that a dispute's evidence is due soon, and records it so the
+	alert isn't raised again. Nothing is raised once evidence was submitted or the
+	dispute no longer accepts it.
+*/
+
+func (a *TemporalActivityPort) alertDisputeDeadlineActivity(ctx context.Context, disputeID string) error {
+	dispute, err := a.repository.GetDispute(ctx, disputeID)
+	if err != nil {
+		return fmt.Errorf("failed to get dispute %s: %w", disputeID, err)
+	}
+	if !dispute.AwaitingEvidence() {
+		return nil
+	}
+
+	remaining := time.Until(*dispute.EvidenceDueBy).Round(time.Minute)
+	activity.GetLogger(ctx).Warn("Dispute evidence due soon",
+		"DisputeID", dispute.ID,
+		"StripeDisputeID", dispute.StripeDisputeID,
+		"PaymentID", dispute.PaymentID,
+		"Amount", dispute.Amount,
+		"Reason", dispute.Reason,
+		"EvidenceDueBy", dispute.EvidenceDueBy,
+		"Remaining", remaining)
+
+	if err := a.repository.MarkDisputeAlerted(ctx, disputeID); err != nil {
+		return fmt.Errorf("failed to mark dispute %s alerted: %w", disputeID, err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+)
+
+const (
+	StartReconciliationRunActivity = "StartReconciliationRunActivity"
+	ReconcileStripeActivity        = "ReconcileStripeActivity"
+	FailReconciliationRunActivity  = "FailReconciliationRunActivity"
+)
+
+type ReconciliationInput struct {
+	RunID       string
+	WorkflowID  string
+	PeriodStart time.Time
+	PeriodEnd   time.Time // exclusive
+}
+
+func (a *TemporalActivityPort) startReconciliationRunActivity(ctx context.Context, input ReconciliationInput) error {
+	err := a.repository.CreateReconciliationRun(ctx, &domain.ReconciliationRun{
+		ID:          input.RunID,
+		WorkflowID:  input.WorkflowID,
+		PeriodStart: input.PeriodStart,
+		PeriodEnd:   input.PeriodEnd,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create reconciliation run %s: %w", input.RunID, err)
+	}
+	return nil
+}
+
+/*
+	Compares Stripe with our payments for the run's period and stores the report:
+		1. Pull the balance transactions and payouts created in the period
+		2. Load the payments their PaymentIntents belong to, and the payments that succeeded in the period
+		3. Build the report (see domain.Reconcile)
+		4. Post the Stripe fees of matched charges to the ledger, keyed by balance transaction
+		   so a repeated run doesn't post them twice
+
+	Returns the number of discrepancies found.
+*/
+
+func (a *TemporalActivityPort) reconcileStripeActivity(ctx context.Context, input ReconciliationInput) (int, error) {
+	txns, err := a.stripe.ListBalanceTransactions(ctx, input.PeriodStart, input.PeriodEnd)
+	if err != nil {
+		return 0, err
+	}
+	payouts, err := a.stripe.ListPayouts(ctx, input.PeriodStart, input.PeriodEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	var intentIDs []string
+	for _, txn := range txns {
+		if txn.PaymentIntentID != "" {
+			intentIDs = append(intentIDs, txn.PaymentIntentID)
+		}
+	}
+	payments, err := a.repository.ListPaymentsByStripePaymentIDs(ctx, intentIDs)
+	if err != nil {
+		return 0, err
+	}
+	settled, err := a.repository.ListPaymentsSucceededBetween(ctx, input.PeriodStart, input.PeriodEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	report := domain.Reconcile(payments, settled, txns, payouts)
+
+	if err := a.postStripeFees(ctx, payments, txns); err != nil {
+		return 0, err
+	}
+
+	if err := a.repository.CompleteReconciliationRun(ctx, input.RunID, report); err != nil {
+		return 0, fmt.Errorf("failed to complete reconciliation run %s: %w", input.RunID, err)
+	}
+	return len(report.Discrepancies), nil
+}
+
+func (a *TemporalActivityPort) postStripeFees(ctx context.Context, payments []*domain.Payment, txns []*domain.StripeBalanceTransaction) error {
+	byIntent := make(map[string]*domain.Payment, len(payments))
+	for _, p := range payments {
+		byIntent[p.StripePaymentID] = p
+	}
+
+	for _, txn := range txns {
+		payment, ok := byIntent[txn.PaymentIntentID]
+		if !ok || txn.Fee <= 0 {
+			continue
+		}
+		if txn.Type != domain.StripeBalanceTransactionCharge && txn.Type != domain.StripeBalanceTransactionPayment {
+			continue
+		}
+		if _, err := a.repository.PostJournalEntry(ctx, domain.FeePosting(payment, txn.Fee, txn.ID, txn.CreatedAt)); err != nil {
+			return fmt.Errorf("failed to post fee of balance transaction %s: %w", txn.ID, err)
+		}
+	}
+	return nil
+}
+
+type FailReconciliationRunInput struct {
+	RunID  string
+	Reason string
+}
+
+func (a *TemporalActivityPort) failReconciliationRunActivity(ctx context.Context, input FailReconciliationRunInput) error {
+	if err := a.repository.FailReconciliationRun(ctx, input.RunID, input.Reason); err != nil {
+		return fmt.Errorf("failed to mark reconciliation run %s failed: %w", input.RunID, err)
+	}
+	return nil
+}
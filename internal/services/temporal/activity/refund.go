@@ -0,0 +1,25 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+)
+
+const (
+	CreateStripeRefund   = "CreateStripeRefund"
+	UpdateRefundActivity = "UpdateRefundActivity"
+)
+
+/*
+	Stores the Stripe outcome of a refund. The repository also moves the payment to
+	refunded or partially_refunded in the same transaction.
+*/
+
+func (a *TemporalActivityPort) updateRefundActivity(ctx context.Context, refund domain.Refund) error {
+	if err := a.repository.UpdateRefund(ctx, &refund); err != nil {
+		return fmt.Errorf("failed to update refund %s: %w", refund.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+)
+
+const (
+	ProcessWebhookEventActivity = "ProcessWebhookEventActivity"
+)
+
+/*
+	Loads a stored webhook event and applies it. Processed events are skipped so a
+	duplicate workflow never applies the same event twice; failures are recorded on
+	the event so it can be replayed from the admin API.
+
+	The output tells the workflow which follow-up workflows to start.
+*/
+
+type ProcessWebhookEventOutput struct {
+	ACHReturnID string // ACH return to hand to ACHReturnWorkflow
+	DisputeID   string // dispute to hand to DisputeDeadlineWorkflow
+}
+
+func (a *TemporalActivityPort) processWebhookEventActivity(ctx context.Context, eventID string) (*ProcessWebhookEventOutput, error) {
+	event, err := a.repository.GetWebhookEventByID(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook event %s: %w", eventID, err)
+	}
+
+	if event.Status == domain.WebhookEventStatusProcessed {
+		return &ProcessWebhookEventOutput{}, nil
+	}
+
+	if err := a.repository.MarkWebhookEventProcessing(ctx, eventID); err != nil {
+		return nil, fmt.Errorf("failed to mark webhook event %s as processing: %w", eventID, err)
+	}
+
+	result, err := a.webhooks.Process(ctx, event)
+	if err != nil {
+		if markErr := a.repository.MarkWebhookEventFailed(ctx, eventID, err.Error()); markErr != nil {
+			return nil, fmt.Errorf("failed to mark webhook event %s as failed: %w", eventID, markErr)
+		}
+		return nil, fmt.Errorf("failed to process %s webhook event %s: %w", event.Provider, event.EventID, err)
+	}
+
+	if err := a.repository.MarkWebhookEventProcessed(ctx, eventID); err != nil {
+		return nil, fmt.Errorf("failed to mark webhook event %s as processed: %w", eventID, err)
+	}
+	return &ProcessWebhookEventOutput{ACHReturnID: result.ACHReturnID, DisputeID: result.DisputeID}, nil
+}
@@ -0,0 +1,89 @@
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	activity "github.com/GalaDe/payments-service/internal/services/temporal/activity"
+)
+
+// ACHReturnWorkflowID is the workflow ID used for an ACH return, so a return is
+// only ever handled once no matter how often its webhook is delivered.
+func ACHReturnWorkflowID(returnID string) string {
+	return "ach-return-" + returnID
+}
+
+type ACHReturnWorkflowInput struct {
+	ACHReturnID string `json:"ach_return_id"`
+}
+
+/*
+ 1. Classify the return: disable the payment method on hard returns, plan a retry on retryable ones
+ 2. For a retry, wait the retry delay and start a new PaymentWorkflow for the same debit
+ 3. Link the retry payment to the return
+*/
+func achReturnWorkflow(ctx workflow.Context, input ACHReturnWorkflowInput) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: DefaultActivityTimeout,
+		RetryPolicy:         RetryPolicy3Attempts,
+	})
+
+	// Step 1: Decide and apply the action
+	var handled *activity.HandleACHReturnOutput
+	if err := workflow.ExecuteActivity(ctx, activity.HandleACHReturnActivity, input.ACHReturnID).Get(ctx, &handled); err != nil {
+		return err
+	}
+	if handled.Retry == nil {
+		return nil
+	}
+
+	// Step 2: Re-present the debit later as a new payment
+	if err := workflow.Sleep(ctx, DefaultACHRetryDelay); err != nil {
+		return err
+	}
+
+	var retryPaymentID string
+	encoded := workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+		return uuid.NewString()
+	})
+	if err := encoded.Get(&retryPaymentID); err != nil {
+		return err
+	}
+
+	retry := handled.Retry
+	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID:        fmt.Sprintf("payment-%s-%s", retry.CustomerID, retryPaymentID),
+		TaskQueue:         DefaultTaskQueue,
+		ParentClosePolicy: enumspb.PARENT_CLOSE_POLICY_ABANDON,
+	})
+	paymentInput := PaymentWorkflowInput{
+		PaymentID:        retryPaymentID,
+		UserID:           retry.UserID,
+		CustomerID:       retry.CustomerID,
+		PaymentMethodID:  retry.PaymentMethodID,
+		PlaidAccountID:   retry.PlaidAccountID,
+		PlaidItemID:      retry.PlaidItemID,
+		Amount:           retry.Amount,
+		Currency:         retry.Currency,
+		Description:      fmt.Sprintf("ACH retry %d of payment %s", retry.Attempt-1, retry.RetryOfPaymentID),
+		IdempotencyKey:   "ach-retry-" + retryPaymentID,
+		RetryOfPaymentID: retry.RetryOfPaymentID,
+		Attempt:          retry.Attempt,
+	}
+	child := workflow.ExecuteChildWorkflow(childCtx, PaymentWorkflow, paymentInput)
+	if err := child.GetChildWorkflowExecution().Get(ctx, nil); err != nil {
+		return err
+	}
+
+	// Step 3: Record the retry payment on the return
+	updateInput := activity.UpdateACHReturnActionInput{
+		ACHReturnID:    input.ACHReturnID,
+		Action:         domain.ACHReturnActionRetryScheduled,
+		RetryPaymentID: retryPaymentID,
+	}
+	return workflow.ExecuteActivity(ctx, activity.UpdateACHReturnActionActivity, updateInput).Get(ctx, nil)
+}
@@ -0,0 +1,57 @@
+package workflow
+
+import (
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	activity "github.com/GalaDe/payments-service/internal/services/temporal/activity"
+)
+
+// DisputeDeadlineWorkflowID is the workflow ID used for a dispute, so every event of a
+// dispute shares one deadline tracker.
+func DisputeDeadlineWorkflowID(disputeID string) string {
+	return "dispute-deadline-" + disputeID
+}
+
+type DisputeDeadlineWorkflowInput struct {
+	DisputeID string `json:"dispute_id"`
+}
+
+/*
+ 1. Load the dispute and find the next alert: DisputeEvidenceAlertLeads before its evidence is due
+ 2. Sleep until then, and load the dispute again in case evidence was submitted meanwhile
+ 3. Raise the alert, and repeat until no alert is left or the dispute stops awaiting evidence
+
+Alerts that were missed, e.g. for a dispute reported close to its deadline, are raised once.
+*/
+func disputeDeadlineWorkflow(ctx workflow.Context, input DisputeDeadlineWorkflowInput) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: DefaultActivityTimeout,
+		RetryPolicy:         RetryPolicy3Attempts,
+	})
+
+	for {
+		// Step 1: Find the next alert
+		var dispute *domain.Dispute
+		if err := workflow.ExecuteActivity(ctx, activity.GetDisputeActivity, input.DisputeID).Get(ctx, &dispute); err != nil {
+			return err
+		}
+		alertAt, ok := dispute.NextEvidenceAlert(DisputeEvidenceAlertLeads)
+		if !ok {
+			return nil
+		}
+
+		// Step 2: Wait for it
+		if wait := alertAt.Sub(workflow.Now(ctx)); wait > 0 {
+			if err := workflow.Sleep(ctx, wait); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Step 3: Alert
+		if err := workflow.ExecuteActivity(ctx, activity.AlertDisputeDeadlineActivity, input.DisputeID).Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package workflow
+
+import (
+	"errors"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	activity "github.com/GalaDe/payments-service/internal/services/temporal/activity"
+)
+
+/*
+paymentCancellation tracks PaymentCancelSignal requests for a PaymentWorkflow and
+exposes the outcome through the PaymentCancellationQuery, which is how
+POST /payments/{id}/cancel learns whether the cancel went through.
+
+Requests are picked up between steps (poll) and while waiting for settlement
+(signals). A request that is still open when the workflow finishes is rejected.
+*/
+type paymentCancellation struct {
+	signals workflow.ReceiveChannel
+	state   domain.PaymentCancellation
+}
+
+func watchCancellation(ctx workflow.Context) (*paymentCancellation, error) {
+	c := &paymentCancellation{
+		signals: workflow.GetSignalChannel(ctx, domain.PaymentCancelSignal),
+	}
+	err := workflow.SetQueryHandler(ctx, domain.PaymentCancellationQuery, func() (domain.PaymentCancellation, error) {
+		return c.state, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// poll drains pending cancel signals without blocking and reports whether a cancel is open.
+func (c *paymentCancellation) poll() bool {
+	var req domain.PaymentCancelRequest
+	for c.signals.ReceiveAsync(&req) {
+		c.request(req)
+	}
+	return c.pending()
+}
+
+func (c *paymentCancellation) receive(ctx workflow.Context, ch workflow.ReceiveChannel) {
+	var req domain.PaymentCancelRequest
+	ch.Receive(ctx, &req)
+	c.request(req)
+}
+
+func (c *paymentCancellation) request(req domain.PaymentCancelRequest) {
+	// A request after the payment was already canceled or refused changes nothing
+	if c.state.State == "" {
+		c.state = domain.PaymentCancellation{
+			State:  domain.PaymentCancellationRequested,
+			Reason: req.Reason,
+		}
+	}
+}
+
+func (c *paymentCancellation) pending() bool {
+	return c.state.State == domain.PaymentCancellationRequested
+}
+
+func (c *paymentCancellation) reject(reason string) {
+	c.state = domain.PaymentCancellation{
+		State:  domain.PaymentCancellationRejected,
+		Reason: reason,
+	}
+}
+
+// rejectPending refuses a cancel that arrived too late to be acted on.
+func (c *paymentCancellation) rejectPending(reason string) {
+	if c.poll() {
+		c.reject(reason)
+	}
+}
+
+/*
+cancel runs the compensation for the steps that already completed and moves the
+payment to canceled, reporting whether it did:
+
+  - no PaymentIntent yet: no money moved, only the payment record changes
+  - a PaymentIntent exists: it is canceled in Stripe, which fails once the debit was
+    submitted to the ACH network; the request is then rejected and the payment goes on
+
+Customer and payment method setup is reusable and left in place.
+*/
+func (c *paymentCancellation) cancel(ctx workflow.Context, paymentID, paymentIntentID string) (bool, error) {
+	if paymentIntentID != "" {
+		err := workflow.ExecuteActivity(ctx, activity.CancelACHPaymentIntentActivity, paymentIntentID).Get(ctx, nil)
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) && appErr.Type() == "PaymentNotCancelable" {
+			c.reject("the payment was already submitted to the ACH network")
+			return false, nil
+		}
+		if err != nil {
+			c.reject("the payment could not be canceled: " + err.Error())
+			return false, nil
+		}
+	}
+
+	reason := "canceled by request"
+	if c.state.Reason != "" {
+		reason += ": " + c.state.Reason
+	}
+	if err := markPayment(ctx, paymentID, domain.PaymentStatusCanceled, reason); err != nil {
+		return false, err
+	}
+
+	c.state.State = domain.PaymentCancellationCanceled
+	return true, nil
+}
@@ -0,0 +1,96 @@
+package workflow
+
+import (
+	"fmt"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	activity "github.com/GalaDe/payments-service/internal/services/temporal/activity"
+)
+
+/*
+checkBalance runs the optional pre-charge balance check and reports whether the
+payment should be charged. When the balance can't cover the payment:
+
+  - in "fail" mode the payment fails right away
+  - in "review" mode it is put on hold until a PaymentReviewSignal approves or rejects
+    it, or DefaultPaymentReviewTimeout passes and it fails
+
+A held payment can still be canceled.
+*/
+func checkBalance(ctx workflow.Context, input PaymentWorkflowInput, cancellation *paymentCancellation) (bool, error) {
+	checkInput := activity.CheckBalanceInput{
+		PaymentID:      input.PaymentID,
+		UserID:         input.UserID,
+		PlaidAccountID: input.PlaidAccountID,
+		Amount:         input.Amount,
+	}
+	var result *activity.CheckBalanceOutput
+	if err := workflow.ExecuteActivity(ctx, activity.CheckBalanceActivity, checkInput).Get(ctx, &result); err != nil {
+		return false, err
+	}
+	if result.Check == nil || result.Check.Result != domain.BalanceCheckInsufficient {
+		return true, nil
+	}
+
+	reason := fmt.Sprintf("insufficient funds: available %d, required %d", result.Check.Available, result.Check.Required)
+	if result.Mode != domain.BalanceCheckModeReview {
+		return false, markPayment(ctx, input.PaymentID, domain.PaymentStatusFailed, "balance check failed, "+reason)
+	}
+
+	if err := markPayment(ctx, input.PaymentID, domain.PaymentStatusOnHold, "held for review, "+reason); err != nil {
+		return false, err
+	}
+	return awaitReview(ctx, input.PaymentID, cancellation)
+}
+
+func awaitReview(ctx workflow.Context, paymentID string, cancellation *paymentCancellation) (bool, error) {
+	logger := workflow.GetLogger(ctx)
+
+	timerCtx, cancelTimer := workflow.WithCancel(ctx)
+	defer cancelTimer()
+	timer := workflow.NewTimer(timerCtx, DefaultPaymentReviewTimeout)
+
+	signals := workflow.GetSignalChannel(ctx, domain.PaymentReviewSignal)
+	var decision *domain.PaymentReviewDecision
+	timedOut := false
+
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(signals, func(c workflow.ReceiveChannel, more bool) {
+		c.Receive(ctx, &decision)
+	})
+	selector.AddFuture(timer, func(f workflow.Future) {
+		timedOut = true
+	})
+	selector.AddReceive(cancellation.signals, func(c workflow.ReceiveChannel, more bool) {
+		cancellation.receive(ctx, c)
+	})
+
+	for {
+		selector.Select(ctx)
+
+		switch {
+		case cancellation.pending():
+			_, err := cancellation.cancel(ctx, paymentID, "")
+			return false, err
+
+		case timedOut:
+			logger.Warn("Payment review timed out", "payment_id", paymentID)
+			return false, markPayment(ctx, paymentID, domain.PaymentStatusFailed, "review timed out")
+
+		case decision != nil && decision.Approved:
+			return true, markPayment(ctx, paymentID, domain.PaymentStatusCreated, reviewReason("approved in review", decision.Reason))
+
+		case decision != nil:
+			return false, markPayment(ctx, paymentID, domain.PaymentStatusFailed, reviewReason("rejected in review", decision.Reason))
+		}
+	}
+}
+
+func reviewReason(outcome, reason string) string {
+	if reason == "" {
+		return outcome
+	}
+	return outcome + ": " + reason
+}
@@ -0,0 +1,95 @@
+package workflow
+
+import (
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	activity "github.com/GalaDe/payments-service/internal/services/temporal/activity"
+)
+
+/*
+paymentSchedule holds a PaymentWorkflow until its execute_at date. The wait is a
+durable timer, so it survives worker restarts and deploys.
+
+While waiting the payment can be:
+  - rescheduled with a PaymentRescheduleSignal, which stores the new date and restarts the timer
+  - canceled with a PaymentCancelSignal, which only has to update the record
+
+The PaymentScheduleQuery reports the current date and whether it was reached, which is how
+POST /payments/{id}/reschedule learns whether the new date was taken.
+*/
+type paymentSchedule struct {
+	signals workflow.ReceiveChannel
+	state   domain.PaymentSchedule
+}
+
+func watchSchedule(ctx workflow.Context, executeAt *time.Time) (*paymentSchedule, error) {
+	s := &paymentSchedule{
+		signals: workflow.GetSignalChannel(ctx, domain.PaymentRescheduleSignal),
+	}
+	if executeAt != nil {
+		s.state.ExecuteAt = *executeAt
+	} else {
+		s.state.Started = true
+	}
+	err := workflow.SetQueryHandler(ctx, domain.PaymentScheduleQuery, func() (domain.PaymentSchedule, error) {
+		return s.state, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// await blocks until the execution date and reports whether the payment should go ahead;
+// false means it was canceled while waiting.
+func (s *paymentSchedule) await(ctx workflow.Context, paymentID string, cancellation *paymentCancellation) (bool, error) {
+	logger := workflow.GetLogger(ctx)
+
+	for !s.state.Started {
+		// A date already in the past, e.g. after a long outage, fires right away
+		wait := s.state.ExecuteAt.Sub(workflow.Now(ctx))
+		if wait < 0 {
+			wait = 0
+		}
+		timerCtx, cancelTimer := workflow.WithCancel(ctx)
+		timer := workflow.NewTimer(timerCtx, wait)
+
+		var reschedule *domain.PaymentRescheduleRequest
+		selector := workflow.NewSelector(ctx)
+		selector.AddFuture(timer, func(f workflow.Future) {
+			s.state.Started = true
+		})
+		selector.AddReceive(s.signals, func(c workflow.ReceiveChannel, more bool) {
+			c.Receive(ctx, &reschedule)
+		})
+		selector.AddReceive(cancellation.signals, func(c workflow.ReceiveChannel, more bool) {
+			cancellation.receive(ctx, c)
+		})
+		selector.Select(ctx)
+		cancelTimer()
+
+		if cancellation.pending() {
+			canceled, err := cancellation.cancel(ctx, paymentID, "")
+			if err != nil || canceled {
+				return false, err
+			}
+		}
+
+		if reschedule != nil && !reschedule.ExecuteAt.Equal(s.state.ExecuteAt) {
+			updateInput := activity.UpdatePaymentExecuteAtInput{
+				PaymentID: paymentID,
+				ExecuteAt: reschedule.ExecuteAt,
+			}
+			if err := workflow.ExecuteActivity(ctx, activity.UpdatePaymentExecuteAtActivity, updateInput).Get(ctx, nil); err != nil {
+				return false, err
+			}
+			logger.Info("Payment rescheduled", "payment_id", paymentID, "execute_at", reschedule.ExecuteAt)
+			s.state.ExecuteAt = reschedule.ExecuteAt
+		}
+	}
+
+	return true, nil
+}
@@ -1,23 +1,45 @@
 package workflow
 
 import (
+	"time"
+
+	"github.com/google/uuid"
 	"go.temporal.io/sdk/workflow"
 
+	"github.com/GalaDe/payments-service/internal/calendar"
 	"github.com/GalaDe/payments-service/internal/domain"
 	activity "github.com/GalaDe/payments-service/internal/services/temporal/activity"
 )
 
 type PaymentWorkflowInput struct {
-	UserID           string `json:"user_id"`           // internal app user
-	CustomerID       string `json:"customer_id"`       // Stripe customer ID
-	PaymentMethodID  string `json:"payment_method_id"`
-	Amount           int64  `json:"amount"`
-	Currency         string `json:"currency"`
-	Description      string `json:"description"`
-	IdempotencyKey   string `json:"idempotency_key"`
+	PaymentID       string                    `json:"payment_id"`  // internal payment UUID, generated by the API or by the workflow when empty
+	UserID          string                    `json:"user_id"`     // internal app user
+	CustomerID      string                    `json:"customer_id"` // Stripe customer ID
+	PaymentMethodID string                    `json:"payment_method_id"`
+	PlaidAccountID  string                    `json:"plaid_account_id"` // funding account picked by the user
+	PlaidItemID     string                    `json:"plaid_item_id"`
+	Amount          int64                     `json:"amount"`
+	Currency        string                    `json:"currency"`
+	Description     string                    `json:"description"`
+	IdempotencyKey  string                    `json:"idempotency_key"`
+	Mandate         *domain.MandateAcceptance `json:"mandate"`
+	// RetryOfPaymentID and Attempt are set when the workflow re-presents a debit
+	// that was returned as retryable (see achReturnWorkflow).
+	RetryOfPaymentID string `json:"retry_of_payment_id"`
+	Attempt          int32  `json:"attempt"`
+	// RecurringPaymentID is set on runs started by a recurring payment's schedule,
+	// which reuses the same input for every cycle (see recurringPaymentSchedule).
+	RecurringPaymentID string `json:"recurring_payment_id"`
+	// ExecuteAt holds the charge until this date (see paymentSchedule). Nil charges right away.
+	ExecuteAt *time.Time `json:"execute_at"`
+	// SettlementTimeout bounds how long the workflow waits for a settlement signal
+	// before asking Stripe directly. Defaults to DefaultSettlementTimeout.
+	SettlementTimeout time.Duration `json:"settlement_timeout"`
 }
 
 /*
+ 0. Save a pending payment record, and for a scheduled payment wait for its execute_at date
+    a. Optionally check the funding account's balance, failing or holding the payment for review
  1. Check if Plaid/Stripe account setup exists
  2. If not:
     a. Retrieve Plaid token from DB (or error out)
@@ -25,6 +47,11 @@ type PaymentWorkflowInput struct {
     c. Create Stripe bank account or payment method (if needed)
  3. Proceed to charge the customer (ACH)
  4. Update DB
+ 5. Wait for the settlement signal sent by the Stripe webhook, falling back to Stripe on timeout
+ 6. Update DB with the settlement outcome
+
+A PaymentCancelSignal received before the debit is submitted to the ACH network cancels
+the PaymentIntent and moves the payment to canceled (see paymentCancellation).
 */
 func paymentWorkflow(ctx workflow.Context, input PaymentWorkflowInput) error {
 	// Set retry policy or activity timeout if needed
@@ -33,36 +60,257 @@ func paymentWorkflow(ctx workflow.Context, input PaymentWorkflowInput) error {
 		RetryPolicy:         RetryPolicy3Attempts,
 	})
 
-	// Step 1: Ensure user has Plaid token
-	var plaidToken *domain.PlaidToken
-	if err := workflow.ExecuteActivity(ctx, activity.EnsurePlaidAccountActivity, input.UserID).Get(ctx, &plaidToken); err != nil {
+	cancellation, err := watchCancellation(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancellation.rejectPending("the payment already finished")
+
+	schedule, err := watchSchedule(ctx, input.ExecuteAt)
+	if err != nil {
 		return err
 	}
 
+	// Scheduled runs share one input, so each run creates its own payment ID and
+	// uses its workflow ID, unique per run, as the Stripe idempotency key
+	if input.PaymentID == "" {
+		encoded := workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+			return uuid.NewString()
+		})
+		if err := encoded.Get(&input.PaymentID); err != nil {
+			return err
+		}
+	}
+	if input.IdempotencyKey == "" {
+		input.IdempotencyKey = workflow.GetInfo(ctx).WorkflowExecution.ID
+	}
+
+	// Step 0: Save pending payment record
+	recordInput := activity.CreatePaymentRecordInput{
+		PaymentID:          input.PaymentID,
+		WorkflowID:         workflow.GetInfo(ctx).WorkflowExecution.ID,
+		UserID:             input.UserID,
+		PlaidAccountID:     input.PlaidAccountID,
+		PlaidItemID:        input.PlaidItemID,
+		StripeCustomerID:   input.CustomerID,
+		Amount:             input.Amount,
+		Currency:           input.Currency,
+		RetryOfPaymentID:   input.RetryOfPaymentID,
+		Attempt:            input.Attempt,
+		RecurringPaymentID: input.RecurringPaymentID,
+		ExecuteAt:          input.ExecuteAt,
+	}
+	if err := workflow.ExecuteActivity(ctx, activity.CreatePaymentRecordActivity, recordInput).Get(ctx, nil); err != nil {
+		return err
+	}
+
+	// Nothing was sent to Stripe yet, a cancel only has to update the record
+	if cancellation.poll() {
+		_, err := cancellation.cancel(ctx, input.PaymentID, "")
+		return err
+	}
+
+	if input.ExecuteAt != nil {
+		proceed, err := schedule.await(ctx, input.PaymentID, cancellation)
+		if err != nil || !proceed {
+			return err
+		}
+		if err := markPayment(ctx, input.PaymentID, domain.PaymentStatusCreated, "scheduled date reached"); err != nil {
+			return err
+		}
+	}
+
+	// Optional balance check, configured on the worker (PAYMENT_BALANCE_CHECK)
+	proceed, err := checkBalance(ctx, input, cancellation)
+	if err != nil || !proceed {
+		return err
+	}
+
+	charge, err := chargePayment(ctx, input)
+	if err != nil {
+		markPayment(ctx, input.PaymentID, domain.PaymentStatusFailed, "charge failed: "+err.Error())
+		return err
+	}
+
+	// Step 5: Attach the PaymentIntent and move the record to the status Stripe reported.
+	// A "processing" intent stays processing until it settles, expected per the banking calendar.
+	attachInput := activity.AttachStripePaymentInput{
+		PaymentID:       input.PaymentID,
+		StripePaymentID: charge.ID,
+		PaymentMethodID: charge.PaymentMethodID,
+	}
+	if charge.Status == domain.PaymentStatusProcessing {
+		expected := calendar.ExpectedSettlement(workflow.Now(ctx))
+		attachInput.ExpectedSettlementAt = &expected
+	}
+	if err := workflow.ExecuteActivity(ctx, activity.AttachStripePaymentActivity, attachInput).Get(ctx, nil); err != nil {
+		return err
+	}
+
+	// A cancel that arrived while the intent was being created
+	if charge.Status == domain.PaymentStatusProcessing && cancellation.poll() {
+		canceled, err := cancellation.cancel(ctx, input.PaymentID, charge.ID)
+		if err != nil || canceled {
+			return err
+		}
+	}
+
+	if err := markPayment(ctx, input.PaymentID, charge.Status, "payment intent "+charge.ID+" "+charge.StripeStatus); err != nil {
+		return err
+	}
+	if charge.Status != domain.PaymentStatusProcessing {
+		return nil
+	}
+
+	// Step 6: ACH settles days later; wait for the outcome and record it
+	settlement, err := awaitSettlement(ctx, input, charge.ID, *attachInput.ExpectedSettlementAt, cancellation)
+	if err != nil {
+		return err
+	}
+	if settlement == nil {
+		// Canceled while waiting, the status is already recorded
+		return nil
+	}
+	reason := "settlement timed out, status read from Stripe"
+	if settlement.StripeEventID != "" {
+		reason = "stripe event " + settlement.StripeEventID
+	}
+	return markPayment(ctx, input.PaymentID, settlement.Status, reason)
+}
+
+/*
+awaitSettlement blocks until a PaymentSettlementSignal carries a final status or the
+settlement timeout fires. On timeout the PaymentIntent is fetched from Stripe so a
+missed webhook cannot leave the payment processing forever.
+
+The timeout is stretched to one business day past the expected settlement when holidays
+push settlement beyond it.
+
+Cancel requests are handled while waiting; a nil settlement means the payment was canceled.
+*/
+func awaitSettlement(ctx workflow.Context, input PaymentWorkflowInput, paymentIntentID string, expectedAt time.Time, cancellation *paymentCancellation) (*domain.PaymentSettlement, error) {
+	logger := workflow.GetLogger(ctx)
+
+	timeout := input.SettlementTimeout
+	if timeout <= 0 {
+		timeout = DefaultSettlementTimeout
+	}
+	if late := calendar.AddBusinessDays(expectedAt, 1).Sub(workflow.Now(ctx)); late > timeout {
+		timeout = late
+	}
+
+	timerCtx, cancelTimer := workflow.WithCancel(ctx)
+	defer cancelTimer()
+	timer := workflow.NewTimer(timerCtx, timeout)
+
+	signals := workflow.GetSignalChannel(ctx, domain.PaymentSettlementSignal)
+	timedOut := false
+	var settlement domain.PaymentSettlement
+
+	selector := workflow.NewSelector(ctx)
+	selector.AddReceive(signals, func(c workflow.ReceiveChannel, more bool) {
+		c.Receive(ctx, &settlement)
+	})
+	selector.AddFuture(timer, func(f workflow.Future) {
+		timedOut = true
+	})
+	selector.AddReceive(cancellation.signals, func(c workflow.ReceiveChannel, more bool) {
+		cancellation.receive(ctx, c)
+	})
+
+	for {
+		selector.Select(ctx)
+		if timedOut {
+			break
+		}
+		if cancellation.pending() {
+			canceled, err := cancellation.cancel(ctx, input.PaymentID, paymentIntentID)
+			if err != nil || canceled {
+				return nil, err
+			}
+			continue
+		}
+		if settlement.Status != "" && settlement.Status != domain.PaymentStatusProcessing {
+			logger.Info("Payment settlement received", "status", settlement.Status, "stripe_event_id", settlement.StripeEventID)
+			return &settlement, nil
+		}
+	}
+
+	logger.Warn("Payment settlement timed out, checking Stripe", "payment_intent_id", paymentIntentID)
+	var charge *domain.ACHCharge
+	if err := workflow.ExecuteActivity(ctx, activity.GetACHPaymentIntent, paymentIntentID).Get(ctx, &charge); err != nil {
+		return nil, err
+	}
+	return &domain.PaymentSettlement{
+		Status:         charge.Status,
+		FailureMessage: charge.FailureMessage,
+	}, nil
+}
+
+func chargePayment(ctx workflow.Context, input PaymentWorkflowInput) (*domain.ACHCharge, error) {
+	// Step 1: Ensure the funding account is still linked and its item is healthy
+	plaidInput := activity.EnsurePlaidAccountInput{
+		UserID:    input.UserID,
+		AccountID: input.PlaidAccountID,
+	}
+	var plaidToken *activity.EnsurePlaidAccountOutput
+	if err := workflow.ExecuteActivity(ctx, activity.EnsurePlaidAccountActivity, plaidInput).Get(ctx, &plaidToken); err != nil {
+		return nil, err
+	}
+
 	// Step 2: Get or create Stripe customer
+	customerInput := activity.GetOrCreateStripeCustomerInput{
+		UserID: input.UserID,
+	}
 	var stripeCustomer *domain.StripeCustomer
-	if err := workflow.ExecuteActivity(ctx, activity.GetOrCreateStripeCustomerActivity, input.UserID).Get(ctx, &stripeCustomer); err != nil {
-		return err
+	if err := workflow.ExecuteActivity(ctx, activity.GetOrCreateStripeCustomerActivity, customerInput).Get(ctx, &stripeCustomer); err != nil {
+		return nil, err
 	}
 
 	// Step 3: Ensure default payment method exists
-	if err := workflow.ExecuteActivity(ctx, activity.EnsureDefaultPaymentMethodActivity, stripeCustomer).Get(ctx, nil); err != nil {
-		return err
+	paymentMethodInput := activity.EnsureDefaultPaymentMethodInput{
+		CustomerID: stripeCustomer.StripeCustomerID,
+		UserID:     input.UserID,
+	}
+	var defaultPaymentMethod *domain.PaymentMethod
+	if err := workflow.ExecuteActivity(ctx, activity.EnsureDefaultPaymentMethodActivity, paymentMethodInput).Get(ctx, &defaultPaymentMethod); err != nil {
+		return nil, err
+	}
+
+	paymentMethodID := input.PaymentMethodID
+	if paymentMethodID == "" {
+		paymentMethodID = defaultPaymentMethod.ID
 	}
 
-	// Step 4: Charge customer
+	// Step 4: Charge customer through an ACH PaymentIntent
 	chargeInput := domain.CreateACHChargeInput{
-		CustomerID:     stripeCustomer.StripeCustomerID,
-		Amount:         input.Amount,
-		IdempotencyKey: input.IdempotencyKey,
+		PaymentID:       input.PaymentID,
+		CustomerID:      stripeCustomer.StripeCustomerID,
+		Amount:          input.Amount,
+		Currency:        input.Currency,
+		PaymentMethodID: paymentMethodID,
+		IdempotencyKey:  input.IdempotencyKey,
+		Description:     input.Description,
+		// Runs started by a schedule carry no mandate; workflow time stays the same on retries
+		Mandate: input.Mandate.AcceptedBy(workflow.Now(ctx)),
 	}
 	var charge *domain.ACHCharge
-	if err := workflow.ExecuteActivity(ctx, activity.CreateACHCharge, chargeInput).Get(ctx, &charge); err != nil {
-		return err
+	if err := workflow.ExecuteActivity(ctx, activity.CreateACHPaymentIntent, chargeInput).Get(ctx, &charge); err != nil {
+		return nil, err
 	}
 
-	// Step 5: Save payment record (optional)
-	// ... add SavePaymentRecordActivity if needed
+	return charge, nil
+}
+
+// markPayment moves the payment record to the given status. It runs in a
+// disconnected context so the update still happens if the workflow was canceled.
+func markPayment(ctx workflow.Context, paymentID string, status domain.PaymentStatus, reason string) error {
+	ctx, _ = workflow.NewDisconnectedContext(ctx)
 
-	return nil
+	statusInput := activity.UpdatePaymentStatusInput{
+		PaymentID: paymentID,
+		Status:    status,
+		Reason:    reason,
+	}
+	return workflow.ExecuteActivity(ctx, activity.UpdatePaymentStatusActivity, statusInput).Get(ctx, nil)
 }
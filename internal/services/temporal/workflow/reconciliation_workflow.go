@@ -0,0 +1,111 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	activity "github.com/GalaDe/payments-service/internal/services/temporal/activity"
+)
+
+// ReconciliationScheduleID is the Temporal Schedule that reconciles the previous day.
+const ReconciliationScheduleID = "daily-reconciliation"
+
+type ReconciliationWorkflowInput struct {
+	RunID       string    `json:"run_id"`       // generated when empty, as for scheduled runs
+	PeriodStart time.Time `json:"period_start"` // zero reconciles the previous UTC day
+	PeriodEnd   time.Time `json:"period_end"`   // exclusive
+}
+
+/*
+ 1. Record the run for its period
+ 2. Compare Stripe's balance transactions and payouts with our payments and store the report
+ 3. Mark the run failed if the comparison couldn't be completed
+*/
+func reconciliationWorkflow(ctx workflow.Context, input ReconciliationWorkflowInput) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: ReconciliationActivityTimeout,
+		RetryPolicy:         RetryPolicy3Attempts,
+	})
+
+	if input.RunID == "" {
+		encoded := workflow.SideEffect(ctx, func(ctx workflow.Context) interface{} {
+			return uuid.NewString()
+		})
+		if err := encoded.Get(&input.RunID); err != nil {
+			return err
+		}
+	}
+	if input.PeriodStart.IsZero() {
+		input.PeriodEnd = workflow.Now(ctx).UTC().Truncate(24 * time.Hour)
+		input.PeriodStart = input.PeriodEnd.AddDate(0, 0, -1)
+	}
+
+	runInput := activity.ReconciliationInput{
+		RunID:       input.RunID,
+		WorkflowID:  workflow.GetInfo(ctx).WorkflowExecution.ID,
+		PeriodStart: input.PeriodStart,
+		PeriodEnd:   input.PeriodEnd,
+	}
+
+	// Step 1: Record the run
+	if err := workflow.ExecuteActivity(ctx, activity.StartReconciliationRunActivity, runInput).Get(ctx, nil); err != nil {
+		return err
+	}
+
+	// Step 2: Reconcile
+	var discrepancies int
+	if err := workflow.ExecuteActivity(ctx, activity.ReconcileStripeActivity, runInput).Get(ctx, &discrepancies); err != nil {
+		// Step 3: Record the failure, even if the workflow was canceled
+		failCtx, _ := workflow.NewDisconnectedContext(ctx)
+		failInput := activity.FailReconciliationRunInput{RunID: input.RunID, Reason: err.Error()}
+		if failErr := workflow.ExecuteActivity(failCtx, activity.FailReconciliationRunActivity, failInput).Get(failCtx, nil); failErr != nil {
+			workflow.GetLogger(ctx).Error("Failed to mark reconciliation run failed", "RunID", input.RunID, "Error", failErr)
+		}
+		return err
+	}
+
+	if discrepancies > 0 {
+		workflow.GetLogger(ctx).Warn("Reconciliation found discrepancies", "RunID", input.RunID, "Discrepancies", discrepancies)
+	}
+	return nil
+}
+
+/*
+EnsureReconciliationSchedule creates the Temporal Schedule that starts a ReconciliationWorkflow
+every day at ReconciliationHour UTC for the previous day. It is a no-op when the schedule
+already exists, so it is safe to call on every start.
+
+Runs don't overlap; a run missed while the scheduler was unavailable is caught up within a day.
+*/
+func EnsureReconciliationSchedule(ctx context.Context, c client.Client) error {
+	_, err := c.ScheduleClient().Create(ctx, client.ScheduleOptions{
+		ID: ReconciliationScheduleID,
+		Spec: client.ScheduleSpec{
+			Calendars: []client.ScheduleCalendarSpec{{
+				Second: []client.ScheduleRange{{Start: 0}},
+				Minute: []client.ScheduleRange{{Start: 0}},
+				Hour:   []client.ScheduleRange{{Start: ReconciliationHour}},
+			}},
+			TimeZoneName: "UTC",
+		},
+		Action: &client.ScheduleWorkflowAction{
+			ID:        "reconciliation",
+			Workflow:  ReconciliationWorkflow,
+			TaskQueue: DefaultTaskQueue,
+			Args:      []interface{}{ReconciliationWorkflowInput{}},
+		},
+		Overlap:       enumspb.SCHEDULE_OVERLAP_POLICY_SKIP,
+		CatchupWindow: 24 * time.Hour,
+	})
+	if errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+)
+
+// A cycle missed while the scheduler was unavailable still runs if it is caught up within a day;
+// older cycles are skipped rather than charging the customer several times at once.
+const RecurringPaymentCatchupWindow = 24 * time.Hour
+
+// RecurringPaymentScheduleID is the Temporal Schedule ID of a recurring payment.
+func RecurringPaymentScheduleID(recurringPaymentID string) string {
+	return "recurring-payment-" + recurringPaymentID
+}
+
+/*
+RecurringPaymentScheduleOptions describes the Temporal Schedule that charges a recurring
+payment. Every cycle starts a PaymentWorkflow:
+
+  - the calendar repeats the anchor's time of day, weekday (weekly), day of month (monthly)
+    or date (yearly), in UTC, between anchor_at and end_at
+  - cycles are allowed to overlap, an ACH debit takes days to settle and must not hold up the next one
+  - Temporal appends the scheduled time to the workflow ID, so every run gets its own
+    payment ID and Stripe idempotency key (see paymentWorkflow)
+*/
+func RecurringPaymentScheduleOptions(recurring *domain.RecurringPayment, settlementTimeout time.Duration) client.ScheduleOptions {
+	schedule := RecurringPaymentSchedule(recurring, settlementTimeout)
+	return client.ScheduleOptions{
+		ID:            recurring.ScheduleID,
+		Spec:          *schedule.Spec,
+		Action:        schedule.Action,
+		Overlap:       schedule.Policy.Overlap,
+		CatchupWindow: schedule.Policy.CatchupWindow,
+		Paused:        recurring.Status == domain.RecurringPaymentStatusPaused,
+	}
+}
+
+// RecurringPaymentSchedule builds the schedule for recurring, used to create and to update it.
+func RecurringPaymentSchedule(recurring *domain.RecurringPayment, settlementTimeout time.Duration) *client.Schedule {
+	return &client.Schedule{
+		Spec: recurringPaymentSpec(recurring),
+		Action: &client.ScheduleWorkflowAction{
+			ID:        fmt.Sprintf("payment-%s-recurring-%s", recurring.CustomerID, recurring.ID),
+			Workflow:  PaymentWorkflow,
+			TaskQueue: DefaultTaskQueue,
+			Args: []interface{}{PaymentWorkflowInput{
+				UserID:             recurring.UserID,
+				CustomerID:         recurring.CustomerID,
+				PaymentMethodID:    recurring.PaymentMethodID,
+				PlaidAccountID:     recurring.PlaidAccountID,
+				PlaidItemID:        recurring.PlaidItemID,
+				Amount:             recurring.Amount,
+				Currency:           recurring.Currency,
+				Description:        recurring.Description,
+				RecurringPaymentID: recurring.ID,
+				SettlementTimeout:  settlementTimeout,
+			}},
+		},
+		Policy: &client.SchedulePolicies{
+			Overlap:       enumspb.SCHEDULE_OVERLAP_POLICY_ALLOW_ALL,
+			CatchupWindow: RecurringPaymentCatchupWindow,
+		},
+		State: &client.ScheduleState{
+			Paused: recurring.Status == domain.RecurringPaymentStatusPaused,
+		},
+	}
+}
+
+func recurringPaymentSpec(recurring *domain.RecurringPayment) *client.ScheduleSpec {
+	anchor := recurring.AnchorAt.UTC()
+	calendar := client.ScheduleCalendarSpec{
+		Second: []client.ScheduleRange{{Start: anchor.Second()}},
+		Minute: []client.ScheduleRange{{Start: anchor.Minute()}},
+		Hour:   []client.ScheduleRange{{Start: anchor.Hour()}},
+	}
+
+	switch recurring.Interval {
+	case domain.RecurringIntervalWeekly:
+		calendar.DayOfWeek = []client.ScheduleRange{{Start: int(anchor.Weekday())}}
+	case domain.RecurringIntervalMonthly:
+		calendar.DayOfMonth = []client.ScheduleRange{{Start: anchor.Day()}}
+	case domain.RecurringIntervalYearly:
+		calendar.DayOfMonth = []client.ScheduleRange{{Start: anchor.Day()}}
+		calendar.Month = []client.ScheduleRange{{Start: int(anchor.Month())}}
+	}
+
+	spec := &client.ScheduleSpec{
+		Calendars:    []client.ScheduleCalendarSpec{calendar},
+		StartAt:      anchor,
+		TimeZoneName: "UTC",
+	}
+	if recurring.EndAt != nil {
+		spec.EndAt = recurring.EndAt.UTC()
+	}
+	return spec
+}
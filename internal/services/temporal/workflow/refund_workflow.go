@@ -0,0 +1,60 @@
+package workflow
+
+import (
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	activity "github.com/GalaDe/payments-service/internal/services/temporal/activity"
+)
+
+type RefundWorkflowInput struct {
+	RefundID        string `json:"refund_id"`         // internal refund UUID, already stored as pending
+	PaymentID       string `json:"payment_id"`        // internal payment UUID
+	PaymentIntentID string `json:"payment_intent_id"` // Stripe PaymentIntent being refunded
+	Amount          int64  `json:"amount"`
+	Reason          string `json:"reason"`
+}
+
+/*
+ 1. Create the refund in Stripe, keyed by our refund ID so retries never refund twice
+ 2. Store the Stripe refund ID and status, updating the payment's refund status
+*/
+func refundWorkflow(ctx workflow.Context, input RefundWorkflowInput) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: DefaultActivityTimeout,
+		RetryPolicy:         RetryPolicy3Attempts,
+	})
+
+	// Step 1: Refund in Stripe
+	refundInput := domain.CreateRefundInput{
+		PaymentIntentID: input.PaymentIntentID,
+		Amount:          input.Amount,
+		Reason:          input.Reason,
+		IdempotencyKey:  "refund-" + input.RefundID,
+		RefundID:        input.RefundID,
+	}
+	var result *domain.ACHRefund
+	if err := workflow.ExecuteActivity(ctx, activity.CreateStripeRefund, refundInput).Get(ctx, &result); err != nil {
+		markRefund(ctx, domain.Refund{
+			ID:            input.RefundID,
+			Status:        domain.RefundStatusFailed,
+			FailureReason: err.Error(),
+		})
+		return err
+	}
+
+	// Step 2: Save the outcome
+	return markRefund(ctx, domain.Refund{
+		ID:             input.RefundID,
+		StripeRefundID: result.ID,
+		Status:         result.Status,
+		FailureReason:  result.FailureReason,
+	})
+}
+
+// markRefund stores the refund outcome in a disconnected context so it is
+// recorded even if the workflow was canceled.
+func markRefund(ctx workflow.Context, refund domain.Refund) error {
+	ctx, _ = workflow.NewDisconnectedContext(ctx)
+	return workflow.ExecuteActivity(ctx, activity.UpdateRefundActivity, refund).Get(ctx, nil)
+}
@@ -0,0 +1,66 @@
+package workflow
+
+import (
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	activity "github.com/GalaDe/payments-service/internal/services/temporal/activity"
+)
+
+// WebhookEventWorkflowID is the workflow ID used for a stored webhook event, so
+// a redelivered event never runs concurrently with its first delivery.
+func WebhookEventWorkflowID(eventID string) string {
+	return "webhook-" + eventID
+}
+
+/*
+ 1. Process a webhook event already stored in the webhook_events inbox
+ 2. Hand an ACH return recorded by the event to its own ACHReturnWorkflow, and a dispute
+    awaiting evidence to its DisputeDeadlineWorkflow. Both can run for days, so finish as
+    soon as they have started
+*/
+func webhookEventWorkflow(ctx workflow.Context, eventID string) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: DefaultActivityTimeout,
+		RetryPolicy:         RetryPolicy3Attempts,
+	})
+
+	var result *activity.ProcessWebhookEventOutput
+	if err := workflow.ExecuteActivity(ctx, activity.ProcessWebhookEventActivity, eventID).Get(ctx, &result); err != nil {
+		return err
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if result.ACHReturnID != "" {
+		input := ACHReturnWorkflowInput{ACHReturnID: result.ACHReturnID}
+		if err := startFollowUpWorkflow(ctx, ACHReturnWorkflowID(result.ACHReturnID), ACHReturnWorkflow, input); err != nil {
+			return err
+		}
+	}
+	if result.DisputeID != "" {
+		input := DisputeDeadlineWorkflowInput{DisputeID: result.DisputeID}
+		if err := startFollowUpWorkflow(ctx, DisputeDeadlineWorkflowID(result.DisputeID), DisputeDeadlineWorkflow, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startFollowUpWorkflow starts a child workflow that outlives the webhook workflow. One
+// that is already running under the same ID is left alone.
+func startFollowUpWorkflow(ctx workflow.Context, workflowID, workflowType string, input interface{}) error {
+	childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+		WorkflowID:        workflowID,
+		TaskQueue:         DefaultTaskQueue,
+		ParentClosePolicy: enumspb.PARENT_CLOSE_POLICY_ABANDON,
+	})
+	child := workflow.ExecuteChildWorkflow(childCtx, workflowType, input)
+	if err := child.GetChildWorkflowExecution().Get(ctx, nil); err != nil && !temporal.IsWorkflowExecutionAlreadyStartedError(err) {
+		return err
+	}
+	return nil
+}
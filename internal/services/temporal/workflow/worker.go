@@ -12,9 +12,25 @@ import (
 type ContextKey string
 
 const (
-	DefaultActivityTimeout = 120 * time.Second
-	DefaultTaskQueue       = "default-task-queue"
-	ClientContextKey ContextKey = "Client"
+	DefaultActivityTimeout            = 120 * time.Second
+	DefaultTaskQueue                  = "default-task-queue"
+	ClientContextKey       ContextKey = "Client"
+
+	// ACH debits usually settle within 4 business days; leave room for weekends and holidays
+	DefaultSettlementTimeout = 7 * 24 * time.Hour
+
+	// Wait a few days before re-presenting a debit returned for insufficient funds,
+	// so the retry is likely to land after the customer's next deposit
+	DefaultACHRetryDelay = 3 * 24 * time.Hour
+
+	// A payment held for review fails if nobody approves it within this time
+	DefaultPaymentReviewTimeout = 3 * 24 * time.Hour
+
+	// The previous day is reconciled at this hour (UTC), once Stripe has closed it
+	ReconciliationHour = 6
+
+	// Pulling a day of balance transactions and payouts from Stripe can take a while
+	ReconciliationActivityTimeout = 10 * time.Minute
 )
 
 var (
@@ -38,6 +54,9 @@ var (
 		MaximumInterval:    time.Minute,
 		MaximumAttempts:    3,
 	}
+
+	// Alert this long before a dispute's evidence is due, while it's still unanswered
+	DisputeEvidenceAlertLeads = []time.Duration{7 * 24 * time.Hour, 3 * 24 * time.Hour, 24 * time.Hour}
 )
 
 type TemporalConfig struct {
@@ -6,9 +6,19 @@ import (
 )
 
 const (
-	PaymentWorkflow = "PaymentWorkflow"
+	PaymentWorkflow         = "PaymentWorkflow"
+	RefundWorkflow          = "RefundWorkflow"
+	WebhookEventWorkflow    = "WebhookEventWorkflow"
+	ACHReturnWorkflow       = "ACHReturnWorkflow"
+	ReconciliationWorkflow  = "ReconciliationWorkflow"
+	DisputeDeadlineWorkflow = "DisputeDeadlineWorkflow"
 )
 
 func RegisterWorkflows(c worker.WorkflowRegistry) {
 	c.RegisterWorkflowWithOptions(paymentWorkflow, workflow.RegisterOptions{Name: PaymentWorkflow})
+	c.RegisterWorkflowWithOptions(refundWorkflow, workflow.RegisterOptions{Name: RefundWorkflow})
+	c.RegisterWorkflowWithOptions(webhookEventWorkflow, workflow.RegisterOptions{Name: WebhookEventWorkflow})
+	c.RegisterWorkflowWithOptions(achReturnWorkflow, workflow.RegisterOptions{Name: ACHReturnWorkflow})
+	c.RegisterWorkflowWithOptions(reconciliationWorkflow, workflow.RegisterOptions{Name: ReconciliationWorkflow})
+	c.RegisterWorkflowWithOptions(disputeDeadlineWorkflow, workflow.RegisterOptions{Name: DisputeDeadlineWorkflow})
 }
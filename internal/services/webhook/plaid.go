@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+)
+
+const (
+	plaidWebhookTypeItem         = "ITEM"
+	plaidWebhookTypeAuth         = "AUTH"
+	plaidWebhookTypeTransactions = "TRANSACTIONS"
+
+	plaidCodeError                 = "ERROR"
+	plaidCodeLoginRepaired         = "LOGIN_REPAIRED"
+	plaidCodePendingExpiration     = "PENDING_EXPIRATION"
+	plaidCodeUserPermissionRevoked = "USER_PERMISSION_REVOKED"
+	plaidCodeDefaultUpdate         = "DEFAULT_UPDATE"
+	plaidCodeAutomaticallyVerified = "AUTOMATICALLY_VERIFIED"
+	plaidCodeVerificationExpired   = "VERIFICATION_EXPIRED"
+	plaidCodeTransactionsUpdated   = "TRANSACTIONS_UPDATED"
+
+	plaidErrorItemLoginRequired = "ITEM_LOGIN_REQUIRED"
+)
+
+// plaidWebhook holds the fields we use across the Plaid webhook payloads we handle.
+// See https://plaid.com/docs/api/items/#webhooks and https://plaid.com/docs/auth/#webhooks
+type plaidWebhook struct {
+	WebhookType           string      `json:"webhook_type"`
+	WebhookCode           string      `json:"webhook_code"`
+	ItemID                string      `json:"item_id"`
+	AccountID             string      `json:"account_id"`
+	ConsentExpirationTime *time.Time  `json:"consent_expiration_time"`
+	Error                 *plaidError `json:"error"`
+}
+
+type plaidError struct {
+	ErrorType    string `json:"error_type"`
+	ErrorCode    string `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+}
+
+/*
+processPlaidEvent keeps the per-item health state in plaid_items up to date:
+
+	ITEM ERROR (ITEM_LOGIN_REQUIRED)  -> login_required, the user has to re-authenticate through Link update mode
+	ITEM ERROR (anything else)        -> error, with the Plaid error code
+	ITEM LOGIN_REPAIRED               -> healthy again
+	ITEM PENDING_EXPIRATION           -> pending_expiration, with the consent expiration time
+	ITEM USER_PERMISSION_REVOKED      -> permission_revoked, the access token can no longer be used
+	AUTH DEFAULT_UPDATE               -> account numbers changed, auth_updated_at is stamped
+	AUTH AUTOMATICALLY_VERIFIED       -> automated micro-deposits verified the account
+	AUTH VERIFICATION_EXPIRED         -> automated micro-deposits could not verify the account
+*/
+func (p *Processor) processPlaidEvent(ctx context.Context, event *plaidWebhook) error {
+	switch event.WebhookType {
+	case plaidWebhookTypeItem:
+		return p.processPlaidItemEvent(ctx, event)
+	case plaidWebhookTypeAuth:
+		return p.processPlaidAuthEvent(ctx, event)
+	case plaidWebhookTypeTransactions:
+		if event.WebhookCode == plaidCodeTransactionsUpdated {
+			log.Printf("Plaid transactions updated for item %s", event.ItemID)
+		}
+		return nil
+	default:
+		log.Printf("Unhandled Plaid webhook: %s %s", event.WebhookType, event.WebhookCode)
+		return nil
+	}
+}
+
+func (p *Processor) processPlaidItemEvent(ctx context.Context, event *plaidWebhook) error {
+	switch event.WebhookCode {
+	case plaidCodeError:
+		status := domain.PlaidItemStatusError
+		var errorCode, errorMessage string
+		if event.Error != nil {
+			errorCode, errorMessage = event.Error.ErrorCode, event.Error.ErrorMessage
+			if errorCode == plaidErrorItemLoginRequired {
+				status = domain.PlaidItemStatusLoginRequired
+			}
+		}
+		log.Printf("Plaid item %s error %s: %s", event.ItemID, errorCode, errorMessage)
+		return p.repository.UpdatePlaidItemStatus(ctx, event.ItemID, status, errorCode, errorMessage, event.WebhookCode)
+
+	case plaidCodeLoginRepaired:
+		return p.repository.UpdatePlaidItemStatus(ctx, event.ItemID, domain.PlaidItemStatusHealthy, "", "", event.WebhookCode)
+
+	case plaidCodePendingExpiration:
+		var expiresAt time.Time
+		if event.ConsentExpirationTime != nil {
+			expiresAt = *event.ConsentExpirationTime
+		}
+		log.Printf("Plaid item %s consent expires at %s", event.ItemID, expiresAt)
+		return p.repository.UpdatePlaidItemConsentExpiration(ctx, event.ItemID, expiresAt)
+
+	case plaidCodeUserPermissionRevoked:
+		var errorCode, errorMessage string
+		if event.Error != nil {
+			errorCode, errorMessage = event.Error.ErrorCode, event.Error.ErrorMessage
+		}
+		log.Printf("Plaid item %s permission revoked by user", event.ItemID)
+		return p.repository.UpdatePlaidItemStatus(ctx, event.ItemID, domain.PlaidItemStatusPermissionRevoked, errorCode, errorMessage, event.WebhookCode)
+
+	default:
+		log.Printf("Unhandled Plaid ITEM webhook code %s for item %s", event.WebhookCode, event.ItemID)
+		return nil
+	}
+}
+
+func (p *Processor) processPlaidAuthEvent(ctx context.Context, event *plaidWebhook) error {
+	switch event.WebhookCode {
+	case plaidCodeDefaultUpdate:
+		log.Printf("Plaid item %s account numbers updated", event.ItemID)
+		return p.repository.MarkPlaidItemAuthUpdated(ctx, event.ItemID)
+
+	case plaidCodeAutomaticallyVerified:
+		return p.repository.UpdatePlaidItemVerification(ctx, event.ItemID, event.AccountID,
+			domain.PlaidVerificationStatusAutomaticallyVerified, event.WebhookCode)
+
+	case plaidCodeVerificationExpired:
+		log.Printf("Plaid account %s verification expired", event.AccountID)
+		return p.repository.UpdatePlaidItemVerification(ctx, event.ItemID, event.AccountID,
+			domain.PlaidVerificationStatusVerificationExpired, event.WebhookCode)
+
+	default:
+		log.Printf("Unhandled Plaid AUTH webhook code %s for item %s", event.WebhookCode, event.ItemID)
+		return nil
+	}
+}
@@ -0,0 +1,397 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	stripesvc "github.com/GalaDe/payments-service/internal/services/stripe"
+	"github.com/jackc/pgx/v4"
+	"github.com/stripe/stripe-go/v75"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+)
+
+// Processor applies stored webhook events to our own state. Events are
+// verified and persisted by the HTTP handlers before they reach the processor,
+// so Process may run more than once for the same event and must stay idempotent.
+//
+// Settlement outcomes for a payment whose PaymentWorkflow is still running are
+// sent to the workflow as a signal; the workflow records them. Only when the
+// workflow has already completed is the payment row updated directly.
+//
+// Follow-up work that outlives the event, like handling an ACH return or
+// tracking a dispute's evidence deadline, is reported in the Result for the
+// webhook workflow to start.
+type Processor struct {
+	repository     domain.Repository
+	temporalClient client.Client
+}
+
+func NewProcessor(repository domain.Repository, temporalClient client.Client) *Processor {
+	return &Processor{
+		repository:     repository,
+		temporalClient: temporalClient,
+	}
+}
+
+// Result reports follow-up work for a processed event.
+type Result struct {
+	ACHReturnID string // ACH return that still has to be handled
+	DisputeID   string // dispute still awaiting evidence
+}
+
+func (p *Processor) Process(ctx context.Context, event *domain.WebhookEvent) (*Result, error) {
+	switch event.Provider {
+	case domain.WebhookProviderStripe:
+		var stripeEvent stripe.Event
+		if err := json.Unmarshal(event.Payload, &stripeEvent); err != nil {
+			return nil, fmt.Errorf("invalid Stripe webhook payload: %w", err)
+		}
+		return p.processStripeEvent(ctx, &stripeEvent)
+	case domain.WebhookProviderPlaid:
+		var plaidEvent plaidWebhook
+		if err := json.Unmarshal(event.Payload, &plaidEvent); err != nil {
+			return nil, fmt.Errorf("invalid Plaid webhook payload: %w", err)
+		}
+		return &Result{}, p.processPlaidEvent(ctx, &plaidEvent)
+	default:
+		return nil, fmt.Errorf("unknown webhook provider %q", event.Provider)
+	}
+}
+
+func (p *Processor) processStripeEvent(ctx context.Context, event *stripe.Event) (*Result, error) {
+	switch event.Type {
+	case "charge.succeeded", "charge.pending", "charge.failed":
+		var charge stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+			return nil, fmt.Errorf("invalid charge payload: %w", err)
+		}
+		// Charges created through a PaymentIntent are tracked by the intent ID
+		stripePaymentID := charge.ID
+		if charge.PaymentIntent != nil {
+			stripePaymentID = charge.PaymentIntent.ID
+		}
+		log.Printf("Charge %s for: %s", charge.Status, charge.ID)
+		err := p.applyStripePaymentStatus(ctx, event.ID, "", stripePaymentID, domain.PaymentSettlement{
+			Status:         stripesvc.PaymentStatusFromCharge(charge.Status),
+			StripeEventID:  event.ID,
+			FailureMessage: charge.FailureMessage,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if event.Type == "charge.failed" && isACHDebit(&charge) {
+			return p.recordACHReturn(ctx, event.ID, stripePaymentID, &charge)
+		}
+		return &Result{}, nil
+
+	case "payment_intent.processing", "payment_intent.succeeded", "payment_intent.payment_failed", "payment_intent.canceled":
+		var intent stripe.PaymentIntent
+		if err := json.Unmarshal(event.Data.Raw, &intent); err != nil {
+			return nil, fmt.Errorf("invalid payment intent payload: %w", err)
+		}
+		settlement := domain.PaymentSettlement{
+			Status:        stripesvc.PaymentStatusFromIntent(intent.Status),
+			StripeEventID: event.ID,
+		}
+		if intent.LastPaymentError != nil {
+			settlement.FailureMessage = intent.LastPaymentError.Msg
+		}
+		log.Printf("Payment intent %s for: %s", intent.Status, intent.ID)
+		return &Result{}, p.applyStripePaymentStatus(ctx, event.ID, intent.Metadata["payment_id"], intent.ID, settlement)
+
+	case "charge.refund.updated", "refund.created", "refund.updated", "refund.failed":
+		var stripeRefund stripe.Refund
+		if err := json.Unmarshal(event.Data.Raw, &stripeRefund); err != nil {
+			return nil, fmt.Errorf("invalid refund payload: %w", err)
+		}
+		log.Printf("Refund %s for: %s", stripeRefund.Status, stripeRefund.ID)
+		return &Result{}, p.applyRefund(ctx, &stripeRefund)
+
+	case "charge.dispute.created", "charge.dispute.updated", "charge.dispute.closed",
+		"charge.dispute.funds_withdrawn", "charge.dispute.funds_reinstated":
+		var dispute stripe.Dispute
+		if err := json.Unmarshal(event.Data.Raw, &dispute); err != nil {
+			return nil, fmt.Errorf("invalid dispute payload: %w", err)
+		}
+		log.Printf("Dispute %s for: %s", dispute.Status, dispute.ID)
+		return p.applyDispute(ctx, event.ID, &dispute)
+
+	default:
+		log.Printf("Unhandled event type: %s", event.Type)
+	}
+
+	return &Result{}, nil
+}
+
+/*
+recordACHReturn stores the NACHA return behind a failed ACH debit. Stripe only
+reports its own failure_code, which is mapped to the NACHA code here; handling the
+return (disabling the payment method or retrying) is left to ACHReturnWorkflow.
+
+A return whose handling has not finished yet is reported again on redelivery so a
+crash between recording and handling doesn't lose it.
+*/
+func (p *Processor) recordACHReturn(ctx context.Context, eventID, stripePaymentID string, charge *stripe.Charge) (*Result, error) {
+	payment, err := p.repository.GetPaymentByStripePaymentID(ctx, stripePaymentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &Result{}, nil
+		}
+		return nil, err
+	}
+
+	code, known := domain.ACHReturnFromStripe(charge.FailureCode)
+	if !known {
+		log.Printf("Unknown ACH failure code %q on charge %s", charge.FailureCode, charge.ID)
+	}
+	reason := code.Reason
+	if charge.FailureMessage != "" {
+		reason = charge.FailureMessage
+	}
+
+	achReturn := &domain.ACHReturn{
+		PaymentID:         payment.ID,
+		StripeChargeID:    charge.ID,
+		StripeEventID:     eventID,
+		ReturnCode:        code.Code,
+		Reason:            reason,
+		StripeFailureCode: charge.FailureCode,
+		Retryable:         code.Retryable,
+	}
+	created, err := p.repository.CreateACHReturn(ctx, achReturn)
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		log.Printf("Recorded ACH return %s (%s) for payment %s", achReturn.ReturnCode, achReturn.StripeFailureCode, payment.ID)
+	}
+
+	if achReturn.Action != domain.ACHReturnActionPending {
+		return &Result{}, nil
+	}
+	return &Result{ACHReturnID: achReturn.ID}, nil
+}
+
+/*
+applyRefund stores the status of a refund reported by Stripe. ACH refunds are usually
+still pending when RefundWorkflow creates them and only succeed or fail days later,
+which Stripe reports with these events. The refund is found by the refund_id we put in
+its metadata, or by its Stripe ID for refunds created before that.
+*/
+func (p *Processor) applyRefund(ctx context.Context, stripeRefund *stripe.Refund) error {
+	var refund *domain.Refund
+	var err error
+	if refundID := stripeRefund.Metadata["refund_id"]; refundID != "" {
+		refund, err = p.repository.GetRefundByID(ctx, refundID)
+	} else {
+		refund, err = p.repository.GetRefundByStripeRefundID(ctx, stripeRefund.ID)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Refunds made in the Stripe dashboard have no refund of ours
+			log.Printf("No refund found for Stripe refund %s", stripeRefund.ID)
+			return nil
+		}
+		return err
+	}
+
+	status := stripesvc.RefundStatusFromStripe(stripeRefund.Status)
+	if refund.Status == status && refund.StripeRefundID == stripeRefund.ID {
+		return nil
+	}
+
+	refund.StripeRefundID = stripeRefund.ID
+	refund.Status = status
+	refund.FailureReason = string(stripeRefund.FailureReason)
+	return p.repository.UpdateRefund(ctx, refund)
+}
+
+/*
+applyDispute records a dispute and moves its payment along with it:
+  - while the disputed amount is withdrawn (needs_response, under_review) the payment is disputed
+  - a won dispute returns the payment to succeeded, or partially_refunded if it had been refunded in part
+  - a lost dispute leaves the payment refunded, the customer got the money back
+  - a dispute that arrives already lost, as ACH disputes do since they can't be contested,
+    moves the payment through disputed to refunded so the ledger records the loss
+
+Inquiries (warning_*) don't withdraw funds and only update the dispute. A dispute that
+still awaits evidence is reported so its deadline is tracked.
+*/
+func (p *Processor) applyDispute(ctx context.Context, eventID string, stripeDispute *stripe.Dispute) (*Result, error) {
+	var stripePaymentID string
+	switch {
+	case stripeDispute.PaymentIntent != nil:
+		stripePaymentID = stripeDispute.PaymentIntent.ID
+	case stripeDispute.Charge != nil:
+		stripePaymentID = stripeDispute.Charge.ID
+	}
+
+	payment, err := p.repository.GetPaymentByStripePaymentID(ctx, stripePaymentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("No payment found for dispute %s", stripeDispute.ID)
+			return &Result{}, nil
+		}
+		return nil, err
+	}
+
+	dispute := stripesvc.DisputeFromStripe(stripeDispute)
+	dispute.PaymentID = payment.ID
+	dispute.StripeEventID = eventID
+	changed, err := p.repository.SaveDispute(ctx, dispute)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		log.Printf("Ignoring event %s for closed dispute %s", eventID, dispute.ID)
+		return &Result{}, nil
+	}
+
+	var refunded bool
+	if dispute.Status == domain.DisputeStatusWon {
+		if refunded, err = p.hasSucceededRefund(ctx, payment.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if path := disputedPaymentStatuses(payment.Status, dispute, refunded); len(path) > 0 {
+		if err := p.repository.UpdatePaymentStatuses(ctx, payment.ID, path, "stripe event "+eventID); err != nil {
+			return nil, err
+		}
+	}
+
+	if !dispute.AwaitingEvidence() {
+		return &Result{}, nil
+	}
+	return &Result{DisputeID: dispute.ID}, nil
+}
+
+/*
+disputedPaymentStatuses returns the statuses a payment in status current moves through for
+its dispute, in order, or nil when it stays where it is, e.g. a payment already returned.
+refunded reports whether part of the payment was refunded, which a won dispute returns it to.
+*/
+func disputedPaymentStatuses(current domain.PaymentStatus, dispute *domain.Dispute, refunded bool) []domain.PaymentStatus {
+	var path []domain.PaymentStatus
+	switch {
+	case dispute.FundsWithdrawn():
+		path = []domain.PaymentStatus{domain.PaymentStatusDisputed}
+	case dispute.Status == domain.DisputeStatusLost && current == domain.PaymentStatusDisputed:
+		path = []domain.PaymentStatus{domain.PaymentStatusRefunded}
+	case dispute.Status == domain.DisputeStatusLost &&
+		(current == domain.PaymentStatusSucceeded || current == domain.PaymentStatusPartiallyRefunded):
+		// Never seen open: withdraw the funds and lose them in one go
+		path = []domain.PaymentStatus{domain.PaymentStatusDisputed, domain.PaymentStatusRefunded}
+	case dispute.Status == domain.DisputeStatusWon && current == domain.PaymentStatusDisputed && refunded:
+		path = []domain.PaymentStatus{domain.PaymentStatusPartiallyRefunded}
+	case dispute.Status == domain.DisputeStatusWon && current == domain.PaymentStatusDisputed:
+		path = []domain.PaymentStatus{domain.PaymentStatusSucceeded}
+	}
+
+	from := current
+	for _, next := range path {
+		if !from.CanTransitionTo(next) {
+			return nil
+		}
+		from = next
+	}
+	if from == current {
+		return nil
+	}
+	return path
+}
+
+func (p *Processor) hasSucceededRefund(ctx context.Context, paymentID string) (bool, error) {
+	refunds, err := p.repository.GetRefundsByPaymentID(ctx, paymentID)
+	if err != nil {
+		return false, err
+	}
+	for _, refund := range refunds {
+		if refund.Status == domain.RefundStatusSucceeded {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isACHDebit(charge *stripe.Charge) bool {
+	if charge.FailureCode == "" || charge.PaymentMethodDetails == nil {
+		return false
+	}
+	switch charge.PaymentMethodDetails.Type {
+	case "us_bank_account", stripe.ChargePaymentMethodDetailsTypeACHDebit:
+		return true
+	}
+	return false
+}
+
+// applyStripePaymentStatus routes a settlement outcome to the payment it belongs to.
+// The payment is found by our own ID from PaymentIntent metadata when present, which
+// also covers events that arrive before the workflow attached the Stripe ID.
+// Events for payments we don't know about are acknowledged and ignored.
+func (p *Processor) applyStripePaymentStatus(ctx context.Context, eventID, paymentID, stripePaymentID string, settlement domain.PaymentSettlement) error {
+	payment, err := p.findPayment(ctx, paymentID, stripePaymentID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("No payment found for Stripe payment %s", stripePaymentID)
+			return nil
+		}
+		return err
+	}
+
+	// A debit failing after it had succeeded was returned by the bank
+	if payment.Status == domain.PaymentStatusSucceeded && settlement.Status == domain.PaymentStatusFailed {
+		settlement.Status = domain.PaymentStatusReturned
+	}
+
+	if payment.WorkflowID != "" && settlement.Status != domain.PaymentStatusProcessing {
+		err := p.temporalClient.SignalWorkflow(ctx, payment.WorkflowID, "", domain.PaymentSettlementSignal, settlement)
+		if err == nil {
+			log.Printf("Signaled workflow %s with status %s from event %s", payment.WorkflowID, settlement.Status, eventID)
+			return nil
+		}
+		var notFound *serviceerror.NotFound
+		if !errors.As(err, &notFound) {
+			return fmt.Errorf("failed to signal workflow %s: %w", payment.WorkflowID, err)
+		}
+		// The workflow already completed; record the outcome ourselves
+	}
+
+	if isStaleStripeStatus(payment.Status, settlement.Status) || !payment.Status.CanTransitionTo(settlement.Status) {
+		log.Printf("Ignoring status %s for payment %s in status %s", settlement.Status, payment.ID, payment.Status)
+		return nil
+	}
+
+	return p.repository.UpdatePaymentStatus(ctx, payment.ID, settlement.Status, "stripe event "+eventID)
+}
+
+func (p *Processor) findPayment(ctx context.Context, paymentID, stripePaymentID string) (*domain.Payment, error) {
+	if paymentID != "" {
+		payment, err := p.repository.GetPaymentByID(ctx, paymentID)
+		if err == nil || !errors.Is(err, pgx.ErrNoRows) {
+			return payment, err
+		}
+	}
+	return p.repository.GetPaymentByStripePaymentID(ctx, stripePaymentID)
+}
+
+// Stripe does not guarantee event ordering, so a late processing or
+// succeeded event must not undo a later outcome we already recorded.
+func isStaleStripeStatus(current, next domain.PaymentStatus) bool {
+	if current == next {
+		return true
+	}
+	switch current {
+	case domain.PaymentStatusCreated, domain.PaymentStatusProcessing:
+		return false
+	case domain.PaymentStatusRefunded, domain.PaymentStatusPartiallyRefunded, domain.PaymentStatusDisputed:
+		return next != domain.PaymentStatusReturned
+	default:
+		return next == domain.PaymentStatusProcessing
+	}
+}
@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+)
+
+func TestDisputedPaymentStatuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  domain.PaymentStatus
+		dispute  string
+		refunded bool
+		want     []domain.PaymentStatus
+	}{
+		{name: "opened", current: domain.PaymentStatusSucceeded, dispute: domain.DisputeStatusNeedsResponse, want: []domain.PaymentStatus{domain.PaymentStatusDisputed}},
+		{name: "opened on a partial refund", current: domain.PaymentStatusPartiallyRefunded, dispute: domain.DisputeStatusNeedsResponse, want: []domain.PaymentStatus{domain.PaymentStatusDisputed}},
+		{name: "under review", current: domain.PaymentStatusDisputed, dispute: domain.DisputeStatusUnderReview},
+		{name: "inquiry", current: domain.PaymentStatusSucceeded, dispute: domain.DisputeStatusWarningNeedsResponse},
+		{name: "inquiry closed", current: domain.PaymentStatusSucceeded, dispute: domain.DisputeStatusWarningClosed},
+		{name: "won", current: domain.PaymentStatusDisputed, dispute: domain.DisputeStatusWon, want: []domain.PaymentStatus{domain.PaymentStatusSucceeded}},
+		{name: "won after a partial refund", current: domain.PaymentStatusDisputed, dispute: domain.DisputeStatusWon, refunded: true, want: []domain.PaymentStatus{domain.PaymentStatusPartiallyRefunded}},
+		{name: "lost", current: domain.PaymentStatusDisputed, dispute: domain.DisputeStatusLost, want: []domain.PaymentStatus{domain.PaymentStatusRefunded}},
+		{name: "lost again", current: domain.PaymentStatusRefunded, dispute: domain.DisputeStatusLost},
+
+		// ACH disputes can't be contested, Stripe creates them already lost
+		{name: "arrives lost", current: domain.PaymentStatusSucceeded, dispute: domain.DisputeStatusLost, want: []domain.PaymentStatus{domain.PaymentStatusDisputed, domain.PaymentStatusRefunded}},
+		{name: "arrives lost on a partial refund", current: domain.PaymentStatusPartiallyRefunded, dispute: domain.DisputeStatusLost, want: []domain.PaymentStatus{domain.PaymentStatusDisputed, domain.PaymentStatusRefunded}},
+
+		{name: "opened on a returned payment", current: domain.PaymentStatusReturned, dispute: domain.DisputeStatusNeedsResponse},
+		{name: "arrives lost on a returned payment", current: domain.PaymentStatusReturned, dispute: domain.DisputeStatusLost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := disputedPaymentStatuses(tt.current, &domain.Dispute{Status: tt.dispute}, tt.refunded)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("disputedPaymentStatuses(%s, %s) = %v, want %v", tt.current, tt.dispute, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisputeArrivingLostPostsTheLoss(t *testing.T) {
+	payment := &domain.Payment{ID: "payment-1", Amount: 1000, Currency: "usd", Status: domain.PaymentStatusSucceeded}
+	dispute := &domain.Dispute{Status: domain.DisputeStatusLost}
+
+	var entries []string
+	from := payment.Status
+	for _, to := range disputedPaymentStatuses(from, dispute, false) {
+		for _, entry := range domain.PaymentPostings(payment, from, to, payment.Amount, "payment_status_history:1", time.Now()) {
+			entries = append(entries, entry.Type)
+		}
+		from = to
+	}
+
+	want := []string{domain.JournalEntryDispute, domain.JournalEntryDisputeLost}
+	if !slices.Equal(entries, want) {
+		t.Fatalf("posted %v, want %v", entries, want)
+	}
+}
@@ -6,30 +6,220 @@ package orm
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+type AchReturn struct {
+	ID                uuid.UUID     `db:"id" json:"ID"`
+	PaymentID         uuid.UUID     `db:"payment_id" json:"PaymentID"`
+	StripeChargeID    string        `db:"stripe_charge_id" json:"StripeChargeID"`
+	StripeEventID     string        `db:"stripe_event_id" json:"StripeEventID"`
+	ReturnCode        string        `db:"return_code" json:"ReturnCode"`
+	Reason            string        `db:"reason" json:"Reason"`
+	StripeFailureCode string        `db:"stripe_failure_code" json:"StripeFailureCode"`
+	Retryable         bool          `db:"retryable" json:"Retryable"`
+	Action            string        `db:"action" json:"Action"`
+	RetryPaymentID    uuid.NullUUID `db:"retry_payment_id" json:"RetryPaymentID"`
+	CreatedAt         sql.NullTime  `db:"created_at" json:"CreatedAt"`
+	UpdatedAt         sql.NullTime  `db:"updated_at" json:"UpdatedAt"`
+}
+
+type ApiKey struct {
+	ID         uuid.UUID    `db:"id" json:"ID"`
+	Name       string       `db:"name" json:"Name"`
+	Prefix     string       `db:"prefix" json:"Prefix"`
+	SecretHash string       `db:"secret_hash" json:"SecretHash"`
+	Scopes     []string     `db:"scopes" json:"Scopes"`
+	CreatedBy  string       `db:"created_by" json:"CreatedBy"`
+	LastUsedAt sql.NullTime `db:"last_used_at" json:"LastUsedAt"`
+	RevokedAt  sql.NullTime `db:"revoked_at" json:"RevokedAt"`
+	CreatedAt  time.Time    `db:"created_at" json:"CreatedAt"`
+}
+
+type Dispute struct {
+	ID                  uuid.UUID    `db:"id" json:"ID"`
+	PaymentID           uuid.UUID    `db:"payment_id" json:"PaymentID"`
+	StripeDisputeID     string       `db:"stripe_dispute_id" json:"StripeDisputeID"`
+	StripeChargeID      string       `db:"stripe_charge_id" json:"StripeChargeID"`
+	Amount              int64        `db:"amount" json:"Amount"`
+	Currency            string       `db:"currency" json:"Currency"`
+	Reason              string       `db:"reason" json:"Reason"`
+	Status              string       `db:"status" json:"Status"`
+	EvidenceDueBy       sql.NullTime `db:"evidence_due_by" json:"EvidenceDueBy"`
+	EvidenceSubmittedAt sql.NullTime `db:"evidence_submitted_at" json:"EvidenceSubmittedAt"`
+	LastAlertAt         sql.NullTime `db:"last_alert_at" json:"LastAlertAt"`
+	StripeEventID       string       `db:"stripe_event_id" json:"StripeEventID"`
+	CreatedAt           sql.NullTime `db:"created_at" json:"CreatedAt"`
+	UpdatedAt           sql.NullTime `db:"updated_at" json:"UpdatedAt"`
+}
+
+type IdempotencyKey struct {
+	UserID         string        `db:"user_id" json:"UserID"`
+	IdempotencyKey string        `db:"idempotency_key" json:"IdempotencyKey"`
+	RequestHash    string        `db:"request_hash" json:"RequestHash"`
+	PaymentID      uuid.UUID     `db:"payment_id" json:"PaymentID"`
+	ResponseStatus sql.NullInt32 `db:"response_status" json:"ResponseStatus"`
+	ResponseBody   []byte        `db:"response_body" json:"ResponseBody"`
+	CreatedAt      sql.NullTime  `db:"created_at" json:"CreatedAt"`
+	UpdatedAt      sql.NullTime  `db:"updated_at" json:"UpdatedAt"`
+}
+
+type JournalEntry struct {
+	ID          uuid.UUID     `db:"id" json:"ID"`
+	EntryType   string        `db:"entry_type" json:"EntryType"`
+	Reference   string        `db:"reference" json:"Reference"`
+	PaymentID   uuid.NullUUID `db:"payment_id" json:"PaymentID"`
+	Currency    string        `db:"currency" json:"Currency"`
+	Description string        `db:"description" json:"Description"`
+	EffectiveAt time.Time     `db:"effective_at" json:"EffectiveAt"`
+	CreatedAt   sql.NullTime  `db:"created_at" json:"CreatedAt"`
+}
+
+type JournalLine struct {
+	ID        int64     `db:"id" json:"ID"`
+	EntryID   uuid.UUID `db:"entry_id" json:"EntryID"`
+	AccountID uuid.UUID `db:"account_id" json:"AccountID"`
+	Direction string    `db:"direction" json:"Direction"`
+	Amount    int64     `db:"amount" json:"Amount"`
+}
+
+type LedgerAccount struct {
+	ID            uuid.UUID    `db:"id" json:"ID"`
+	Code          string       `db:"code" json:"Code"`
+	UserID        string       `db:"user_id" json:"UserID"`
+	Currency      string       `db:"currency" json:"Currency"`
+	NormalBalance string       `db:"normal_balance" json:"NormalBalance"`
+	CreatedAt     sql.NullTime `db:"created_at" json:"CreatedAt"`
+}
+
 type Payment struct {
+	ID                   uuid.UUID      `db:"id" json:"ID"`
+	UserID               string         `db:"user_id" json:"UserID"`
+	Amount               int64          `db:"amount" json:"Amount"`
+	Currency             string         `db:"currency" json:"Currency"`
+	PlaidAccountID       sql.NullString `db:"plaid_account_id" json:"PlaidAccountID"`
+	PlaidItemID          sql.NullString `db:"plaid_item_id" json:"PlaidItemID"`
+	StripeCustomerID     sql.NullString `db:"stripe_customer_id" json:"StripeCustomerID"`
+	StripePaymentID      sql.NullString `db:"stripe_payment_id" json:"StripePaymentID"`
+	Status               string         `db:"status" json:"Status"`
+	WorkflowID           sql.NullString `db:"workflow_id" json:"WorkflowID"`
+	PaymentMethodID      sql.NullString `db:"payment_method_id" json:"PaymentMethodID"`
+	RetryOfPaymentID     uuid.NullUUID  `db:"retry_of_payment_id" json:"RetryOfPaymentID"`
+	Attempt              int32          `db:"attempt" json:"Attempt"`
+	RecurringPaymentID   uuid.NullUUID  `db:"recurring_payment_id" json:"RecurringPaymentID"`
+	ExecuteAt            sql.NullTime   `db:"execute_at" json:"ExecuteAt"`
+	ExpectedSettlementAt sql.NullTime   `db:"expected_settlement_at" json:"ExpectedSettlementAt"`
+	BalanceCheckResult   sql.NullString `db:"balance_check_result" json:"BalanceCheckResult"`
+	BalanceAvailable     sql.NullInt64  `db:"balance_available" json:"BalanceAvailable"`
+	BalanceRequired      sql.NullInt64  `db:"balance_required" json:"BalanceRequired"`
+	BalanceCheckedAt     sql.NullTime   `db:"balance_checked_at" json:"BalanceCheckedAt"`
+	DisputeStatus        sql.NullString `db:"dispute_status" json:"DisputeStatus"`
+	CreatedAt            sql.NullTime   `db:"created_at" json:"CreatedAt"`
+	UpdatedAt            sql.NullTime   `db:"updated_at" json:"UpdatedAt"`
+}
+
+type PaymentStatusHistory struct {
+	ID         int64          `db:"id" json:"ID"`
+	PaymentID  uuid.UUID      `db:"payment_id" json:"PaymentID"`
+	FromStatus sql.NullString `db:"from_status" json:"FromStatus"`
+	ToStatus   string         `db:"to_status" json:"ToStatus"`
+	Reason     string         `db:"reason" json:"Reason"`
+	CreatedAt  sql.NullTime   `db:"created_at" json:"CreatedAt"`
+}
+
+type PlaidAccount struct {
+	AccountID             string         `db:"account_id" json:"AccountID"`
+	ItemID                string         `db:"item_id" json:"ItemID"`
+	UserID                string         `db:"user_id" json:"UserID"`
+	Name                  string         `db:"name" json:"Name"`
+	OfficialName          sql.NullString `db:"official_name" json:"OfficialName"`
+	Mask                  sql.NullString `db:"mask" json:"Mask"`
+	Type                  string         `db:"type" json:"Type"`
+	Subtype               sql.NullString `db:"subtype" json:"Subtype"`
+	IsDefault             bool           `db:"is_default" json:"IsDefault"`
+	StripePaymentMethodID sql.NullString `db:"stripe_payment_method_id" json:"StripePaymentMethodID"`
+	CreatedAt             sql.NullTime   `db:"created_at" json:"CreatedAt"`
+	UpdatedAt             sql.NullTime   `db:"updated_at" json:"UpdatedAt"`
+}
+
+type PlaidItem struct {
+	ItemID                string         `db:"item_id" json:"ItemID"`
+	UserID                string         `db:"user_id" json:"UserID"`
+	AccessTokenCiphertext []byte         `db:"access_token_ciphertext" json:"AccessTokenCiphertext"`
+	AccessTokenDataKey    []byte         `db:"access_token_data_key" json:"AccessTokenDataKey"`
+	AccessTokenKeyID      string         `db:"access_token_key_id" json:"AccessTokenKeyID"`
+	InstitutionID         sql.NullString `db:"institution_id" json:"InstitutionID"`
+	InstitutionName       sql.NullString `db:"institution_name" json:"InstitutionName"`
+	Status                string         `db:"status" json:"Status"`
+	ErrorCode             sql.NullString `db:"error_code" json:"ErrorCode"`
+	ErrorMessage          sql.NullString `db:"error_message" json:"ErrorMessage"`
+	ConsentExpiresAt      sql.NullTime   `db:"consent_expires_at" json:"ConsentExpiresAt"`
+	VerificationStatus    sql.NullString `db:"verification_status" json:"VerificationStatus"`
+	VerificationAccountID sql.NullString `db:"verification_account_id" json:"VerificationAccountID"`
+	AuthUpdatedAt         sql.NullTime   `db:"auth_updated_at" json:"AuthUpdatedAt"`
+	LastWebhookCode       sql.NullString `db:"last_webhook_code" json:"LastWebhookCode"`
+	CreatedAt             sql.NullTime   `db:"created_at" json:"CreatedAt"`
+	UpdatedAt             sql.NullTime   `db:"updated_at" json:"UpdatedAt"`
+}
+
+type PlaidWebhookKey struct {
+	Kid       string        `db:"kid" json:"Kid"`
+	Alg       string        `db:"alg" json:"Alg"`
+	Crv       string        `db:"crv" json:"Crv"`
+	Kty       string        `db:"kty" json:"Kty"`
+	KeyUse    string        `db:"key_use" json:"KeyUse"`
+	X         string        `db:"x" json:"X"`
+	Y         string        `db:"y" json:"Y"`
+	CreatedAt int64         `db:"created_at" json:"CreatedAt"`
+	ExpiredAt sql.NullInt64 `db:"expired_at" json:"ExpiredAt"`
+	FetchedAt time.Time     `db:"fetched_at" json:"FetchedAt"`
+}
+
+type ReconciliationRun struct {
+	ID            uuid.UUID      `db:"id" json:"ID"`
+	WorkflowID    string         `db:"workflow_id" json:"WorkflowID"`
+	PeriodStart   time.Time      `db:"period_start" json:"PeriodStart"`
+	PeriodEnd     time.Time      `db:"period_end" json:"PeriodEnd"`
+	Status        string         `db:"status" json:"Status"`
+	Discrepancies int32          `db:"discrepancies" json:"Discrepancies"`
+	Report        []byte         `db:"report" json:"Report"`
+	Error         sql.NullString `db:"error" json:"Error"`
+	StartedAt     time.Time      `db:"started_at" json:"StartedAt"`
+	CompletedAt   sql.NullTime   `db:"completed_at" json:"CompletedAt"`
+}
+
+type RecurringPayment struct {
 	ID               uuid.UUID      `db:"id" json:"ID"`
 	UserID           string         `db:"user_id" json:"UserID"`
-	Amount           int64          `db:"amount" json:"Amount"`
-	Currency         string         `db:"currency" json:"Currency"`
+	StripeCustomerID string         `db:"stripe_customer_id" json:"StripeCustomerID"`
+	PaymentMethodID  string         `db:"payment_method_id" json:"PaymentMethodID"`
 	PlaidAccountID   sql.NullString `db:"plaid_account_id" json:"PlaidAccountID"`
 	PlaidItemID      sql.NullString `db:"plaid_item_id" json:"PlaidItemID"`
-	StripeCustomerID sql.NullString `db:"stripe_customer_id" json:"StripeCustomerID"`
-	StripePaymentID  sql.NullString `db:"stripe_payment_id" json:"StripePaymentID"`
+	Amount           int64          `db:"amount" json:"Amount"`
+	Currency         string         `db:"currency" json:"Currency"`
+	Description      string         `db:"description" json:"Description"`
+	BillingInterval  string         `db:"billing_interval" json:"BillingInterval"`
+	AnchorAt         time.Time      `db:"anchor_at" json:"AnchorAt"`
+	EndAt            sql.NullTime   `db:"end_at" json:"EndAt"`
 	Status           string         `db:"status" json:"Status"`
+	ScheduleID       string         `db:"schedule_id" json:"ScheduleID"`
 	CreatedAt        sql.NullTime   `db:"created_at" json:"CreatedAt"`
 	UpdatedAt        sql.NullTime   `db:"updated_at" json:"UpdatedAt"`
 }
 
-type PlaidToken struct {
-	UserID      string       `db:"user_id" json:"UserID"`
-	AccessToken string       `db:"access_token" json:"AccessToken"`
-	AccountID   string       `db:"account_id" json:"AccountID"`
-	ItemID      string       `db:"item_id" json:"ItemID"`
-	CreatedAt   sql.NullTime `db:"created_at" json:"CreatedAt"`
+type Refund struct {
+	ID             uuid.UUID      `db:"id" json:"ID"`
+	PaymentID      uuid.UUID      `db:"payment_id" json:"PaymentID"`
+	Amount         int64          `db:"amount" json:"Amount"`
+	Currency       string         `db:"currency" json:"Currency"`
+	Reason         sql.NullString `db:"reason" json:"Reason"`
+	StripeRefundID sql.NullString `db:"stripe_refund_id" json:"StripeRefundID"`
+	Status         string         `db:"status" json:"Status"`
+	FailureReason  sql.NullString `db:"failure_reason" json:"FailureReason"`
+	CreatedAt      sql.NullTime   `db:"created_at" json:"CreatedAt"`
+	UpdatedAt      sql.NullTime   `db:"updated_at" json:"UpdatedAt"`
 }
 
 type StripeCustomer struct {
@@ -44,3 +234,17 @@ type StripeCustomer struct {
 	CreatedAt         sql.NullTime   `db:"created_at" json:"CreatedAt"`
 	UpdatedAt         sql.NullTime   `db:"updated_at" json:"UpdatedAt"`
 }
+
+type WebhookEvent struct {
+	ID          uuid.UUID      `db:"id" json:"ID"`
+	Provider    string         `db:"provider" json:"Provider"`
+	EventID     string         `db:"event_id" json:"EventID"`
+	EventType   string         `db:"event_type" json:"EventType"`
+	Payload     []byte         `db:"payload" json:"Payload"`
+	Status      string         `db:"status" json:"Status"`
+	Attempts    int32          `db:"attempts" json:"Attempts"`
+	LastError   sql.NullString `db:"last_error" json:"LastError"`
+	ReceivedAt  sql.NullTime   `db:"received_at" json:"ReceivedAt"`
+	ProcessedAt sql.NullTime   `db:"processed_at" json:"ProcessedAt"`
+	UpdatedAt   sql.NullTime   `db:"updated_at" json:"UpdatedAt"`
+}
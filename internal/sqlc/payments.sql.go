@@ -8,48 +8,55 @@ package orm
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
-const getAllPayments = `-- name: GetAllPayments :many
-SELECT id, user_id, amount, currency, plaid_account_id, plaid_item_id, stripe_customer_id, stripe_payment_id, status, created_at, updated_at FROM payments ORDER BY created_at DESC
+const countPayments = `-- name: CountPayments :one
+SELECT COUNT(*) FROM payments
+WHERE ($1::text = '' OR user_id = $1)
+  AND (COALESCE(cardinality($2::text[]), 0) = 0 OR status = ANY($2::text[]))
+  AND ($3::bigint IS NULL OR amount >= $3)
+  AND ($4::bigint IS NULL OR amount <= $4)
+  AND ($5::text = '' OR currency = $5)
+  AND ($6::timestamp IS NULL OR created_at >= $6)
+  AND ($7::timestamp IS NULL OR created_at < $7)
+  AND ($8::text = '' OR stripe_payment_id = $8)
+  AND ($9::text = '' OR stripe_customer_id = $9)
 `
 
-func (q *Queries) GetAllPayments(ctx context.Context) ([]*Payment, error) {
-	rows, err := q.db.Query(ctx, getAllPayments)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []*Payment
-	for rows.Next() {
-		var i Payment
-		if err := rows.Scan(
-			&i.ID,
-			&i.UserID,
-			&i.Amount,
-			&i.Currency,
-			&i.PlaidAccountID,
-			&i.PlaidItemID,
-			&i.StripeCustomerID,
-			&i.StripePaymentID,
-			&i.Status,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, &i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type CountPaymentsParams struct {
+	UserID           string        `db:"user_id" json:"UserID"`
+	Statuses         []string      `db:"statuses" json:"Statuses"`
+	MinAmount        sql.NullInt64 `db:"min_amount" json:"MinAmount"`
+	MaxAmount        sql.NullInt64 `db:"max_amount" json:"MaxAmount"`
+	Currency         string        `db:"currency" json:"Currency"`
+	CreatedFrom      sql.NullTime  `db:"created_from" json:"CreatedFrom"`
+	CreatedTo        sql.NullTime  `db:"created_to" json:"CreatedTo"`
+	StripePaymentID  string        `db:"stripe_payment_id" json:"StripePaymentID"`
+	StripeCustomerID string        `db:"stripe_customer_id" json:"StripeCustomerID"`
+}
+
+func (q *Queries) CountPayments(ctx context.Context, arg CountPaymentsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countPayments,
+		arg.UserID,
+		arg.Statuses,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Currency,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.StripePaymentID,
+		arg.StripeCustomerID,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
 const getPaymentByID = `-- name: GetPaymentByID :one
-SELECT id, user_id, amount, currency, plaid_account_id, plaid_item_id, stripe_customer_id, stripe_payment_id, status, created_at, updated_at FROM payments WHERE id = $1
+SELECT id, user_id, amount, currency, plaid_account_id, plaid_item_id, stripe_customer_id, stripe_payment_id, status, workflow_id, payment_method_id, retry_of_payment_id, attempt, recurring_payment_id, execute_at, expected_settlement_at, balance_check_result, balance_available, balance_required, balance_checked_at, dispute_status, created_at, updated_at FROM payments WHERE id = $1
 `
 
 func (q *Queries) GetPaymentByID(ctx context.Context, id uuid.UUID) (*Payment, error) {
@@ -65,34 +72,125 @@ func (q *Queries) GetPaymentByID(ctx context.Context, id uuid.UUID) (*Payment, e
 		&i.StripeCustomerID,
 		&i.StripePaymentID,
 		&i.Status,
+		&i.WorkflowID,
+		&i.PaymentMethodID,
+		&i.RetryOfPaymentID,
+		&i.Attempt,
+		&i.RecurringPaymentID,
+		&i.ExecuteAt,
+		&i.ExpectedSettlementAt,
+		&i.BalanceCheckResult,
+		&i.BalanceAvailable,
+		&i.BalanceRequired,
+		&i.BalanceCheckedAt,
+		&i.DisputeStatus,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return &i, err
 }
 
-const insertPayment = `-- name: InsertPayment :exec
+const getPaymentByIDForUpdate = `-- name: GetPaymentByIDForUpdate :one
+SELECT id, user_id, amount, currency, plaid_account_id, plaid_item_id, stripe_customer_id, stripe_payment_id, status, workflow_id, payment_method_id, retry_of_payment_id, attempt, recurring_payment_id, execute_at, expected_settlement_at, balance_check_result, balance_available, balance_required, balance_checked_at, dispute_status, created_at, updated_at FROM payments WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetPaymentByIDForUpdate(ctx context.Context, id uuid.UUID) (*Payment, error) {
+	row := q.db.QueryRow(ctx, getPaymentByIDForUpdate, id)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Amount,
+		&i.Currency,
+		&i.PlaidAccountID,
+		&i.PlaidItemID,
+		&i.StripeCustomerID,
+		&i.StripePaymentID,
+		&i.Status,
+		&i.WorkflowID,
+		&i.PaymentMethodID,
+		&i.RetryOfPaymentID,
+		&i.Attempt,
+		&i.RecurringPaymentID,
+		&i.ExecuteAt,
+		&i.ExpectedSettlementAt,
+		&i.BalanceCheckResult,
+		&i.BalanceAvailable,
+		&i.BalanceRequired,
+		&i.BalanceCheckedAt,
+		&i.DisputeStatus,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const getPaymentByStripePaymentID = `-- name: GetPaymentByStripePaymentID :one
+SELECT id, user_id, amount, currency, plaid_account_id, plaid_item_id, stripe_customer_id, stripe_payment_id, status, workflow_id, payment_method_id, retry_of_payment_id, attempt, recurring_payment_id, execute_at, expected_settlement_at, balance_check_result, balance_available, balance_required, balance_checked_at, dispute_status, created_at, updated_at FROM payments WHERE stripe_payment_id = $1
+`
+
+func (q *Queries) GetPaymentByStripePaymentID(ctx context.Context, stripePaymentID sql.NullString) (*Payment, error) {
+	row := q.db.QueryRow(ctx, getPaymentByStripePaymentID, stripePaymentID)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Amount,
+		&i.Currency,
+		&i.PlaidAccountID,
+		&i.PlaidItemID,
+		&i.StripeCustomerID,
+		&i.StripePaymentID,
+		&i.Status,
+		&i.WorkflowID,
+		&i.PaymentMethodID,
+		&i.RetryOfPaymentID,
+		&i.Attempt,
+		&i.RecurringPaymentID,
+		&i.ExecuteAt,
+		&i.ExpectedSettlementAt,
+		&i.BalanceCheckResult,
+		&i.BalanceAvailable,
+		&i.BalanceRequired,
+		&i.BalanceCheckedAt,
+		&i.DisputeStatus,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return &i, err
+}
+
+const insertPayment = `-- name: InsertPayment :execrows
 INSERT INTO payments (
-    user_id, amount, currency, plaid_account_id,
-    plaid_item_id, stripe_customer_id, stripe_payment_id, status
+    id, user_id, amount, currency, plaid_account_id,
+    plaid_item_id, stripe_customer_id, stripe_payment_id, status, workflow_id,
+    retry_of_payment_id, attempt, recurring_payment_id, execute_at
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
 )
+ON CONFLICT (id) DO NOTHING
 `
 
 type InsertPaymentParams struct {
-	UserID           string         `db:"user_id" json:"UserID"`
-	Amount           int64          `db:"amount" json:"Amount"`
-	Currency         string         `db:"currency" json:"Currency"`
-	PlaidAccountID   sql.NullString `db:"plaid_account_id" json:"PlaidAccountID"`
-	PlaidItemID      sql.NullString `db:"plaid_item_id" json:"PlaidItemID"`
-	StripeCustomerID sql.NullString `db:"stripe_customer_id" json:"StripeCustomerID"`
-	StripePaymentID  sql.NullString `db:"stripe_payment_id" json:"StripePaymentID"`
-	Status           string         `db:"status" json:"Status"`
-}
-
-func (q *Queries) InsertPayment(ctx context.Context, arg InsertPaymentParams) error {
-	_, err := q.db.Exec(ctx, insertPayment,
+	ID                 uuid.UUID      `db:"id" json:"ID"`
+	UserID             string         `db:"user_id" json:"UserID"`
+	Amount             int64          `db:"amount" json:"Amount"`
+	Currency           string         `db:"currency" json:"Currency"`
+	PlaidAccountID     sql.NullString `db:"plaid_account_id" json:"PlaidAccountID"`
+	PlaidItemID        sql.NullString `db:"plaid_item_id" json:"PlaidItemID"`
+	StripeCustomerID   sql.NullString `db:"stripe_customer_id" json:"StripeCustomerID"`
+	StripePaymentID    sql.NullString `db:"stripe_payment_id" json:"StripePaymentID"`
+	Status             string         `db:"status" json:"Status"`
+	WorkflowID         sql.NullString `db:"workflow_id" json:"WorkflowID"`
+	RetryOfPaymentID   uuid.NullUUID  `db:"retry_of_payment_id" json:"RetryOfPaymentID"`
+	Attempt            int32          `db:"attempt" json:"Attempt"`
+	RecurringPaymentID uuid.NullUUID  `db:"recurring_payment_id" json:"RecurringPaymentID"`
+	ExecuteAt          sql.NullTime   `db:"execute_at" json:"ExecuteAt"`
+}
+
+func (q *Queries) InsertPayment(ctx context.Context, arg InsertPaymentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, insertPayment,
+		arg.ID,
 		arg.UserID,
 		arg.Amount,
 		arg.Currency,
@@ -101,7 +199,287 @@ func (q *Queries) InsertPayment(ctx context.Context, arg InsertPaymentParams) er
 		arg.StripeCustomerID,
 		arg.StripePaymentID,
 		arg.Status,
+		arg.WorkflowID,
+		arg.RetryOfPaymentID,
+		arg.Attempt,
+		arg.RecurringPaymentID,
+		arg.ExecuteAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listPayments = `-- name: ListPayments :many
+SELECT id, user_id, amount, currency, plaid_account_id, plaid_item_id, stripe_customer_id, stripe_payment_id, status, workflow_id, payment_method_id, retry_of_payment_id, attempt, recurring_payment_id, execute_at, expected_settlement_at, balance_check_result, balance_available, balance_required, balance_checked_at, dispute_status, created_at, updated_at FROM payments
+WHERE ($1::text = '' OR user_id = $1)
+  AND (COALESCE(cardinality($2::text[]), 0) = 0 OR status = ANY($2::text[]))
+  AND ($3::bigint IS NULL OR amount >= $3)
+  AND ($4::bigint IS NULL OR amount <= $4)
+  AND ($5::text = '' OR currency = $5)
+  AND ($6::timestamp IS NULL OR created_at >= $6)
+  AND ($7::timestamp IS NULL OR created_at < $7)
+  AND ($8::text = '' OR stripe_payment_id = $8)
+  AND ($9::text = '' OR stripe_customer_id = $9)
+  AND ($10::timestamp IS NULL OR (created_at, id) < ($10, $11::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $12
+`
+
+type ListPaymentsParams struct {
+	UserID           string        `db:"user_id" json:"UserID"`
+	Statuses         []string      `db:"statuses" json:"Statuses"`
+	MinAmount        sql.NullInt64 `db:"min_amount" json:"MinAmount"`
+	MaxAmount        sql.NullInt64 `db:"max_amount" json:"MaxAmount"`
+	Currency         string        `db:"currency" json:"Currency"`
+	CreatedFrom      sql.NullTime  `db:"created_from" json:"CreatedFrom"`
+	CreatedTo        sql.NullTime  `db:"created_to" json:"CreatedTo"`
+	StripePaymentID  string        `db:"stripe_payment_id" json:"StripePaymentID"`
+	StripeCustomerID string        `db:"stripe_customer_id" json:"StripeCustomerID"`
+	CursorCreatedAt  sql.NullTime  `db:"cursor_created_at" json:"CursorCreatedAt"`
+	CursorID         uuid.UUID     `db:"cursor_id" json:"CursorID"`
+	Limit            int32         `db:"limit" json:"Limit"`
+}
+
+// Empty strings, NULLs and an empty status list don't filter. Pages are ordered newest
+// first, with the ID breaking ties; the cursor is the last row of the previous page.
+func (q *Queries) ListPayments(ctx context.Context, arg ListPaymentsParams) ([]*Payment, error) {
+	rows, err := q.db.Query(ctx, listPayments,
+		arg.UserID,
+		arg.Statuses,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Currency,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.StripePaymentID,
+		arg.StripeCustomerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Payment
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Amount,
+			&i.Currency,
+			&i.PlaidAccountID,
+			&i.PlaidItemID,
+			&i.StripeCustomerID,
+			&i.StripePaymentID,
+			&i.Status,
+			&i.WorkflowID,
+			&i.PaymentMethodID,
+			&i.RetryOfPaymentID,
+			&i.Attempt,
+			&i.RecurringPaymentID,
+			&i.ExecuteAt,
+			&i.ExpectedSettlementAt,
+			&i.BalanceCheckResult,
+			&i.BalanceAvailable,
+			&i.BalanceRequired,
+			&i.BalanceCheckedAt,
+			&i.DisputeStatus,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPaymentsByStripePaymentIDs = `-- name: ListPaymentsByStripePaymentIDs :many
+SELECT id, user_id, amount, currency, plaid_account_id, plaid_item_id, stripe_customer_id, stripe_payment_id, status, workflow_id, payment_method_id, retry_of_payment_id, attempt, recurring_payment_id, execute_at, expected_settlement_at, balance_check_result, balance_available, balance_required, balance_checked_at, dispute_status, created_at, updated_at FROM payments
+WHERE stripe_payment_id = ANY($1::text[])
+`
+
+func (q *Queries) ListPaymentsByStripePaymentIDs(ctx context.Context, stripePaymentIds []string) ([]*Payment, error) {
+	rows, err := q.db.Query(ctx, listPaymentsByStripePaymentIDs, stripePaymentIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Payment
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Amount,
+			&i.Currency,
+			&i.PlaidAccountID,
+			&i.PlaidItemID,
+			&i.StripeCustomerID,
+			&i.StripePaymentID,
+			&i.Status,
+			&i.WorkflowID,
+			&i.PaymentMethodID,
+			&i.RetryOfPaymentID,
+			&i.Attempt,
+			&i.RecurringPaymentID,
+			&i.ExecuteAt,
+			&i.ExpectedSettlementAt,
+			&i.BalanceCheckResult,
+			&i.BalanceAvailable,
+			&i.BalanceRequired,
+			&i.BalanceCheckedAt,
+			&i.DisputeStatus,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPaymentsSucceededBetween = `-- name: ListPaymentsSucceededBetween :many
+SELECT id, user_id, amount, currency, plaid_account_id, plaid_item_id, stripe_customer_id, stripe_payment_id, status, workflow_id, payment_method_id, retry_of_payment_id, attempt, recurring_payment_id, execute_at, expected_settlement_at, balance_check_result, balance_available, balance_required, balance_checked_at, dispute_status, created_at, updated_at FROM payments
+WHERE stripe_payment_id IS NOT NULL
+  AND id IN (
+    SELECT h.payment_id FROM payment_status_history h
+    WHERE h.to_status = 'succeeded'
+      AND h.created_at >= $1::timestamp
+      AND h.created_at < $2::timestamp
+  )
+`
+
+type ListPaymentsSucceededBetweenParams struct {
+	PeriodStart time.Time `db:"period_start" json:"PeriodStart"`
+	PeriodEnd   time.Time `db:"period_end" json:"PeriodEnd"`
+}
+
+func (q *Queries) ListPaymentsSucceededBetween(ctx context.Context, arg ListPaymentsSucceededBetweenParams) ([]*Payment, error) {
+	rows, err := q.db.Query(ctx, listPaymentsSucceededBetween, arg.PeriodStart, arg.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*Payment
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Amount,
+			&i.Currency,
+			&i.PlaidAccountID,
+			&i.PlaidItemID,
+			&i.StripeCustomerID,
+			&i.StripePaymentID,
+			&i.Status,
+			&i.WorkflowID,
+			&i.PaymentMethodID,
+			&i.RetryOfPaymentID,
+			&i.Attempt,
+			&i.RecurringPaymentID,
+			&i.ExecuteAt,
+			&i.ExpectedSettlementAt,
+			&i.BalanceCheckResult,
+			&i.BalanceAvailable,
+			&i.BalanceRequired,
+			&i.BalanceCheckedAt,
+			&i.DisputeStatus,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updatePaymentBalanceCheck = `-- name: UpdatePaymentBalanceCheck :exec
+UPDATE payments
+SET balance_check_result = $2,
+    balance_available = $3,
+    balance_required = $4,
+    balance_checked_at = $5,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdatePaymentBalanceCheckParams struct {
+	ID                 uuid.UUID      `db:"id" json:"ID"`
+	BalanceCheckResult sql.NullString `db:"balance_check_result" json:"BalanceCheckResult"`
+	BalanceAvailable   sql.NullInt64  `db:"balance_available" json:"BalanceAvailable"`
+	BalanceRequired    sql.NullInt64  `db:"balance_required" json:"BalanceRequired"`
+	BalanceCheckedAt   sql.NullTime   `db:"balance_checked_at" json:"BalanceCheckedAt"`
+}
+
+func (q *Queries) UpdatePaymentBalanceCheck(ctx context.Context, arg UpdatePaymentBalanceCheckParams) error {
+	_, err := q.db.Exec(ctx, updatePaymentBalanceCheck,
+		arg.ID,
+		arg.BalanceCheckResult,
+		arg.BalanceAvailable,
+		arg.BalanceRequired,
+		arg.BalanceCheckedAt,
+	)
+	return err
+}
+
+const updatePaymentDisputeStatus = `-- name: UpdatePaymentDisputeStatus :exec
+UPDATE payments
+SET dispute_status = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdatePaymentDisputeStatusParams struct {
+	ID            uuid.UUID      `db:"id" json:"ID"`
+	DisputeStatus sql.NullString `db:"dispute_status" json:"DisputeStatus"`
+}
+
+func (q *Queries) UpdatePaymentDisputeStatus(ctx context.Context, arg UpdatePaymentDisputeStatusParams) error {
+	_, err := q.db.Exec(ctx, updatePaymentDisputeStatus, arg.ID, arg.DisputeStatus)
+	return err
+}
+
+const updatePaymentExecuteAt = `-- name: UpdatePaymentExecuteAt :exec
+UPDATE payments SET execute_at = $2, updated_at = NOW() WHERE id = $1
+`
+
+type UpdatePaymentExecuteAtParams struct {
+	ID        uuid.UUID    `db:"id" json:"ID"`
+	ExecuteAt sql.NullTime `db:"execute_at" json:"ExecuteAt"`
+}
+
+func (q *Queries) UpdatePaymentExecuteAt(ctx context.Context, arg UpdatePaymentExecuteAtParams) error {
+	_, err := q.db.Exec(ctx, updatePaymentExecuteAt, arg.ID, arg.ExecuteAt)
+	return err
+}
+
+const updatePaymentExpectedSettlement = `-- name: UpdatePaymentExpectedSettlement :exec
+UPDATE payments SET expected_settlement_at = $2, updated_at = NOW() WHERE id = $1
+`
+
+type UpdatePaymentExpectedSettlementParams struct {
+	ID                   uuid.UUID    `db:"id" json:"ID"`
+	ExpectedSettlementAt sql.NullTime `db:"expected_settlement_at" json:"ExpectedSettlementAt"`
+}
+
+func (q *Queries) UpdatePaymentExpectedSettlement(ctx context.Context, arg UpdatePaymentExpectedSettlementParams) error {
+	_, err := q.db.Exec(ctx, updatePaymentExpectedSettlement, arg.ID, arg.ExpectedSettlementAt)
 	return err
 }
 
@@ -118,3 +496,18 @@ func (q *Queries) UpdatePaymentStatus(ctx context.Context, arg UpdatePaymentStat
 	_, err := q.db.Exec(ctx, updatePaymentStatus, arg.ID, arg.Status)
 	return err
 }
+
+const updatePaymentStripeID = `-- name: UpdatePaymentStripeID :exec
+UPDATE payments SET stripe_payment_id = $2, payment_method_id = $3, updated_at = NOW() WHERE id = $1
+`
+
+type UpdatePaymentStripeIDParams struct {
+	ID              uuid.UUID      `db:"id" json:"ID"`
+	StripePaymentID sql.NullString `db:"stripe_payment_id" json:"StripePaymentID"`
+	PaymentMethodID sql.NullString `db:"payment_method_id" json:"PaymentMethodID"`
+}
+
+func (q *Queries) UpdatePaymentStripeID(ctx context.Context, arg UpdatePaymentStripeIDParams) error {
+	_, err := q.db.Exec(ctx, updatePaymentStripeID, arg.ID, arg.StripePaymentID, arg.PaymentMethodID)
+	return err
+}
@@ -6,22 +6,110 @@ package orm
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
-	DeletePlaidToken(ctx context.Context, userID string) error
+	ClearDefaultPlaidAccount(ctx context.Context, userID string) error
+	ClearStripeCustomerDefaultPayment(ctx context.Context, arg ClearStripeCustomerDefaultPaymentParams) error
+	CompleteReconciliationRun(ctx context.Context, arg CompleteReconciliationRunParams) error
+	CountPayments(ctx context.Context, arg CountPaymentsParams) (int64, error)
+	DeletePlaidItem(ctx context.Context, arg DeletePlaidItemParams) (int64, error)
+	DeleteRecurringPayment(ctx context.Context, id uuid.UUID) error
 	DeleteStripeCustomer(ctx context.Context, userID string) error
-	GetAllPayments(ctx context.Context) ([]*Payment, error)
+	FailReconciliationRun(ctx context.Context, arg FailReconciliationRunParams) error
+	GetACHReturnByID(ctx context.Context, id uuid.UUID) (*AchReturn, error)
+	GetACHReturnByStripeChargeID(ctx context.Context, stripeChargeID string) (*AchReturn, error)
+	GetACHReturnsByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]*AchReturn, error)
+	GetAPIKeyByID(ctx context.Context, id uuid.UUID) (*ApiKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*ApiKey, error)
+	GetDefaultPlaidTokenByUserID(ctx context.Context, userID string) (*GetDefaultPlaidTokenByUserIDRow, error)
+	GetDisputeByID(ctx context.Context, id uuid.UUID) (*Dispute, error)
+	GetDisputeByStripeID(ctx context.Context, stripeDisputeID string) (*Dispute, error)
+	GetDisputesByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]*Dispute, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (*IdempotencyKey, error)
+	GetJournalEntriesByPaymentID(ctx context.Context, paymentID uuid.NullUUID) ([]*JournalEntry, error)
+	GetJournalLinesByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]*GetJournalLinesByPaymentIDRow, error)
+	GetLedgerAccountBalance(ctx context.Context, arg GetLedgerAccountBalanceParams) (int64, error)
+	GetLedgerAccountByID(ctx context.Context, id uuid.UUID) (*LedgerAccount, error)
 	GetPaymentByID(ctx context.Context, id uuid.UUID) (*Payment, error)
-	GetPlaidTokenByUserID(ctx context.Context, userID string) (*GetPlaidTokenByUserIDRow, error)
+	GetPaymentByIDForUpdate(ctx context.Context, id uuid.UUID) (*Payment, error)
+	GetPaymentByStripePaymentID(ctx context.Context, stripePaymentID sql.NullString) (*Payment, error)
+	GetPaymentStatusHistory(ctx context.Context, paymentID uuid.UUID) ([]*PaymentStatusHistory, error)
+	GetPlaidItemByItemID(ctx context.Context, itemID string) (*PlaidItem, error)
+	GetPlaidTokenByAccountID(ctx context.Context, arg GetPlaidTokenByAccountIDParams) (*GetPlaidTokenByAccountIDRow, error)
+	GetPlaidWebhookKey(ctx context.Context, kid string) (*PlaidWebhookKey, error)
+	GetReconciliationRunByID(ctx context.Context, id uuid.UUID) (*ReconciliationRun, error)
+	GetRecurringPaymentByID(ctx context.Context, id uuid.UUID) (*RecurringPayment, error)
+	GetRefundByID(ctx context.Context, id uuid.UUID) (*Refund, error)
+	GetRefundByStripeRefundID(ctx context.Context, stripeRefundID sql.NullString) (*Refund, error)
+	GetRefundedAmountByPaymentID(ctx context.Context, paymentID uuid.UUID) (int64, error)
+	GetRefundsByPaymentID(ctx context.Context, paymentID uuid.UUID) ([]*Refund, error)
 	GetStripeCustomerByUserID(ctx context.Context, userID string) (*StripeCustomer, error)
-	InsertPayment(ctx context.Context, arg InsertPaymentParams) error
+	GetSucceededRefundAmountByPaymentID(ctx context.Context, paymentID uuid.UUID) (int64, error)
+	GetWebhookEventByID(ctx context.Context, id uuid.UUID) (*WebhookEvent, error)
+	GetWebhookEventByProviderEventID(ctx context.Context, arg GetWebhookEventByProviderEventIDParams) (*WebhookEvent, error)
+	InsertACHReturn(ctx context.Context, arg InsertACHReturnParams) (*AchReturn, error)
+	InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (*ApiKey, error)
+	InsertIdempotencyKey(ctx context.Context, arg InsertIdempotencyKeyParams) (*IdempotencyKey, error)
+	InsertJournalEntry(ctx context.Context, arg InsertJournalEntryParams) (int64, error)
+	InsertJournalLine(ctx context.Context, arg InsertJournalLineParams) error
+	InsertPayment(ctx context.Context, arg InsertPaymentParams) (int64, error)
+	InsertPaymentStatusHistory(ctx context.Context, arg InsertPaymentStatusHistoryParams) (int64, error)
+	InsertReconciliationRun(ctx context.Context, arg InsertReconciliationRunParams) error
+	InsertRecurringPayment(ctx context.Context, arg InsertRecurringPaymentParams) (*RecurringPayment, error)
+	InsertRefund(ctx context.Context, arg InsertRefundParams) error
 	InsertStripeCustomer(ctx context.Context, arg InsertStripeCustomerParams) error
+	InsertWebhookEvent(ctx context.Context, arg InsertWebhookEventParams) (*WebhookEvent, error)
+	ListAPIKeys(ctx context.Context) ([]*ApiKey, error)
+	ListDisputes(ctx context.Context, arg ListDisputesParams) ([]*Dispute, error)
+	ListLedgerAccountsByUserID(ctx context.Context, userID string) ([]*LedgerAccount, error)
+	// Empty strings, NULLs and an empty status list don't filter. Pages are ordered newest
+	// first, with the ID breaking ties; the cursor is the last row of the previous page.
+	ListPayments(ctx context.Context, arg ListPaymentsParams) ([]*Payment, error)
+	ListPaymentsByStripePaymentIDs(ctx context.Context, stripePaymentIds []string) ([]*Payment, error)
+	ListPaymentsSucceededBetween(ctx context.Context, arg ListPaymentsSucceededBetweenParams) ([]*Payment, error)
+	ListPlaidAccountsByUserID(ctx context.Context, userID string) ([]*ListPlaidAccountsByUserIDRow, error)
+	ListPlaidItemsForKeyRotation(ctx context.Context, arg ListPlaidItemsForKeyRotationParams) ([]*ListPlaidItemsForKeyRotationRow, error)
+	ListReconciliationRuns(ctx context.Context, limit int32) ([]*ReconciliationRun, error)
+	ListRecurringPaymentsByUserID(ctx context.Context, userID string) ([]*RecurringPayment, error)
+	ListWebhookEventsByStatus(ctx context.Context, arg ListWebhookEventsByStatusParams) ([]*WebhookEvent, error)
+	MarkDisputeAlerted(ctx context.Context, id uuid.UUID) error
+	MarkWebhookEventFailed(ctx context.Context, arg MarkWebhookEventFailedParams) error
+	MarkWebhookEventProcessed(ctx context.Context, id uuid.UUID) error
+	MarkWebhookEventProcessing(ctx context.Context, id uuid.UUID) error
+	ResetWebhookEvent(ctx context.Context, id uuid.UUID) error
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (int64, error)
+	RewrapPlaidItemDataKey(ctx context.Context, arg RewrapPlaidItemDataKeyParams) error
+	SaveIdempotencyKeyResponse(ctx context.Context, arg SaveIdempotencyKeyResponseParams) error
+	SetDefaultPlaidAccount(ctx context.Context, arg SetDefaultPlaidAccountParams) (int64, error)
+	SetDefaultPlaidAccountIfNone(ctx context.Context, arg SetDefaultPlaidAccountIfNoneParams) error
+	SetPlaidAccountPaymentMethod(ctx context.Context, arg SetPlaidAccountPaymentMethodParams) (int64, error)
+	TouchAPIKey(ctx context.Context, id uuid.UUID) error
+	UpdateACHReturnAction(ctx context.Context, arg UpdateACHReturnActionParams) error
+	UpdateDisputeEvidence(ctx context.Context, arg UpdateDisputeEvidenceParams) error
+	UpdatePaymentBalanceCheck(ctx context.Context, arg UpdatePaymentBalanceCheckParams) error
+	UpdatePaymentDisputeStatus(ctx context.Context, arg UpdatePaymentDisputeStatusParams) error
+	UpdatePaymentExecuteAt(ctx context.Context, arg UpdatePaymentExecuteAtParams) error
+	UpdatePaymentExpectedSettlement(ctx context.Context, arg UpdatePaymentExpectedSettlementParams) error
 	UpdatePaymentStatus(ctx context.Context, arg UpdatePaymentStatusParams) error
+	UpdatePaymentStripeID(ctx context.Context, arg UpdatePaymentStripeIDParams) error
+	UpdatePlaidItemAuthUpdated(ctx context.Context, itemID string) error
+	UpdatePlaidItemConsentExpiration(ctx context.Context, arg UpdatePlaidItemConsentExpirationParams) error
+	UpdatePlaidItemStatus(ctx context.Context, arg UpdatePlaidItemStatusParams) error
+	UpdatePlaidItemVerification(ctx context.Context, arg UpdatePlaidItemVerificationParams) error
+	UpdateRecurringPayment(ctx context.Context, arg UpdateRecurringPaymentParams) (*RecurringPayment, error)
+	UpdateRecurringPaymentStatus(ctx context.Context, arg UpdateRecurringPaymentStatusParams) error
+	UpdateRefundResult(ctx context.Context, arg UpdateRefundResultParams) error
 	UpdateStripeCustomerDefaultPayment(ctx context.Context, arg UpdateStripeCustomerDefaultPaymentParams) error
-	UpsertPlaidToken(ctx context.Context, arg UpsertPlaidTokenParams) error
+	// Closed disputes are final, a late event for one returns no row.
+	UpsertDispute(ctx context.Context, arg UpsertDisputeParams) (*Dispute, error)
+	UpsertLedgerAccount(ctx context.Context, arg UpsertLedgerAccountParams) (*LedgerAccount, error)
+	UpsertPlaidAccount(ctx context.Context, arg UpsertPlaidAccountParams) error
+	UpsertPlaidItem(ctx context.Context, arg UpsertPlaidItemParams) error
+	UpsertPlaidWebhookKey(ctx context.Context, arg UpsertPlaidWebhookKeyParams) error
 }
 
 var _ Querier = (*Queries)(nil)
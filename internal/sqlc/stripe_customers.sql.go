@@ -10,6 +10,24 @@ import (
 	"database/sql"
 )
 
+const clearStripeCustomerDefaultPayment = `-- name: ClearStripeCustomerDefaultPayment :exec
+UPDATE stripe_customers
+SET
+    default_payment_id = NULL,
+    updated_at = NOW()
+WHERE user_id = $1 AND default_payment_id = $2
+`
+
+type ClearStripeCustomerDefaultPaymentParams struct {
+	UserID           string         `db:"user_id" json:"UserID"`
+	DefaultPaymentID sql.NullString `db:"default_payment_id" json:"DefaultPaymentID"`
+}
+
+func (q *Queries) ClearStripeCustomerDefaultPayment(ctx context.Context, arg ClearStripeCustomerDefaultPaymentParams) error {
+	_, err := q.db.Exec(ctx, clearStripeCustomerDefaultPayment, arg.UserID, arg.DefaultPaymentID)
+	return err
+}
+
 const deleteStripeCustomer = `-- name: DeleteStripeCustomer :exec
 DELETE FROM stripe_customers
 WHERE user_id = $1
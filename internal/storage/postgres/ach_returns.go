@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// CreateACHReturn records a returned ACH debit, deduplicating on the Stripe
+// charge ID. It reports whether the return was new; for duplicates, achReturn
+// is filled in with the stored copy.
+func (r *postgresRepo) CreateACHReturn(ctx context.Context, achReturn *domain.ACHReturn) (bool, error) {
+	paymentID, err := uuid.Parse(achReturn.PaymentID)
+	if err != nil {
+		return false, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	q := r.tx.WithQtx(ctx)
+
+	dbReturn, err := q.InsertACHReturn(ctx, orm.InsertACHReturnParams{
+		ID:                uuid.New(),
+		PaymentID:         paymentID,
+		StripeChargeID:    achReturn.StripeChargeID,
+		StripeEventID:     achReturn.StripeEventID,
+		ReturnCode:        achReturn.ReturnCode,
+		Reason:            achReturn.Reason,
+		StripeFailureCode: achReturn.StripeFailureCode,
+		Retryable:         achReturn.Retryable,
+	})
+	if err == nil {
+		*achReturn = *toDomainACHReturn(dbReturn)
+		return true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("failed to insert ACH return for charge %s: %w", achReturn.StripeChargeID, err)
+	}
+
+	// ON CONFLICT DO NOTHING returns no row: the return was already recorded
+	dbReturn, err = q.GetACHReturnByStripeChargeID(ctx, achReturn.StripeChargeID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get ACH return for charge %s: %w", achReturn.StripeChargeID, err)
+	}
+	*achReturn = *toDomainACHReturn(dbReturn)
+	return false, nil
+}
+
+func (r *postgresRepo) GetACHReturnByID(ctx context.Context, returnID string) (*domain.ACHReturn, error) {
+	id, err := uuid.Parse(returnID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	q := r.tx.WithQtx(ctx)
+	dbReturn, err := q.GetACHReturnByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainACHReturn(dbReturn), nil
+}
+
+func (r *postgresRepo) GetACHReturnsByPaymentID(ctx context.Context, paymentID string) ([]*domain.ACHReturn, error) {
+	id, err := uuid.Parse(paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	q := r.tx.WithQtx(ctx)
+	dbReturns, err := q.GetACHReturnsByPaymentID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ACH returns for payment %s: %w", paymentID, err)
+	}
+
+	returns := make([]*domain.ACHReturn, 0, len(dbReturns))
+	for _, ret := range dbReturns {
+		returns = append(returns, toDomainACHReturn(ret))
+	}
+	return returns, nil
+}
+
+// UpdateACHReturnAction records how a return was handled. retryPaymentID is
+// empty unless a retry payment was started.
+func (r *postgresRepo) UpdateACHReturnAction(ctx context.Context, returnID, action, retryPaymentID string) error {
+	id, err := uuid.Parse(returnID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	q := r.tx.WithQtx(ctx)
+	return q.UpdateACHReturnAction(ctx, orm.UpdateACHReturnActionParams{
+		ID:             id,
+		Action:         action,
+		RetryPaymentID: toNullUUID(retryPaymentID),
+	})
+}
+
+func toDomainACHReturn(r *orm.AchReturn) *domain.ACHReturn {
+	return &domain.ACHReturn{
+		ID:                r.ID.String(),
+		PaymentID:         r.PaymentID.String(),
+		StripeChargeID:    r.StripeChargeID,
+		StripeEventID:     r.StripeEventID,
+		ReturnCode:        r.ReturnCode,
+		Reason:            r.Reason,
+		StripeFailureCode: r.StripeFailureCode,
+		Retryable:         r.Retryable,
+		Action:            r.Action,
+		RetryPaymentID:    fromNullUUID(r.RetryPaymentID),
+		CreatedAt:         r.CreatedAt.Time,
+		UpdatedAt:         r.UpdatedAt.Time,
+	}
+}
+
+// toNullUUID treats an empty or malformed ID as NULL.
+func toNullUUID(s string) uuid.NullUUID {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: id, Valid: true}
+}
+
+func fromNullUUID(id uuid.NullUUID) string {
+	if !id.Valid {
+		return ""
+	}
+	return id.UUID.String()
+}
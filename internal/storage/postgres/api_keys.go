@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// CreateAPIKey stores a new key and fills in its stored copy.
+func (r *postgresRepo) CreateAPIKey(ctx context.Context, key *domain.APIKey) error {
+	id, err := uuid.Parse(key.ID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	dbKey, err := r.tx.WithQtx(ctx).InsertAPIKey(ctx, orm.InsertAPIKeyParams{
+		ID:         id,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		SecretHash: key.SecretHash,
+		Scopes:     key.Scopes,
+		CreatedBy:  key.CreatedBy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create API key %s: %w", key.Name, err)
+	}
+	*key = *toDomainAPIKey(dbKey)
+	return nil
+}
+
+func (r *postgresRepo) GetAPIKey(ctx context.Context, keyID string) (*domain.APIKey, error) {
+	id, err := uuid.Parse(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	dbKey, err := r.tx.WithQtx(ctx).GetAPIKeyByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainAPIKey(dbKey), nil
+}
+
+func (r *postgresRepo) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	dbKey, err := r.tx.WithQtx(ctx).GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainAPIKey(dbKey), nil
+}
+
+func (r *postgresRepo) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	dbKeys, err := r.tx.WithQtx(ctx).ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	keys := make([]*domain.APIKey, 0, len(dbKeys))
+	for _, k := range dbKeys {
+		keys = append(keys, toDomainAPIKey(k))
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey returns pgx.ErrNoRows when the key doesn't exist or was already revoked.
+func (r *postgresRepo) RevokeAPIKey(ctx context.Context, keyID string) error {
+	id, err := uuid.Parse(keyID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	revoked, err := r.tx.WithQtx(ctx).RevokeAPIKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key %s: %w", keyID, err)
+	}
+	if revoked == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// TouchAPIKey records that the key was used. The time is only written once a minute,
+// so a busy backend doesn't turn every request into a write.
+func (r *postgresRepo) TouchAPIKey(ctx context.Context, keyID string) error {
+	id, err := uuid.Parse(keyID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+	return r.tx.WithQtx(ctx).TouchAPIKey(ctx, id)
+}
+
+func toDomainAPIKey(k *orm.ApiKey) *domain.APIKey {
+	return &domain.APIKey{
+		ID:         k.ID.String(),
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		SecretHash: k.SecretHash,
+		Scopes:     k.Scopes,
+		CreatedBy:  k.CreatedBy,
+		LastUsedAt: fromNullTime(k.LastUsedAt),
+		RevokedAt:  fromNullTime(k.RevokedAt),
+		CreatedAt:  k.CreatedAt.UTC(),
+	}
+}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+	"github.com/GalaDe/payments-service/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// SaveDispute creates or updates a dispute from Stripe, deduplicating on the Stripe
+// dispute ID, and copies its status onto the payment. It reports whether the dispute
+// changed; closed disputes are final, so a late event for one is ignored. Either way,
+// dispute is filled in with the stored copy.
+func (r *postgresRepo) SaveDispute(ctx context.Context, dispute *domain.Dispute) (bool, error) {
+	paymentID, err := uuid.Parse(dispute.PaymentID)
+	if err != nil {
+		return false, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	var changed bool
+	err = r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		q := r.tx.WithQtx(ctx)
+
+		dbDispute, err := q.UpsertDispute(ctx, orm.UpsertDisputeParams{
+			ID:              uuid.New(),
+			PaymentID:       paymentID,
+			StripeDisputeID: dispute.StripeDisputeID,
+			StripeChargeID:  dispute.StripeChargeID,
+			Amount:          dispute.Amount,
+			Currency:        dispute.Currency,
+			Reason:          dispute.Reason,
+			Status:          dispute.Status,
+			EvidenceDueBy:   toNullTime(dispute.EvidenceDueBy),
+			StripeEventID:   dispute.StripeEventID,
+		})
+		if err == nil {
+			changed = true
+		} else {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("failed to save dispute %s: %w", dispute.StripeDisputeID, err)
+			}
+			// the dispute is already closed and wasn't updated
+			dbDispute, err = q.GetDisputeByStripeID(ctx, dispute.StripeDisputeID)
+			if err != nil {
+				return fmt.Errorf("failed to get dispute %s: %w", dispute.StripeDisputeID, err)
+			}
+		}
+		*dispute = *toDomainDispute(dbDispute)
+
+		if !changed {
+			return nil
+		}
+		return q.UpdatePaymentDisputeStatus(ctx, orm.UpdatePaymentDisputeStatusParams{
+			ID:            paymentID,
+			DisputeStatus: utils.StringToNull(dispute.Status),
+		})
+	})
+	return changed, err
+}
+
+func (r *postgresRepo) GetDispute(ctx context.Context, disputeID string) (*domain.Dispute, error) {
+	id, err := uuid.Parse(disputeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	dbDispute, err := r.tx.WithQtx(ctx).GetDisputeByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainDispute(dbDispute), nil
+}
+
+// ListDisputes returns disputes with the nearest evidence deadline first. An empty
+// status lists disputes in any status.
+func (r *postgresRepo) ListDisputes(ctx context.Context, status string, limit int32) ([]*domain.Dispute, error) {
+	dbDisputes, err := r.tx.WithQtx(ctx).ListDisputes(ctx, orm.ListDisputesParams{
+		Status: status,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disputes: %w", err)
+	}
+	return toDomainDisputes(dbDisputes), nil
+}
+
+func (r *postgresRepo) GetDisputesByPaymentID(ctx context.Context, paymentID string) ([]*domain.Dispute, error) {
+	id, err := uuid.Parse(paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	dbDisputes, err := r.tx.WithQtx(ctx).GetDisputesByPaymentID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disputes for payment %s: %w", paymentID, err)
+	}
+	return toDomainDisputes(dbDisputes), nil
+}
+
+// UpdateDisputeEvidence records evidence sent to Stripe along with the dispute status
+// Stripe returned. submitted is false when the evidence was only staged.
+func (r *postgresRepo) UpdateDisputeEvidence(ctx context.Context, disputeID, status string, submitted bool) error {
+	id, err := uuid.Parse(disputeID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	return r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		q := r.tx.WithQtx(ctx)
+
+		dbDispute, err := q.GetDisputeByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := q.UpdateDisputeEvidence(ctx, orm.UpdateDisputeEvidenceParams{
+			ID:        id,
+			Status:    status,
+			Submitted: submitted,
+		}); err != nil {
+			return fmt.Errorf("failed to update evidence of dispute %s: %w", disputeID, err)
+		}
+		return q.UpdatePaymentDisputeStatus(ctx, orm.UpdatePaymentDisputeStatusParams{
+			ID:            dbDispute.PaymentID,
+			DisputeStatus: utils.StringToNull(status),
+		})
+	})
+}
+
+func (r *postgresRepo) MarkDisputeAlerted(ctx context.Context, disputeID string) error {
+	id, err := uuid.Parse(disputeID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+	return r.tx.WithQtx(ctx).MarkDisputeAlerted(ctx, id)
+}
+
+func toDomainDisputes(dbDisputes []*orm.Dispute) []*domain.Dispute {
+	disputes := make([]*domain.Dispute, 0, len(dbDisputes))
+	for _, d := range dbDisputes {
+		disputes = append(disputes, toDomainDispute(d))
+	}
+	return disputes
+}
+
+func toDomainDispute(d *orm.Dispute) *domain.Dispute {
+	return &domain.Dispute{
+		ID:                  d.ID.String(),
+		PaymentID:           d.PaymentID.String(),
+		StripeDisputeID:     d.StripeDisputeID,
+		StripeChargeID:      d.StripeChargeID,
+		Amount:              d.Amount,
+		Currency:            d.Currency,
+		Reason:              d.Reason,
+		Status:              d.Status,
+		EvidenceDueBy:       fromNullTime(d.EvidenceDueBy),
+		EvidenceSubmittedAt: fromNullTime(d.EvidenceSubmittedAt),
+		LastAlertAt:         fromNullTime(d.LastAlertAt),
+		StripeEventID:       d.StripeEventID,
+		CreatedAt:           d.CreatedAt.Time,
+		UpdatedAt:           d.UpdatedAt.Time,
+	}
+}
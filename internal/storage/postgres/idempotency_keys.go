@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+/*
+ClaimIdempotencyKey records the first use of an Idempotency-Key. It reports whether
+the key was new; for a key seen before, key is filled in with the stored copy,
+including the payment ID and the response of the first request once it finished.
+
+A stored key with a different request hash fails with domain.ErrIdempotencyKeyReused.
+*/
+func (r *postgresRepo) ClaimIdempotencyKey(ctx context.Context, key *domain.IdempotencyKey) (bool, error) {
+	paymentID, err := uuid.Parse(key.PaymentID)
+	if err != nil {
+		return false, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	q := r.tx.WithQtx(ctx)
+
+	dbKey, err := q.InsertIdempotencyKey(ctx, orm.InsertIdempotencyKeyParams{
+		UserID:         key.UserID,
+		IdempotencyKey: key.Key,
+		RequestHash:    key.RequestHash,
+		PaymentID:      paymentID,
+	})
+	if err == nil {
+		*key = *toDomainIdempotencyKey(dbKey)
+		return true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("failed to insert idempotency key: %w", err)
+	}
+
+	// ON CONFLICT DO NOTHING returns no row: the key was used before
+	dbKey, err = q.GetIdempotencyKey(ctx, orm.GetIdempotencyKeyParams{
+		UserID:         key.UserID,
+		IdempotencyKey: key.Key,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	if dbKey.RequestHash != key.RequestHash {
+		return false, domain.ErrIdempotencyKeyReused
+	}
+	*key = *toDomainIdempotencyKey(dbKey)
+	return false, nil
+}
+
+// SaveIdempotencyKeyResponse stores the response sent for the first request with a key.
+func (r *postgresRepo) SaveIdempotencyKeyResponse(ctx context.Context, key *domain.IdempotencyKey) error {
+	q := r.tx.WithQtx(ctx)
+
+	return q.SaveIdempotencyKeyResponse(ctx, orm.SaveIdempotencyKeyResponseParams{
+		UserID:         key.UserID,
+		IdempotencyKey: key.Key,
+		ResponseStatus: sql.NullInt32{Int32: int32(key.ResponseStatus), Valid: key.ResponseStatus != 0},
+		ResponseBody:   key.ResponseBody,
+	})
+}
+
+func toDomainIdempotencyKey(k *orm.IdempotencyKey) *domain.IdempotencyKey {
+	return &domain.IdempotencyKey{
+		UserID:         k.UserID,
+		Key:            k.IdempotencyKey,
+		RequestHash:    k.RequestHash,
+		PaymentID:      k.PaymentID.String(),
+		ResponseStatus: int(k.ResponseStatus.Int32),
+		ResponseBody:   k.ResponseBody,
+		CreatedAt:      k.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+	"github.com/google/uuid"
+)
+
+// PostJournalEntry validates and posts a journal entry, creating the accounts it
+// posts to on first use. It reports false when an entry with the same reference
+// was posted before, in which case nothing is written.
+func (r *postgresRepo) PostJournalEntry(ctx context.Context, entry *domain.JournalEntry) (bool, error) {
+	var posted bool
+	err := r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		posted, err = postJournalEntry(ctx, r.tx.WithQtx(ctx), entry)
+		return err
+	})
+	return posted, err
+}
+
+func (r *postgresRepo) GetLedgerAccount(ctx context.Context, accountID string) (*domain.LedgerAccount, error) {
+	id, err := uuid.Parse(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	q := r.tx.WithQtx(ctx)
+	account, err := q.GetLedgerAccountByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainLedgerAccount(account), nil
+}
+
+// GetLedgerAccountBalance returns the balance of an account from the entries effective at or before asOf.
+func (r *postgresRepo) GetLedgerAccountBalance(ctx context.Context, accountID string, asOf time.Time) (*domain.LedgerBalance, error) {
+	id, err := uuid.Parse(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	q := r.tx.WithQtx(ctx)
+	account, err := q.GetLedgerAccountByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return ledgerBalance(ctx, q, account, asOf)
+}
+
+// GetUserLedgerBalances returns the balance of every account of a user as of asOf.
+// An empty userID returns the system accounts.
+func (r *postgresRepo) GetUserLedgerBalances(ctx context.Context, userID string, asOf time.Time) ([]*domain.LedgerBalance, error) {
+	q := r.tx.WithQtx(ctx)
+	accounts, err := q.ListLedgerAccountsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger accounts for user %s: %w", userID, err)
+	}
+
+	balances := make([]*domain.LedgerBalance, 0, len(accounts))
+	for _, account := range accounts {
+		balance, err := ledgerBalance(ctx, q, account, asOf)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, balance)
+	}
+	return balances, nil
+}
+
+func (r *postgresRepo) GetJournalEntriesByPaymentID(ctx context.Context, paymentID string) ([]*domain.JournalEntry, error) {
+	id, err := uuid.Parse(paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	q := r.tx.WithQtx(ctx)
+	dbEntries, err := q.GetJournalEntriesByPaymentID(ctx, uuid.NullUUID{UUID: id, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal entries for payment %s: %w", paymentID, err)
+	}
+	dbLines, err := q.GetJournalLinesByPaymentID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal lines for payment %s: %w", paymentID, err)
+	}
+
+	entries := make([]*domain.JournalEntry, 0, len(dbEntries))
+	byID := make(map[uuid.UUID]*domain.JournalEntry, len(dbEntries))
+	for _, e := range dbEntries {
+		entry := &domain.JournalEntry{
+			ID:          e.ID.String(),
+			Type:        e.EntryType,
+			Reference:   e.Reference,
+			PaymentID:   fromNullUUID(e.PaymentID),
+			Currency:    e.Currency,
+			Description: e.Description,
+			EffectiveAt: e.EffectiveAt.UTC(),
+			CreatedAt:   e.CreatedAt.Time,
+		}
+		entries = append(entries, entry)
+		byID[e.ID] = entry
+	}
+	for _, l := range dbLines {
+		if entry, ok := byID[l.EntryID]; ok {
+			entry.Lines = append(entry.Lines, &domain.JournalLine{
+				AccountID:   l.AccountID.String(),
+				AccountCode: l.Code,
+				UserID:      l.UserID,
+				Direction:   l.Direction,
+				Amount:      l.Amount,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// postJournalEntry writes an entry and its lines. It must run inside a transaction so
+// an entry is never stored without all of its lines.
+func postJournalEntry(ctx context.Context, q orm.Querier, entry *domain.JournalEntry) (bool, error) {
+	if err := entry.Validate(); err != nil {
+		return false, err
+	}
+
+	id := uuid.New()
+	inserted, err := q.InsertJournalEntry(ctx, orm.InsertJournalEntryParams{
+		ID:          id,
+		EntryType:   entry.Type,
+		Reference:   entry.Reference,
+		PaymentID:   toNullUUID(entry.PaymentID),
+		Currency:    entry.Currency,
+		Description: entry.Description,
+		EffectiveAt: entry.EffectiveAt.UTC(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to insert journal entry %s: %w", entry.Reference, err)
+	}
+	if inserted == 0 {
+		return false, nil
+	}
+	entry.ID = id.String()
+
+	for _, line := range entry.Lines {
+		normal, _ := domain.LedgerNormalBalance(line.AccountCode)
+		account, err := q.UpsertLedgerAccount(ctx, orm.UpsertLedgerAccountParams{
+			ID:            uuid.New(),
+			Code:          line.AccountCode,
+			UserID:        line.UserID,
+			Currency:      entry.Currency,
+			NormalBalance: normal,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to get ledger account %s for user %q: %w", line.AccountCode, line.UserID, err)
+		}
+		line.AccountID = account.ID.String()
+
+		err = q.InsertJournalLine(ctx, orm.InsertJournalLineParams{
+			EntryID:   id,
+			AccountID: account.ID,
+			Direction: line.Direction,
+			Amount:    line.Amount,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to insert journal line for entry %s: %w", entry.Reference, err)
+		}
+	}
+	return true, nil
+}
+
+// postPaymentTransition posts the ledger entries for a payment status change recorded
+// as history entry historyID (see domain.PaymentPostings).
+func postPaymentTransition(ctx context.Context, q orm.Querier, payment *orm.Payment, from, to domain.PaymentStatus, historyID int64) error {
+	net := payment.Amount
+	if to == domain.PaymentStatusReturned || to == domain.PaymentStatusDisputed || from == domain.PaymentStatusDisputed {
+		refunded, err := q.GetSucceededRefundAmountByPaymentID(ctx, payment.ID)
+		if err != nil {
+			return fmt.Errorf("failed to sum refunds for payment %s: %w", payment.ID, err)
+		}
+		net -= refunded
+	}
+
+	reference := fmt.Sprintf("payment_status_history:%d", historyID)
+	for _, entry := range domain.PaymentPostings(toDomainPayment(payment), from, to, net, reference, time.Now().UTC()) {
+		if _, err := postJournalEntry(ctx, q, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// postRefund posts a refund that succeeded, or reverses one that had succeeded and
+// no longer has. previous is the refund's status before the update.
+func postRefund(ctx context.Context, q orm.Querier, payment *orm.Payment, refund *domain.Refund, previous string) error {
+	var entry *domain.JournalEntry
+	switch {
+	case refund.Status == domain.RefundStatusSucceeded && previous != domain.RefundStatusSucceeded:
+		entry = domain.RefundPosting(toDomainPayment(payment), refund, time.Now().UTC())
+	case refund.Status != domain.RefundStatusSucceeded && previous == domain.RefundStatusSucceeded:
+		entry = domain.RefundReversalPosting(toDomainPayment(payment), refund, time.Now().UTC())
+	default:
+		return nil
+	}
+	_, err := postJournalEntry(ctx, q, entry)
+	return err
+}
+
+// ledgerBalance sums an account's lines as of asOf, signed so the account's normal side is positive.
+func ledgerBalance(ctx context.Context, q orm.Querier, account *orm.LedgerAccount, asOf time.Time) (*domain.LedgerBalance, error) {
+	balance, err := q.GetLedgerAccountBalance(ctx, orm.GetLedgerAccountBalanceParams{
+		AccountID: account.ID,
+		AsOf:      asOf.UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance of ledger account %s: %w", account.ID, err)
+	}
+	if account.NormalBalance == domain.LedgerCredit {
+		balance = -balance
+	}
+
+	return &domain.LedgerBalance{
+		Account: toDomainLedgerAccount(account),
+		Balance: balance,
+		AsOf:    asOf.UTC(),
+	}, nil
+}
+
+func toDomainLedgerAccount(a *orm.LedgerAccount) *domain.LedgerAccount {
+	return &domain.LedgerAccount{
+		ID:            a.ID.String(),
+		Code:          a.Code,
+		UserID:        a.UserID,
+		Currency:      a.Currency,
+		NormalBalance: a.NormalBalance,
+		CreatedAt:     a.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+	"github.com/GalaDe/payments-service/internal/utils"
+	"github.com/google/uuid"
+)
+
+// UpdatePaymentStatus moves a payment to status and records the change in its
+// history. Moving to the current status is a no-op, so callers can retry safely;
+// transitions the state machine doesn't allow fail with domain.ErrInvalidPaymentTransition.
+func (r *postgresRepo) UpdatePaymentStatus(ctx context.Context, paymentID string, status domain.PaymentStatus, reason string) error {
+	return r.UpdatePaymentStatuses(ctx, paymentID, []domain.PaymentStatus{status}, reason)
+}
+
+// UpdatePaymentStatuses moves a payment through statuses in order, in one transaction,
+// recording and posting every step.
+func (r *postgresRepo) UpdatePaymentStatuses(ctx context.Context, paymentID string, statuses []domain.PaymentStatus, reason string) error {
+	id, err := uuid.Parse(paymentID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	return r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		q := r.tx.WithQtx(ctx)
+
+		payment, err := q.GetPaymentByIDForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			if err := transitionPayment(ctx, q, payment, status, reason); err != nil {
+				return err
+			}
+			payment.Status = string(status)
+		}
+		return nil
+	})
+}
+
+func (r *postgresRepo) GetPaymentStatusHistory(ctx context.Context, paymentID string) ([]*domain.PaymentStatusChange, error) {
+	id, err := uuid.Parse(paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	q := r.tx.WithQtx(ctx)
+	dbHistory, err := q.GetPaymentStatusHistory(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status history for payment %s: %w", paymentID, err)
+	}
+
+	history := make([]*domain.PaymentStatusChange, 0, len(dbHistory))
+	for _, h := range dbHistory {
+		history = append(history, &domain.PaymentStatusChange{
+			ID:         h.ID,
+			PaymentID:  h.PaymentID.String(),
+			FromStatus: domain.PaymentStatus(utils.NullStringToStr(h.FromStatus)),
+			ToStatus:   domain.PaymentStatus(h.ToStatus),
+			Reason:     h.Reason,
+			CreatedAt:  h.CreatedAt.Time,
+		})
+	}
+	return history, nil
+}
+
+// transitionPayment validates and applies a status change to a payment locked with
+// GetPaymentByIDForUpdate. It must run inside the transaction that took the lock so
+// the status, its history entry and its ledger postings are written together.
+func transitionPayment(ctx context.Context, q orm.Querier, payment *orm.Payment, next domain.PaymentStatus, reason string) error {
+	current := domain.PaymentStatus(payment.Status)
+	if current == next {
+		return nil
+	}
+	if !current.CanTransitionTo(next) {
+		return fmt.Errorf("payment %s from %s to %s: %w", payment.ID, current, next, domain.ErrInvalidPaymentTransition)
+	}
+
+	err := q.UpdatePaymentStatus(ctx, orm.UpdatePaymentStatusParams{
+		ID:     payment.ID,
+		Status: string(next),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update payment %s status: %w", payment.ID, err)
+	}
+
+	historyID, err := q.InsertPaymentStatusHistory(ctx, orm.InsertPaymentStatusHistoryParams{
+		PaymentID:  payment.ID,
+		FromStatus: utils.StringToNull(string(current)),
+		ToStatus:   string(next),
+		Reason:     reason,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record payment %s status history: %w", payment.ID, err)
+	}
+
+	return postPaymentTransition(ctx, q, payment, current, next, historyID)
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+	"github.com/GalaDe/payments-service/internal/utils"
+	"github.com/jackc/pgx/v4"
+)
+
+func (r *postgresRepo) ListPlaidAccounts(ctx context.Context, userID string) ([]*domain.PlaidAccount, error) {
+	q := r.tx.WithQtx(ctx)
+	rows, err := q.ListPlaidAccountsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plaid accounts for user %s: %w", userID, err)
+	}
+
+	accounts := make([]*domain.PlaidAccount, 0, len(rows))
+	for _, a := range rows {
+		accounts = append(accounts, &domain.PlaidAccount{
+			AccountID:       a.AccountID,
+			ItemID:          a.ItemID,
+			UserID:          a.UserID,
+			Name:            a.Name,
+			OfficialName:    utils.NullStringToStr(a.OfficialName),
+			Mask:            utils.NullStringToStr(a.Mask),
+			Type:            a.Type,
+			Subtype:         utils.NullStringToStr(a.Subtype),
+			IsDefault:       a.IsDefault,
+			PaymentMethodID: utils.NullStringToStr(a.StripePaymentMethodID),
+			InstitutionName: utils.NullStringToStr(a.InstitutionName),
+			ItemStatus:      a.ItemStatus,
+			CreatedAt:       a.CreatedAt.Time,
+			UpdatedAt:       a.UpdatedAt.Time,
+		})
+	}
+	return accounts, nil
+}
+
+// SetDefaultPlaidAccount makes accountID the user's default funding account.
+// It returns pgx.ErrNoRows when the account doesn't belong to the user.
+func (r *postgresRepo) SetDefaultPlaidAccount(ctx context.Context, userID, accountID string) error {
+	return r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		q := r.tx.WithQtx(ctx)
+
+		if err := q.ClearDefaultPlaidAccount(ctx, userID); err != nil {
+			return fmt.Errorf("failed to clear default plaid account for user %s: %w", userID, err)
+		}
+
+		updated, err := q.SetDefaultPlaidAccount(ctx, orm.SetDefaultPlaidAccountParams{
+			UserID:    userID,
+			AccountID: accountID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set default plaid account %s: %w", accountID, err)
+		}
+		if updated == 0 {
+			return pgx.ErrNoRows
+		}
+		return nil
+	})
+}
+
+// SetPlaidAccountPaymentMethod records the Stripe payment method that debits accountID.
+// It returns pgx.ErrNoRows when the account doesn't belong to the user.
+func (r *postgresRepo) SetPlaidAccountPaymentMethod(ctx context.Context, userID, accountID, paymentMethodID string) error {
+	q := r.tx.WithQtx(ctx)
+	updated, err := q.SetPlaidAccountPaymentMethod(ctx, orm.SetPlaidAccountPaymentMethodParams{
+		UserID:                userID,
+		AccountID:             accountID,
+		StripePaymentMethodID: utils.StringToNull(paymentMethodID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set payment method of plaid account %s: %w", accountID, err)
+	}
+	if updated == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
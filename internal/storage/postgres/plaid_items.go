@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+	"github.com/GalaDe/payments-service/internal/utils"
+	"github.com/jackc/pgx/v4"
+)
+
+// SavePlaidItem stores a newly linked Item with all of its accounts. If the user
+// has no default funding account yet, the first checking account (or the first
+// account) becomes the default.
+func (r *postgresRepo) SavePlaidItem(ctx context.Context, item *domain.PlaidItem, accounts []*domain.PlaidAccount) error {
+	return r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		q := r.tx.WithQtx(ctx)
+
+		token, err := r.tokens.encrypt(item.ItemID, item.AccessToken)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt access token for plaid item %s: %w", item.ItemID, err)
+		}
+
+		err = q.UpsertPlaidItem(ctx, orm.UpsertPlaidItemParams{
+			ItemID:                item.ItemID,
+			UserID:                item.UserID,
+			AccessTokenCiphertext: token.Ciphertext,
+			AccessTokenDataKey:    token.DataKey,
+			AccessTokenKeyID:      token.KeyID,
+			InstitutionID:         utils.StringToNull(item.InstitutionID),
+			InstitutionName:       utils.StringToNull(item.InstitutionName),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save plaid item %s: %w", item.ItemID, err)
+		}
+
+		for _, account := range accounts {
+			err := q.UpsertPlaidAccount(ctx, orm.UpsertPlaidAccountParams{
+				AccountID:    account.AccountID,
+				ItemID:       item.ItemID,
+				UserID:       item.UserID,
+				Name:         account.Name,
+				OfficialName: utils.StringToNull(account.OfficialName),
+				Mask:         utils.StringToNull(account.Mask),
+				Type:         account.Type,
+				Subtype:      utils.StringToNull(account.Subtype),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to save plaid account %s: %w", account.AccountID, err)
+			}
+		}
+
+		if defaultAccount := pickDefaultPlaidAccount(accounts); defaultAccount != nil {
+			err := q.SetDefaultPlaidAccountIfNone(ctx, orm.SetDefaultPlaidAccountIfNoneParams{
+				AccountID: defaultAccount.AccountID,
+				UserID:    item.UserID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to set default plaid account: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeletePlaidItem removes one of the user's Items together with its accounts.
+// It returns pgx.ErrNoRows when the user has no such Item.
+func (r *postgresRepo) DeletePlaidItem(ctx context.Context, userID, itemID string) error {
+	q := r.tx.WithQtx(ctx)
+	deleted, err := q.DeletePlaidItem(ctx, orm.DeletePlaidItemParams{
+		ItemID: itemID,
+		UserID: userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete plaid item %s: %w", itemID, err)
+	}
+	if deleted == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *postgresRepo) GetPlaidItem(ctx context.Context, itemID string) (*domain.PlaidItem, error) {
+	q := r.tx.WithQtx(ctx)
+	dbItem, err := q.GetPlaidItemByItemID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	item := toDomainPlaidItem(dbItem)
+	item.AccessToken, err = r.tokens.decrypt(dbItem.ItemID, encryptedToken{
+		Ciphertext: dbItem.AccessTokenCiphertext,
+		DataKey:    dbItem.AccessTokenDataKey,
+		KeyID:      dbItem.AccessTokenKeyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// UpdatePlaidItemStatus records the Item health reported by an ITEM webhook.
+// Webhooks for Items we don't know are ignored.
+func (r *postgresRepo) UpdatePlaidItemStatus(ctx context.Context, itemID, status, errorCode, errorMessage, webhookCode string) error {
+	q := r.tx.WithQtx(ctx)
+	err := q.UpdatePlaidItemStatus(ctx, orm.UpdatePlaidItemStatusParams{
+		ItemID:          itemID,
+		Status:          status,
+		ErrorCode:       utils.StringToNull(errorCode),
+		ErrorMessage:    utils.StringToNull(errorMessage),
+		LastWebhookCode: utils.StringToNull(webhookCode),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update plaid item %s status: %w", itemID, err)
+	}
+	return nil
+}
+
+// UpdatePlaidItemConsentExpiration stores when the Item's consent expires. A
+// healthy Item moves to pending_expiration; a worse status is kept.
+func (r *postgresRepo) UpdatePlaidItemConsentExpiration(ctx context.Context, itemID string, expiresAt time.Time) error {
+	q := r.tx.WithQtx(ctx)
+	err := q.UpdatePlaidItemConsentExpiration(ctx, orm.UpdatePlaidItemConsentExpirationParams{
+		ItemID:           itemID,
+		ConsentExpiresAt: sql.NullTime{Time: expiresAt, Valid: !expiresAt.IsZero()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update plaid item %s consent expiration: %w", itemID, err)
+	}
+	return nil
+}
+
+func (r *postgresRepo) UpdatePlaidItemVerification(ctx context.Context, itemID, accountID, verificationStatus, webhookCode string) error {
+	q := r.tx.WithQtx(ctx)
+	err := q.UpdatePlaidItemVerification(ctx, orm.UpdatePlaidItemVerificationParams{
+		ItemID:                itemID,
+		VerificationStatus:    utils.StringToNull(verificationStatus),
+		VerificationAccountID: utils.StringToNull(accountID),
+		LastWebhookCode:       utils.StringToNull(webhookCode),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update plaid item %s verification: %w", itemID, err)
+	}
+	return nil
+}
+
+func (r *postgresRepo) MarkPlaidItemAuthUpdated(ctx context.Context, itemID string) error {
+	q := r.tx.WithQtx(ctx)
+	if err := q.UpdatePlaidItemAuthUpdated(ctx, itemID); err != nil {
+		return fmt.Errorf("failed to mark plaid item %s auth updated: %w", itemID, err)
+	}
+	return nil
+}
+
+func toDomainPlaidItem(i *orm.PlaidItem) *domain.PlaidItem {
+	item := &domain.PlaidItem{
+		ItemID:                i.ItemID,
+		UserID:                i.UserID,
+		InstitutionID:         utils.NullStringToStr(i.InstitutionID),
+		InstitutionName:       utils.NullStringToStr(i.InstitutionName),
+		Status:                i.Status,
+		ErrorCode:             utils.NullStringToStr(i.ErrorCode),
+		ErrorMessage:          utils.NullStringToStr(i.ErrorMessage),
+		VerificationStatus:    utils.NullStringToStr(i.VerificationStatus),
+		VerificationAccountID: utils.NullStringToStr(i.VerificationAccountID),
+		LastWebhookCode:       utils.NullStringToStr(i.LastWebhookCode),
+		CreatedAt:             i.CreatedAt.Time,
+		UpdatedAt:             i.UpdatedAt.Time,
+	}
+	if i.ConsentExpiresAt.Valid {
+		item.ConsentExpiresAt = &i.ConsentExpiresAt.Time
+	}
+	if i.AuthUpdatedAt.Valid {
+		item.AuthUpdatedAt = &i.AuthUpdatedAt.Time
+	}
+	return item
+}
+
+func pickDefaultPlaidAccount(accounts []*domain.PlaidAccount) *domain.PlaidAccount {
+	for _, account := range accounts {
+		if account.Type == "depository" && account.Subtype == "checking" {
+			return account
+		}
+	}
+	if len(accounts) > 0 {
+		return accounts[0]
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+)
+
+func (r *postgresRepo) GetPlaidWebhookKey(ctx context.Context, kid string) (*domain.PlaidWebhookKey, error) {
+	q := r.tx.WithQtx(ctx)
+	dbKey, err := q.GetPlaidWebhookKey(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &domain.PlaidWebhookKey{
+		KeyID:     dbKey.Kid,
+		Alg:       dbKey.Alg,
+		Crv:       dbKey.Crv,
+		Kty:       dbKey.Kty,
+		Use:       dbKey.KeyUse,
+		X:         dbKey.X,
+		Y:         dbKey.Y,
+		CreatedAt: dbKey.CreatedAt,
+		FetchedAt: dbKey.FetchedAt,
+	}
+	if dbKey.ExpiredAt.Valid {
+		key.ExpiredAt = &dbKey.ExpiredAt.Int64
+	}
+	return key, nil
+}
+
+// SavePlaidWebhookKey stores a freshly fetched key. Key material never changes for
+// a kid, so an existing row only gets its expiry and fetch time updated.
+func (r *postgresRepo) SavePlaidWebhookKey(ctx context.Context, key *domain.PlaidWebhookKey) error {
+	params := orm.UpsertPlaidWebhookKeyParams{
+		Kid:       key.KeyID,
+		Alg:       key.Alg,
+		Crv:       key.Crv,
+		Kty:       key.Kty,
+		KeyUse:    key.Use,
+		X:         key.X,
+		Y:         key.Y,
+		CreatedAt: key.CreatedAt,
+		FetchedAt: key.FetchedAt,
+	}
+	if key.ExpiredAt != nil {
+		params.ExpiredAt = sql.NullInt64{Int64: *key.ExpiredAt, Valid: true}
+	}
+
+	q := r.tx.WithQtx(ctx)
+	if err := q.UpsertPlaidWebhookKey(ctx, params); err != nil {
+		return fmt.Errorf("failed to save plaid webhook key %s: %w", key.KeyID, err)
+	}
+	return nil
+}
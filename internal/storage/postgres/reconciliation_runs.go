@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+	"github.com/GalaDe/payments-service/internal/utils"
+	"github.com/google/uuid"
+)
+
+// CreateReconciliationRun records a run as running. Creating a run that already exists
+// is a no-op, so a retried workflow step doesn't fail.
+func (r *postgresRepo) CreateReconciliationRun(ctx context.Context, run *domain.ReconciliationRun) error {
+	id, err := uuid.Parse(run.ID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	return r.tx.WithQtx(ctx).InsertReconciliationRun(ctx, orm.InsertReconciliationRunParams{
+		ID:          id,
+		WorkflowID:  run.WorkflowID,
+		PeriodStart: run.PeriodStart.UTC(),
+		PeriodEnd:   run.PeriodEnd.UTC(),
+	})
+}
+
+func (r *postgresRepo) CompleteReconciliationRun(ctx context.Context, runID string, report *domain.ReconciliationReport) error {
+	id, err := uuid.Parse(runID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	encoded, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode reconciliation report: %w", err)
+	}
+
+	return r.tx.WithQtx(ctx).CompleteReconciliationRun(ctx, orm.CompleteReconciliationRunParams{
+		ID:            id,
+		Discrepancies: int32(len(report.Discrepancies)),
+		Report:        encoded,
+	})
+}
+
+func (r *postgresRepo) FailReconciliationRun(ctx context.Context, runID, reason string) error {
+	id, err := uuid.Parse(runID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	return r.tx.WithQtx(ctx).FailReconciliationRun(ctx, orm.FailReconciliationRunParams{
+		ID:    id,
+		Error: utils.StringToNull(reason),
+	})
+}
+
+func (r *postgresRepo) GetReconciliationRun(ctx context.Context, runID string) (*domain.ReconciliationRun, error) {
+	id, err := uuid.Parse(runID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	run, err := r.tx.WithQtx(ctx).GetReconciliationRunByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainReconciliationRun(run, true)
+}
+
+// ListReconciliationRuns returns the latest runs without their reports.
+func (r *postgresRepo) ListReconciliationRuns(ctx context.Context, limit int32) ([]*domain.ReconciliationRun, error) {
+	dbRuns, err := r.tx.WithQtx(ctx).ListReconciliationRuns(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reconciliation runs: %w", err)
+	}
+
+	runs := make([]*domain.ReconciliationRun, 0, len(dbRuns))
+	for _, dbRun := range dbRuns {
+		run, err := toDomainReconciliationRun(dbRun, false)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+func (r *postgresRepo) ListPaymentsByStripePaymentIDs(ctx context.Context, stripePaymentIDs []string) ([]*domain.Payment, error) {
+	dbPayments, err := r.tx.WithQtx(ctx).ListPaymentsByStripePaymentIDs(ctx, stripePaymentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments by Stripe ID: %w", err)
+	}
+	return toDomainPayments(dbPayments), nil
+}
+
+// ListPaymentsSucceededBetween returns the Stripe payments that moved to succeeded in [from, to).
+func (r *postgresRepo) ListPaymentsSucceededBetween(ctx context.Context, from, to time.Time) ([]*domain.Payment, error) {
+	dbPayments, err := r.tx.WithQtx(ctx).ListPaymentsSucceededBetween(ctx, orm.ListPaymentsSucceededBetweenParams{
+		PeriodStart: from.UTC(),
+		PeriodEnd:   to.UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments succeeded between %s and %s: %w", from, to, err)
+	}
+	return toDomainPayments(dbPayments), nil
+}
+
+func toDomainPayments(dbPayments []*orm.Payment) []*domain.Payment {
+	payments := make([]*domain.Payment, 0, len(dbPayments))
+	for _, p := range dbPayments {
+		payments = append(payments, toDomainPayment(p))
+	}
+	return payments
+}
+
+func toDomainReconciliationRun(r *orm.ReconciliationRun, withReport bool) (*domain.ReconciliationRun, error) {
+	run := &domain.ReconciliationRun{
+		ID:            r.ID.String(),
+		WorkflowID:    r.WorkflowID,
+		PeriodStart:   r.PeriodStart.UTC(),
+		PeriodEnd:     r.PeriodEnd.UTC(),
+		Status:        r.Status,
+		Discrepancies: r.Discrepancies,
+		Error:         utils.NullStringToStr(r.Error),
+		StartedAt:     r.StartedAt.UTC(),
+		CompletedAt:   fromNullTime(r.CompletedAt),
+	}
+	if withReport && len(r.Report) > 0 {
+		if err := json.Unmarshal(r.Report, &run.Report); err != nil {
+			return nil, fmt.Errorf("failed to decode report of reconciliation run %s: %w", run.ID, err)
+		}
+	}
+	return run, nil
+}
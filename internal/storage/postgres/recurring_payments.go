@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+	"github.com/GalaDe/payments-service/internal/utils"
+	"github.com/google/uuid"
+)
+
+// CreateRecurringPayment stores a new recurring payment. If recurring.ID is empty a
+// new UUID is generated; the stored row is written back into recurring.
+func (r *postgresRepo) CreateRecurringPayment(ctx context.Context, recurring *domain.RecurringPayment) error {
+	id := uuid.New()
+	if recurring.ID != "" {
+		parsed, err := uuid.Parse(recurring.ID)
+		if err != nil {
+			return fmt.Errorf("invalid UUID: %w", err)
+		}
+		id = parsed
+	}
+
+	status := recurring.Status
+	if status == "" {
+		status = domain.RecurringPaymentStatusActive
+	}
+
+	q := r.tx.WithQtx(ctx)
+	dbRecurring, err := q.InsertRecurringPayment(ctx, orm.InsertRecurringPaymentParams{
+		ID:               id,
+		UserID:           recurring.UserID,
+		StripeCustomerID: recurring.CustomerID,
+		PaymentMethodID:  recurring.PaymentMethodID,
+		PlaidAccountID:   utils.StringToNull(recurring.PlaidAccountID),
+		PlaidItemID:      utils.StringToNull(recurring.PlaidItemID),
+		Amount:           recurring.Amount,
+		Currency:         recurring.Currency,
+		Description:      recurring.Description,
+		BillingInterval:  recurring.Interval,
+		AnchorAt:         recurring.AnchorAt.UTC(),
+		EndAt:            toNullTime(recurring.EndAt),
+		Status:           status,
+		ScheduleID:       recurring.ScheduleID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert recurring payment for user %s: %w", recurring.UserID, err)
+	}
+
+	*recurring = *toDomainRecurringPayment(dbRecurring)
+	return nil
+}
+
+func (r *postgresRepo) GetRecurringPaymentByID(ctx context.Context, recurringID string) (*domain.RecurringPayment, error) {
+	id, err := uuid.Parse(recurringID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	q := r.tx.WithQtx(ctx)
+	dbRecurring, err := q.GetRecurringPaymentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainRecurringPayment(dbRecurring), nil
+}
+
+func (r *postgresRepo) ListRecurringPayments(ctx context.Context, userID string) ([]*domain.RecurringPayment, error) {
+	q := r.tx.WithQtx(ctx)
+
+	dbRecurring, err := q.ListRecurringPaymentsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recurring payments for user %s: %w", userID, err)
+	}
+
+	recurring := make([]*domain.RecurringPayment, 0, len(dbRecurring))
+	for _, rp := range dbRecurring {
+		recurring = append(recurring, toDomainRecurringPayment(rp))
+	}
+	return recurring, nil
+}
+
+// UpdateRecurringPayment saves the editable fields: amount, payment method, description and end date.
+func (r *postgresRepo) UpdateRecurringPayment(ctx context.Context, recurring *domain.RecurringPayment) error {
+	id, err := uuid.Parse(recurring.ID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	q := r.tx.WithQtx(ctx)
+	dbRecurring, err := q.UpdateRecurringPayment(ctx, orm.UpdateRecurringPaymentParams{
+		ID:              id,
+		Amount:          recurring.Amount,
+		PaymentMethodID: recurring.PaymentMethodID,
+		Description:     recurring.Description,
+		EndAt:           toNullTime(recurring.EndAt),
+	})
+	if err != nil {
+		return err
+	}
+
+	*recurring = *toDomainRecurringPayment(dbRecurring)
+	return nil
+}
+
+func (r *postgresRepo) UpdateRecurringPaymentStatus(ctx context.Context, recurringID, status string) error {
+	id, err := uuid.Parse(recurringID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	q := r.tx.WithQtx(ctx)
+	return q.UpdateRecurringPaymentStatus(ctx, orm.UpdateRecurringPaymentStatusParams{
+		ID:     id,
+		Status: status,
+	})
+}
+
+func (r *postgresRepo) DeleteRecurringPayment(ctx context.Context, recurringID string) error {
+	id, err := uuid.Parse(recurringID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	q := r.tx.WithQtx(ctx)
+	return q.DeleteRecurringPayment(ctx, id)
+}
+
+func toDomainRecurringPayment(rp *orm.RecurringPayment) *domain.RecurringPayment {
+	return &domain.RecurringPayment{
+		ID:              rp.ID.String(),
+		UserID:          rp.UserID,
+		CustomerID:      rp.StripeCustomerID,
+		PaymentMethodID: rp.PaymentMethodID,
+		PlaidAccountID:  utils.NullStringToStr(rp.PlaidAccountID),
+		PlaidItemID:     utils.NullStringToStr(rp.PlaidItemID),
+		Amount:          rp.Amount,
+		Currency:        rp.Currency,
+		Description:     rp.Description,
+		Interval:        rp.BillingInterval,
+		AnchorAt:        rp.AnchorAt.UTC(),
+		EndAt:           fromNullTime(rp.EndAt),
+		Status:          rp.Status,
+		ScheduleID:      rp.ScheduleID,
+		CreatedAt:       rp.CreatedAt.Time,
+		UpdatedAt:       rp.UpdatedAt.Time,
+	}
+}
+
+func toNullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func toNullInt64(n *int64) sql.NullInt64 {
+	if n == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *n, Valid: true}
+}
+
+func fromNullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
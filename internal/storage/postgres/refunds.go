@@ -0,0 +1,229 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+	"github.com/GalaDe/payments-service/internal/utils"
+	"github.com/google/uuid"
+)
+
+// CreateRefund records a pending refund for a payment.
+//
+// The payment row is locked for the duration of the transaction so concurrent
+// refunds cannot together exceed the payment amount. A zero refund.Amount
+// refunds whatever is left on the payment.
+func (r *postgresRepo) CreateRefund(ctx context.Context, refund *domain.Refund) error {
+	paymentID, err := uuid.Parse(refund.PaymentID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	return r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		q := r.tx.WithQtx(ctx)
+
+		payment, err := q.GetPaymentByIDForUpdate(ctx, paymentID)
+		if err != nil {
+			return err
+		}
+		if !isRefundable(payment) {
+			return fmt.Errorf("payment %s with status %s: %w", refund.PaymentID, payment.Status, domain.ErrPaymentNotRefundable)
+		}
+
+		refunded, err := q.GetRefundedAmountByPaymentID(ctx, paymentID)
+		if err != nil {
+			return fmt.Errorf("failed to sum refunds for payment %s: %w", refund.PaymentID, err)
+		}
+
+		remaining := payment.Amount - refunded
+		if refund.Amount == 0 {
+			refund.Amount = remaining
+		}
+		if refund.Amount <= 0 || refund.Amount > remaining {
+			return fmt.Errorf("refund of %d with %d remaining: %w", refund.Amount, remaining, domain.ErrRefundExceedsRemaining)
+		}
+
+		id := uuid.New()
+		refund.ID = id.String()
+		refund.Currency = payment.Currency
+		refund.Status = domain.RefundStatusPending
+
+		return q.InsertRefund(ctx, orm.InsertRefundParams{
+			ID:        id,
+			PaymentID: paymentID,
+			Amount:    refund.Amount,
+			Currency:  refund.Currency,
+			Reason:    utils.StringToNull(refund.Reason),
+			Status:    refund.Status,
+		})
+	})
+}
+
+// UpdateRefund stores the Stripe outcome of a refund, posts it to the ledger once it
+// succeeded, and moves the payment to refunded or partially_refunded based on the
+// refunds that are still standing. A refund that left pending never goes back to it,
+// since the refund workflow and the webhooks may report its status in any order.
+//
+// A refund can settle after its payment was returned or disputed; the refund is still
+// stored and posted, but the payment keeps the status the return or dispute gave it.
+func (r *postgresRepo) UpdateRefund(ctx context.Context, refund *domain.Refund) error {
+	id, err := uuid.Parse(refund.ID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	return r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		q := r.tx.WithQtx(ctx)
+
+		dbRefund, err := q.GetRefundByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get refund %s: %w", refund.ID, err)
+		}
+
+		payment, err := q.GetPaymentByIDForUpdate(ctx, dbRefund.PaymentID)
+		if err != nil {
+			return fmt.Errorf("failed to lock payment %s: %w", dbRefund.PaymentID, err)
+		}
+
+		if refund.Status == domain.RefundStatusPending && dbRefund.Status != domain.RefundStatusPending {
+			refund.Status = dbRefund.Status
+			refund.FailureReason = utils.NullStringToStr(dbRefund.FailureReason)
+		}
+		if refund.StripeRefundID == "" {
+			refund.StripeRefundID = utils.NullStringToStr(dbRefund.StripeRefundID)
+		}
+
+		err = q.UpdateRefundResult(ctx, orm.UpdateRefundResultParams{
+			ID:             id,
+			StripeRefundID: utils.StringToNull(refund.StripeRefundID),
+			Status:         refund.Status,
+			FailureReason:  utils.StringToNull(refund.FailureReason),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update refund %s: %w", refund.ID, err)
+		}
+
+		posted := toDomainRefund(dbRefund)
+		posted.Status = refund.Status
+		if err := postRefund(ctx, q, payment, posted, dbRefund.Status); err != nil {
+			return err
+		}
+
+		refunded, err := q.GetRefundedAmountByPaymentID(ctx, payment.ID)
+		if err != nil {
+			return fmt.Errorf("failed to sum refunds for payment %s: %w", payment.ID, err)
+		}
+
+		disputes, err := q.GetDisputesByPaymentID(ctx, payment.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get disputes for payment %s: %w", payment.ID, err)
+		}
+
+		status := refundedPaymentStatus(payment, refunded, lostDispute(disputes))
+		return transitionPayment(ctx, q, payment, status, "refund "+refund.ID+" "+refund.Status)
+	})
+}
+
+func (r *postgresRepo) GetRefundByID(ctx context.Context, refundID string) (*domain.Refund, error) {
+	id, err := uuid.Parse(refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := r.tx.WithQtx(ctx)
+	dbRefund, err := q.GetRefundByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return toDomainRefund(dbRefund), nil
+}
+
+func (r *postgresRepo) GetRefundByStripeRefundID(ctx context.Context, stripeRefundID string) (*domain.Refund, error) {
+	dbRefund, err := r.tx.WithQtx(ctx).GetRefundByStripeRefundID(ctx, utils.StringToNull(stripeRefundID))
+	if err != nil {
+		return nil, err
+	}
+	return toDomainRefund(dbRefund), nil
+}
+
+func (r *postgresRepo) GetRefundsByPaymentID(ctx context.Context, paymentID string) ([]*domain.Refund, error) {
+	id, err := uuid.Parse(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := r.tx.WithQtx(ctx)
+	dbRefunds, err := q.GetRefundsByPaymentID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunds for payment %s: %w", paymentID, err)
+	}
+
+	refunds := make([]*domain.Refund, 0, len(dbRefunds))
+	for _, dbRefund := range dbRefunds {
+		refunds = append(refunds, toDomainRefund(dbRefund))
+	}
+	return refunds, nil
+}
+
+func toDomainRefund(r *orm.Refund) *domain.Refund {
+	return &domain.Refund{
+		ID:             r.ID.String(),
+		PaymentID:      r.PaymentID.String(),
+		Amount:         r.Amount,
+		Currency:       r.Currency,
+		Reason:         utils.NullStringToStr(r.Reason),
+		StripeRefundID: utils.NullStringToStr(r.StripeRefundID),
+		Status:         r.Status,
+		FailureReason:  utils.NullStringToStr(r.FailureReason),
+		CreatedAt:      r.CreatedAt.Time,
+		UpdatedAt:      r.UpdatedAt.Time,
+	}
+}
+
+func isRefundable(payment *orm.Payment) bool {
+	switch domain.PaymentStatus(payment.Status) {
+	case domain.PaymentStatusSucceeded, domain.PaymentStatusPartiallyRefunded:
+		return payment.StripePaymentID.Valid
+	default:
+		return false
+	}
+}
+
+// refundedPaymentStatus is the status refunds totalling refunded give payment. Only a
+// payment that settled and is not returned or disputed takes its status from its refunds;
+// one refunded by a lost dispute stays refunded.
+func refundedPaymentStatus(payment *orm.Payment, refunded int64, disputeLost bool) domain.PaymentStatus {
+	current := domain.PaymentStatus(payment.Status)
+	switch current {
+	case domain.PaymentStatusSucceeded, domain.PaymentStatusPartiallyRefunded:
+	case domain.PaymentStatusRefunded:
+		if disputeLost {
+			return current
+		}
+	default:
+		return current
+	}
+
+	switch {
+	case refunded >= payment.Amount:
+		return domain.PaymentStatusRefunded
+	case refunded > 0:
+		return domain.PaymentStatusPartiallyRefunded
+	case current == domain.PaymentStatusRefunded || current == domain.PaymentStatusPartiallyRefunded:
+		return domain.PaymentStatusSucceeded
+	default:
+		return current
+	}
+}
+
+func lostDispute(disputes []*orm.Dispute) bool {
+	for _, d := range disputes {
+		if d.Status == domain.DisputeStatusLost {
+			return true
+		}
+	}
+	return false
+}
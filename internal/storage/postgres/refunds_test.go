@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+)
+
+func TestRefundedPaymentStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      domain.PaymentStatus
+		refunded    int64
+		disputeLost bool
+		want        domain.PaymentStatus
+	}{
+		{name: "partial refund", status: domain.PaymentStatusSucceeded, refunded: 400, want: domain.PaymentStatusPartiallyRefunded},
+		{name: "full refund", status: domain.PaymentStatusSucceeded, refunded: 1000, want: domain.PaymentStatusRefunded},
+		{name: "rest of a partial refund", status: domain.PaymentStatusPartiallyRefunded, refunded: 1000, want: domain.PaymentStatusRefunded},
+		{name: "only refund failed", status: domain.PaymentStatusPartiallyRefunded, refunded: 0, want: domain.PaymentStatusSucceeded},
+		{name: "full refund failed", status: domain.PaymentStatusRefunded, refunded: 0, want: domain.PaymentStatusSucceeded},
+		{name: "refunded by a lost dispute", status: domain.PaymentStatusRefunded, refunded: 0, disputeLost: true, want: domain.PaymentStatusRefunded},
+		{name: "refund failed, nothing refunded yet", status: domain.PaymentStatusSucceeded, refunded: 0, want: domain.PaymentStatusSucceeded},
+
+		// Returns and disputes own the payment status once they happen
+		{name: "returned", status: domain.PaymentStatusReturned, refunded: 400, want: domain.PaymentStatusReturned},
+		{name: "returned, full refund", status: domain.PaymentStatusReturned, refunded: 1000, want: domain.PaymentStatusReturned},
+		{name: "disputed", status: domain.PaymentStatusDisputed, refunded: 400, want: domain.PaymentStatusDisputed},
+		{name: "disputed, full refund", status: domain.PaymentStatusDisputed, refunded: 1000, want: domain.PaymentStatusDisputed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := &orm.Payment{Amount: 1000, Status: string(tt.status)}
+			if got := refundedPaymentStatus(payment, tt.refunded, tt.disputeLost); got != tt.want {
+				t.Fatalf("refundedPaymentStatus(%s, %d) = %s, want %s", tt.status, tt.refunded, got, tt.want)
+			}
+		})
+	}
+}
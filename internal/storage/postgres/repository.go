@@ -3,7 +3,9 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/GalaDe/payments-service/internal/domain"
 	orm "github.com/GalaDe/payments-service/internal/sqlc"
@@ -12,40 +14,63 @@ import (
 )
 
 type postgresRepo struct {
-	tx *PostgresTransactor
+	tx     *PostgresTransactor
+	tokens *TokenKeyring // encrypts Plaid access tokens at rest
 }
 
-func NewPostgresRepo(tx *PostgresTransactor) domain.Repository {
-	return &postgresRepo{tx}
-}
-
-func (p *postgresRepo) StorePlaidToken(ctx context.Context, token domain.PlaidToken) error {
-	q := p.tx.WithQtx(ctx)
-	return q.UpsertPlaidToken(ctx, orm.UpsertPlaidTokenParams{
-		UserID:      token.UserID,
-		AccessToken: token.AccessToken,
-		AccountID:   token.AccountID,
-		ItemID:      token.ItemID,
-	})
+func NewPostgresRepo(tx *PostgresTransactor, tokens *TokenKeyring) domain.Repository {
+	return &postgresRepo{tx, tokens}
 }
 
+// GetPlaidToken returns the credentials for the user's default funding account.
 func (p *postgresRepo) GetPlaidToken(ctx context.Context, userID string) (*domain.PlaidToken, error) {
 	q := p.tx.WithQtx(ctx)
-	dbToken, err := q.GetPlaidTokenByUserID(ctx, userID)
+	dbToken, err := q.GetDefaultPlaidTokenByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := p.tokens.decrypt(dbToken.ItemID, encryptedToken{
+		Ciphertext: dbToken.AccessTokenCiphertext,
+		DataKey:    dbToken.AccessTokenDataKey,
+		KeyID:      dbToken.AccessTokenKeyID,
+	})
 	if err != nil {
 		return nil, err
 	}
 	return &domain.PlaidToken{
-		UserID:      userID,
-		AccessToken: dbToken.AccessToken,
-		AccountID:   dbToken.AccountID,
-		ItemID:      dbToken.ItemID,
+		UserID:          userID,
+		AccessToken:     accessToken,
+		AccountID:       dbToken.AccountID,
+		ItemID:          dbToken.ItemID,
+		PaymentMethodID: utils.NullStringToStr(dbToken.StripePaymentMethodID),
 	}, nil
 }
 
-func (p *postgresRepo) DeletePlaidToken(ctx context.Context, userID string) error {
+// GetPlaidTokenForAccount returns the credentials for one of the user's accounts.
+func (p *postgresRepo) GetPlaidTokenForAccount(ctx context.Context, userID, accountID string) (*domain.PlaidToken, error) {
 	q := p.tx.WithQtx(ctx)
-	return q.DeletePlaidToken(ctx, userID)
+	dbToken, err := q.GetPlaidTokenByAccountID(ctx, orm.GetPlaidTokenByAccountIDParams{
+		UserID:    userID,
+		AccountID: accountID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := p.tokens.decrypt(dbToken.ItemID, encryptedToken{
+		Ciphertext: dbToken.AccessTokenCiphertext,
+		DataKey:    dbToken.AccessTokenDataKey,
+		KeyID:      dbToken.AccessTokenKeyID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &domain.PlaidToken{
+		UserID:          userID,
+		AccessToken:     accessToken,
+		AccountID:       dbToken.AccountID,
+		ItemID:          dbToken.ItemID,
+		PaymentMethodID: utils.NullStringToStr(dbToken.StripePaymentMethodID),
+	}, nil
 }
 
 // TODO: Revise SqlToNullString conversion, I belive it can be done better
@@ -71,6 +96,17 @@ func (r *postgresRepo) GetStripeCustomerByUserID(ctx context.Context, userID str
 	}, nil
 }
 
+// ClearStripeCustomerDefaultPayment forgets the customer's default payment method
+// if it is still paymentMethodID, so the next payment sets up a new one.
+func (r *postgresRepo) ClearStripeCustomerDefaultPayment(ctx context.Context, userID, paymentMethodID string) error {
+	q := r.tx.WithQtx(ctx)
+
+	return q.ClearStripeCustomerDefaultPayment(ctx, orm.ClearStripeCustomerDefaultPaymentParams{
+		UserID:           userID,
+		DefaultPaymentID: utils.StringToNull(paymentMethodID),
+	})
+}
+
 // TODO: Revise NullStringToSQL conversion, I belive it can be done better
 func (r *postgresRepo) InsertStripeCustomer(ctx context.Context, customer *domain.StripeCustomer) error {
 	q := r.tx.WithQtx(ctx)
@@ -87,31 +123,121 @@ func (r *postgresRepo) InsertStripeCustomer(ctx context.Context, customer *domai
 	})
 }
 
+// InsertPayment stores a new payment together with the first entry of its status
+// history. If payment.ID is empty a new UUID is generated and written back so the
+// caller can reference the row. Inserting an existing ID is a no-op.
 func (r *postgresRepo) InsertPayment(ctx context.Context, payment *domain.Payment) error {
+	id := uuid.New()
+	if payment.ID != "" {
+		parsed, err := uuid.Parse(payment.ID)
+		if err != nil {
+			return fmt.Errorf("invalid UUID: %w", err)
+		}
+		id = parsed
+	}
+	payment.ID = id.String()
+
+	attempt := payment.Attempt
+	if attempt == 0 {
+		attempt = 1
+	}
+
+	status := payment.Status
+	if status == "" {
+		status = domain.PaymentStatusCreated
+	}
+	if !status.IsValid() {
+		return fmt.Errorf("payment %s with status %q: %w", payment.ID, status, domain.ErrInvalidPaymentTransition)
+	}
+
+	return r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		q := r.tx.WithQtx(ctx)
+
+		inserted, err := q.InsertPayment(ctx, orm.InsertPaymentParams{
+			ID:                 id,
+			UserID:             payment.UserID,
+			Amount:             payment.Amount,
+			Currency:           payment.Currency,
+			PlaidAccountID:     utils.StringToNull(payment.PlaidAccountID),
+			PlaidItemID:        utils.StringToNull(payment.PlaidItemID),
+			StripeCustomerID:   utils.StringToNull(payment.StripeCustomerID),
+			StripePaymentID:    utils.StringToNull(payment.StripePaymentID),
+			Status:             string(status),
+			WorkflowID:         utils.StringToNull(payment.WorkflowID),
+			RetryOfPaymentID:   toNullUUID(payment.RetryOfPaymentID),
+			Attempt:            attempt,
+			RecurringPaymentID: toNullUUID(payment.RecurringPaymentID),
+			ExecuteAt:          toNullTime(payment.ExecuteAt),
+		})
+		if err != nil || inserted == 0 {
+			return err
+		}
+
+		_, err = q.InsertPaymentStatusHistory(ctx, orm.InsertPaymentStatusHistoryParams{
+			PaymentID: id,
+			ToStatus:  string(status),
+			Reason:    "payment created",
+		})
+		return err
+	})
+}
+
+func (r *postgresRepo) UpdatePaymentStripeID(ctx context.Context, paymentID, stripePaymentID, paymentMethodID string) error {
+	id, err := uuid.Parse(paymentID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	q := r.tx.WithQtx(ctx)
+	return q.UpdatePaymentStripeID(ctx, orm.UpdatePaymentStripeIDParams{
+		ID:              id,
+		StripePaymentID: utils.StringToNull(stripePaymentID),
+		PaymentMethodID: utils.StringToNull(paymentMethodID),
+	})
+}
+
+// UpdatePaymentExecuteAt moves the date a scheduled payment is charged on.
+func (r *postgresRepo) UpdatePaymentExecuteAt(ctx context.Context, paymentID string, executeAt time.Time) error {
+	id, err := uuid.Parse(paymentID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+
 	q := r.tx.WithQtx(ctx)
+	return q.UpdatePaymentExecuteAt(ctx, orm.UpdatePaymentExecuteAtParams{
+		ID:        id,
+		ExecuteAt: toNullTime(&executeAt),
+	})
+}
+
+// UpdatePaymentExpectedSettlement records when the payment's ACH debit should settle.
+func (r *postgresRepo) UpdatePaymentExpectedSettlement(ctx context.Context, paymentID string, expectedAt time.Time) error {
+	id, err := uuid.Parse(paymentID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
 
-	return q.InsertPayment(ctx, orm.InsertPaymentParams{
-		UserID:           payment.UserID,
-		Amount:           payment.Amount,
-		Currency:         payment.Currency,
-		PlaidAccountID:   utils.StringToNull(payment.PlaidAccountID),
-		PlaidItemID:      utils.StringToNull(payment.PlaidItemID),
-		StripeCustomerID: utils.StringToNull(payment.StripeCustomerID),
-		StripePaymentID:  utils.StringToNull(payment.StripePaymentID),
-		Status:           payment.Status,
+	q := r.tx.WithQtx(ctx)
+	return q.UpdatePaymentExpectedSettlement(ctx, orm.UpdatePaymentExpectedSettlementParams{
+		ID:                   id,
+		ExpectedSettlementAt: toNullTime(&expectedAt),
 	})
 }
 
-func (r *postgresRepo) UpdatePaymentStatus(ctx context.Context, paymentID, status string) error {
+// UpdatePaymentBalanceCheck records the balance snapshot taken before charging the payment.
+func (r *postgresRepo) UpdatePaymentBalanceCheck(ctx context.Context, paymentID string, check *domain.BalanceCheck) error {
 	id, err := uuid.Parse(paymentID)
 	if err != nil {
 		return fmt.Errorf("invalid UUID: %w", err)
 	}
 
 	q := r.tx.WithQtx(ctx)
-	return q.UpdatePaymentStatus(ctx, orm.UpdatePaymentStatusParams{
-		ID:     id,
-		Status: status,
+	return q.UpdatePaymentBalanceCheck(ctx, orm.UpdatePaymentBalanceCheckParams{
+		ID:                 id,
+		BalanceCheckResult: utils.StringToNull(check.Result),
+		BalanceAvailable:   sql.NullInt64{Int64: check.Available, Valid: true},
+		BalanceRequired:    sql.NullInt64{Int64: check.Required, Valid: true},
+		BalanceCheckedAt:   toNullTime(&check.CheckedAt),
 	})
 }
 
@@ -127,45 +253,123 @@ func (r *postgresRepo) GetPaymentByID(ctx context.Context, paymentID string) (*d
 		return nil, err
 	}
 
-	return &domain.Payment{
-		ID:               dbPayment.ID.String(),
-		UserID:           dbPayment.UserID,
-		Amount:           dbPayment.Amount,
-		Currency:         dbPayment.Currency,
-		PlaidAccountID:   dbPayment.PlaidAccountID.String,
-		PlaidItemID:      dbPayment.PlaidItemID.String,
-		StripeCustomerID: dbPayment.StripeCustomerID.String,
-		StripePaymentID:  dbPayment.StripePaymentID.String,
-		Status:           dbPayment.Status,
-		CreatedAt:        dbPayment.CreatedAt.Time,
-		UpdatedAt:        dbPayment.UpdatedAt.Time,
-	}, nil
+	return toDomainPayment(dbPayment), nil
 }
 
-func (r *postgresRepo) GetAllPayments(ctx context.Context) ([]*domain.Payment, error) {
+// GetPaymentByStripePaymentID looks up a payment by the Stripe PaymentIntent
+// (or legacy charge) ID attached to it.
+func (r *postgresRepo) GetPaymentByStripePaymentID(ctx context.Context, stripePaymentID string) (*domain.Payment, error) {
 	q := r.tx.WithQtx(ctx)
 
-	dbPayments, err := q.GetAllPayments(ctx)
+	dbPayment, err := q.GetPaymentByStripePaymentID(ctx, utils.StringToNull(stripePaymentID))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get payments: %w", err)
-	}
-
-	payments := make([]*domain.Payment, 0, len(dbPayments))
-	for _, p := range dbPayments {
-		payments = append(payments, &domain.Payment{
-			ID:               p.ID.String(),
-			UserID:           p.UserID,
-			Amount:           p.Amount,
-			Currency:         p.Currency,
-			PlaidAccountID:   utils.NullStringToStr(p.PlaidAccountID),
-			PlaidItemID:      utils.NullStringToStr(p.PlaidItemID),
-			StripeCustomerID: utils.NullStringToStr(p.StripeCustomerID),
-			StripePaymentID:  utils.NullStringToStr(p.StripePaymentID),
-			Status:           p.Status,
-			CreatedAt:        p.CreatedAt.Time,
-			UpdatedAt:        p.UpdatedAt.Time,
-		})
+		return nil, err
+	}
+
+	return toDomainPayment(dbPayment), nil
+}
+
+/*
+ListPayments returns up to limit payments matching filter, newest first, starting after the
+cursor or from the newest payment without one. Next is set when more payments follow.
+*/
+func (r *postgresRepo) ListPayments(ctx context.Context, filter domain.PaymentFilter, after *domain.PaymentCursor, limit int32) (*domain.PaymentPage, error) {
+	params := orm.ListPaymentsParams{
+		UserID:           filter.UserID,
+		Statuses:         paymentStatusStrings(filter.Statuses),
+		MinAmount:        toNullInt64(filter.MinAmount),
+		MaxAmount:        toNullInt64(filter.MaxAmount),
+		Currency:         filter.Currency,
+		CreatedFrom:      toNullTime(filter.CreatedFrom),
+		CreatedTo:        toNullTime(filter.CreatedTo),
+		StripePaymentID:  filter.StripePaymentID,
+		StripeCustomerID: filter.StripeCustomerID,
+		// One more than asked for tells whether there is a next page
+		Limit: limit + 1,
+	}
+	if after != nil {
+		id, err := uuid.Parse(after.ID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UUID: %w", err)
+		}
+		params.CursorCreatedAt = sql.NullTime{Time: after.CreatedAt.UTC(), Valid: true}
+		params.CursorID = id
+	}
+
+	dbPayments, err := r.tx.WithQtx(ctx).ListPayments(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+
+	page := &domain.PaymentPage{}
+	if len(dbPayments) > int(limit) {
+		dbPayments = dbPayments[:limit]
+		last := dbPayments[len(dbPayments)-1]
+		page.Next = &domain.PaymentCursor{CreatedAt: last.CreatedAt.Time, ID: last.ID.String()}
+	}
+	page.Payments = toDomainPayments(dbPayments)
+	return page, nil
+}
+
+func (r *postgresRepo) CountPayments(ctx context.Context, filter domain.PaymentFilter) (int64, error) {
+	count, err := r.tx.WithQtx(ctx).CountPayments(ctx, orm.CountPaymentsParams{
+		UserID:           filter.UserID,
+		Statuses:         paymentStatusStrings(filter.Statuses),
+		MinAmount:        toNullInt64(filter.MinAmount),
+		MaxAmount:        toNullInt64(filter.MaxAmount),
+		Currency:         filter.Currency,
+		CreatedFrom:      toNullTime(filter.CreatedFrom),
+		CreatedTo:        toNullTime(filter.CreatedTo),
+		StripePaymentID:  filter.StripePaymentID,
+		StripeCustomerID: filter.StripeCustomerID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count payments: %w", err)
+	}
+	return count, nil
+}
+
+func paymentStatusStrings(statuses []domain.PaymentStatus) []string {
+	out := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		out = append(out, string(s))
 	}
+	return out
+}
 
-	return payments, nil
+func toDomainPayment(p *orm.Payment) *domain.Payment {
+	return &domain.Payment{
+		ID:                   p.ID.String(),
+		UserID:               p.UserID,
+		Amount:               p.Amount,
+		Currency:             p.Currency,
+		PlaidAccountID:       utils.NullStringToStr(p.PlaidAccountID),
+		PlaidItemID:          utils.NullStringToStr(p.PlaidItemID),
+		StripeCustomerID:     utils.NullStringToStr(p.StripeCustomerID),
+		StripePaymentID:      utils.NullStringToStr(p.StripePaymentID),
+		Status:               domain.PaymentStatus(p.Status),
+		WorkflowID:           utils.NullStringToStr(p.WorkflowID),
+		PaymentMethodID:      utils.NullStringToStr(p.PaymentMethodID),
+		RetryOfPaymentID:     fromNullUUID(p.RetryOfPaymentID),
+		Attempt:              p.Attempt,
+		RecurringPaymentID:   fromNullUUID(p.RecurringPaymentID),
+		ExecuteAt:            fromNullTime(p.ExecuteAt),
+		ExpectedSettlementAt: fromNullTime(p.ExpectedSettlementAt),
+		BalanceCheck:         toDomainBalanceCheck(p),
+		DisputeStatus:        utils.NullStringToStr(p.DisputeStatus),
+		CreatedAt:            p.CreatedAt.Time,
+		UpdatedAt:            p.UpdatedAt.Time,
+	}
+}
+
+func toDomainBalanceCheck(p *orm.Payment) *domain.BalanceCheck {
+	if !p.BalanceCheckResult.Valid {
+		return nil
+	}
+	return &domain.BalanceCheck{
+		Result:    p.BalanceCheckResult.String,
+		Available: p.BalanceAvailable.Int64,
+		Required:  p.BalanceRequired.Int64,
+		CheckedAt: p.BalanceCheckedAt.Time,
+	}
 }
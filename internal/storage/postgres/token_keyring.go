@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const dataKeySize = 32 // AES-256
+
+var ErrUnknownTokenKey = errors.New("unknown token encryption key")
+
+/*
+TokenKeyring envelope-encrypts Plaid access tokens before they are written to plaid_items.
+
+	1. Every token is sealed with AES-GCM under its own random data key
+	2. The data key is sealed with AES-GCM under a key-encryption key (KEK) from config
+	3. The KEK's ID is stored next to the ciphertext, so rows sealed under older KEKs
+	   stay readable while the keyring still holds those keys
+
+Rotating to a new KEK only re-wraps the data keys (see TokenKeyRotator); the token
+ciphertexts never change. Both ciphertexts are bound to the item ID, so a row's
+values can't be swapped onto another item.
+*/
+type TokenKeyring struct {
+	activeID string
+	keys     map[string]cipher.AEAD
+}
+
+// encryptedToken is an access token as stored in plaid_items.
+type encryptedToken struct {
+	Ciphertext []byte // nonce || AES-GCM(data key, token)
+	DataKey    []byte // nonce || AES-GCM(KEK, data key)
+	KeyID      string // KEK the data key is wrapped with
+}
+
+// NewTokenKeyring builds a keyring from 32-byte KEKs by ID. New tokens are sealed under activeID.
+func NewTokenKeyring(keys map[string][]byte, activeID string) (*TokenKeyring, error) {
+	k := &TokenKeyring{
+		activeID: activeID,
+		keys:     make(map[string]cipher.AEAD, len(keys)),
+	}
+	for id, key := range keys {
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("token key %q must be %d bytes, got %d", id, dataKeySize, len(key))
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, fmt.Errorf("token key %q: %w", id, err)
+		}
+		k.keys[id] = aead
+	}
+	if _, ok := k.keys[activeID]; !ok {
+		return nil, fmt.Errorf("active token key %q: %w", activeID, ErrUnknownTokenKey)
+	}
+	return k, nil
+}
+
+// ParseTokenKeys parses a "id:base64key,id:base64key" list, as in PLAID_TOKEN_KEYS.
+func ParseTokenKeys(spec string) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid token key entry %q, expected id:base64key", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("token key %q is not valid base64: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return keys, nil
+}
+
+// ActiveKeyID is the KEK new tokens are sealed under.
+func (k *TokenKeyring) ActiveKeyID() string {
+	return k.activeID
+}
+
+func (k *TokenKeyring) encrypt(itemID, token string) (*encryptedToken, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	tokenAEAD, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := seal(tokenAEAD, []byte(token), []byte(itemID))
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := k.wrap(itemID, dataKey, k.activeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptedToken{
+		Ciphertext: ciphertext,
+		DataKey:    wrapped,
+		KeyID:      k.activeID,
+	}, nil
+}
+
+func (k *TokenKeyring) decrypt(itemID string, token encryptedToken) (string, error) {
+	dataKey, err := k.unwrap(itemID, token.DataKey, token.KeyID)
+	if err != nil {
+		return "", err
+	}
+
+	tokenAEAD, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(tokenAEAD, token.Ciphertext, []byte(itemID))
+	if err != nil {
+		return "", fmt.Errorf("decrypt access token for item %s: %w", itemID, err)
+	}
+	return string(plaintext), nil
+}
+
+// rewrap re-seals a data key sealed under keyID with the active KEK.
+func (k *TokenKeyring) rewrap(itemID string, wrapped []byte, keyID string) ([]byte, error) {
+	dataKey, err := k.unwrap(itemID, wrapped, keyID)
+	if err != nil {
+		return nil, err
+	}
+	return k.wrap(itemID, dataKey, k.activeID)
+}
+
+func (k *TokenKeyring) wrap(itemID string, dataKey []byte, keyID string) ([]byte, error) {
+	kek, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTokenKey, keyID)
+	}
+	return seal(kek, dataKey, dataKeyAAD(itemID, keyID))
+}
+
+func (k *TokenKeyring) unwrap(itemID string, wrapped []byte, keyID string) ([]byte, error) {
+	kek, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTokenKey, keyID)
+	}
+	dataKey, err := open(kek, wrapped, dataKeyAAD(itemID, keyID))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key for item %s: %w", itemID, err)
+	}
+	return dataKey, nil
+}
+
+func dataKeyAAD(itemID, keyID string) []byte {
+	return []byte(keyID + "/" + itemID)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
@@ -0,0 +1,201 @@
+package postgres
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func newTestKey(t *testing.T) []byte {
+	t.Helper()
+
+	key := make([]byte, dataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+func newTestKeyring(t *testing.T, keys map[string][]byte, activeID string) *TokenKeyring {
+	t.Helper()
+
+	keyring, err := NewTokenKeyring(keys, activeID)
+	if err != nil {
+		t.Fatalf("NewTokenKeyring: %v", err)
+	}
+	return keyring
+}
+
+func TestTokenKeyringRoundTrip(t *testing.T) {
+	keyring := newTestKeyring(t, map[string][]byte{"v1": newTestKey(t)}, "v1")
+
+	for _, token := range []string{"access-sandbox-8ab976e6-64bc-4b38-98f7-731e7a349970", ""} {
+		sealed, err := keyring.encrypt("item-1", token)
+		if err != nil {
+			t.Fatalf("encrypt: %v", err)
+		}
+		if sealed.KeyID != "v1" {
+			t.Fatalf("KeyID = %q, want v1", sealed.KeyID)
+		}
+		if token != "" && bytes.Contains(sealed.Ciphertext, []byte(token)) {
+			t.Fatal("ciphertext contains the plaintext token")
+		}
+
+		got, err := keyring.decrypt("item-1", *sealed)
+		if err != nil {
+			t.Fatalf("decrypt: %v", err)
+		}
+		if got != token {
+			t.Fatalf("decrypt = %q, want %q", got, token)
+		}
+	}
+}
+
+func TestTokenKeyringEncryptUsesFreshDataKeys(t *testing.T) {
+	keyring := newTestKeyring(t, map[string][]byte{"v1": newTestKey(t)}, "v1")
+
+	a, err := keyring.encrypt("item-1", "access-token")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	b, err := keyring.encrypt("item-1", "access-token")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if bytes.Equal(a.Ciphertext, b.Ciphertext) || bytes.Equal(a.DataKey, b.DataKey) {
+		t.Fatal("encrypting the same token twice gave the same ciphertext")
+	}
+}
+
+func TestTokenKeyringDecryptFails(t *testing.T) {
+	keyring := newTestKeyring(t, map[string][]byte{"v1": newTestKey(t)}, "v1")
+	sealed, err := keyring.encrypt("item-1", "access-token")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	flip := func(b []byte) []byte {
+		tampered := bytes.Clone(b)
+		tampered[len(tampered)-1] ^= 0x01
+		return tampered
+	}
+
+	tests := []struct {
+		name    string
+		keyring *TokenKeyring
+		itemID  string
+		token   encryptedToken
+		wantErr error
+	}{
+		{
+			name:    "wrong key under the same ID",
+			keyring: newTestKeyring(t, map[string][]byte{"v1": newTestKey(t)}, "v1"),
+			itemID:  "item-1",
+			token:   *sealed,
+		},
+		{
+			name:    "unknown key ID",
+			keyring: newTestKeyring(t, map[string][]byte{"v2": newTestKey(t)}, "v2"),
+			itemID:  "item-1",
+			token:   *sealed,
+			wantErr: ErrUnknownTokenKey,
+		},
+		{
+			name:    "tampered ciphertext",
+			keyring: keyring,
+			itemID:  "item-1",
+			token:   encryptedToken{Ciphertext: flip(sealed.Ciphertext), DataKey: sealed.DataKey, KeyID: sealed.KeyID},
+		},
+		{
+			name:    "tampered data key",
+			keyring: keyring,
+			itemID:  "item-1",
+			token:   encryptedToken{Ciphertext: sealed.Ciphertext, DataKey: flip(sealed.DataKey), KeyID: sealed.KeyID},
+		},
+		{
+			name:    "truncated ciphertext",
+			keyring: keyring,
+			itemID:  "item-1",
+			token:   encryptedToken{Ciphertext: sealed.Ciphertext[:4], DataKey: sealed.DataKey, KeyID: sealed.KeyID},
+		},
+		{
+			name:    "row moved to another item",
+			keyring: keyring,
+			itemID:  "item-2",
+			token:   *sealed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.keyring.decrypt(tt.itemID, tt.token)
+			if err == nil {
+				t.Fatal("decrypt succeeded, want an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("decrypt error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTokenKeyring(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     map[string][]byte
+		activeID string
+		wantErr  bool
+	}{
+		{name: "valid", keys: map[string][]byte{"v1": make([]byte, 32)}, activeID: "v1"},
+		{name: "short key", keys: map[string][]byte{"v1": make([]byte, 16)}, activeID: "v1", wantErr: true},
+		{name: "active key missing", keys: map[string][]byte{"v1": make([]byte, 32)}, activeID: "v2", wantErr: true},
+		{name: "no keys", keys: map[string][]byte{}, activeID: "v1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTokenKeyring(tt.keys, tt.activeID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTokenKeyring error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseTokenKeys(t *testing.T) {
+	key1, key2 := newTestKey(t), newTestKey(t)
+	enc := base64.StdEncoding.EncodeToString
+
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string][]byte
+		wantErr bool
+	}{
+		{name: "single", spec: "v1:" + enc(key1), want: map[string][]byte{"v1": key1}},
+		{name: "several with spaces", spec: " v1:" + enc(key1) + " , v2:" + enc(key2) + ",", want: map[string][]byte{"v1": key1, "v2": key2}},
+		{name: "empty", spec: "", want: map[string][]byte{}},
+		{name: "missing id", spec: ":" + enc(key1), wantErr: true},
+		{name: "missing separator", spec: enc(key1), wantErr: true},
+		{name: "bad base64", spec: "v1:not base64!", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTokenKeys(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTokenKeys error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseTokenKeys returned %d keys, want %d", len(got), len(tt.want))
+			}
+			for id, key := range tt.want {
+				if !bytes.Equal(got[id], key) {
+					t.Fatalf("key %q = %x, want %x", id, got[id], key)
+				}
+			}
+		})
+	}
+}
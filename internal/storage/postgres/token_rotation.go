@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+)
+
+// TokenKeyRotator re-wraps the data keys of stored Plaid access tokens under the
+// keyring's active key-encryption key.
+type TokenKeyRotator struct {
+	tx      *PostgresTransactor
+	keyring *TokenKeyring
+}
+
+func NewTokenKeyRotator(tx *PostgresTransactor, keyring *TokenKeyring) *TokenKeyRotator {
+	return &TokenKeyRotator{tx: tx, keyring: keyring}
+}
+
+/*
+Rotate re-wraps every row not yet under the active key and returns how many it re-wrapped.
+
+Each batch runs in its own short transaction and locks only the rows it rewrites
+(FOR UPDATE SKIP LOCKED), so the service keeps reading and linking items while it runs.
+Rows locked by a concurrent relink are skipped; running Rotate again picks them up.
+
+Every running instance must already hold the new key (and still hold the old one)
+before rotating; the old key can be dropped from config once Rotate reports 0.
+*/
+func (r *TokenKeyRotator) Rotate(ctx context.Context, batchSize int32) (int, error) {
+	activeID := r.keyring.ActiveKeyID()
+	total := 0
+
+	for {
+		rotated := 0
+		err := r.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			q := r.tx.WithQtx(ctx)
+
+			rows, err := q.ListPlaidItemsForKeyRotation(ctx, orm.ListPlaidItemsForKeyRotationParams{
+				AccessTokenKeyID: activeID,
+				Limit:            batchSize,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to list plaid items to rotate: %w", err)
+			}
+
+			for _, row := range rows {
+				wrapped, err := r.keyring.rewrap(row.ItemID, row.AccessTokenDataKey, row.AccessTokenKeyID)
+				if err != nil {
+					return err
+				}
+				err = q.RewrapPlaidItemDataKey(ctx, orm.RewrapPlaidItemDataKeyParams{
+					ItemID:             row.ItemID,
+					AccessTokenDataKey: wrapped,
+					AccessTokenKeyID:   activeID,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to rewrap plaid item %s: %w", row.ItemID, err)
+				}
+			}
+			rotated = len(rows)
+			return nil
+		})
+		if err != nil {
+			return total, err
+		}
+
+		total += rotated
+		if rotated < int(batchSize) {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// rotateRow re-wraps a stored token the way TokenKeyRotator.Rotate rewrites a plaid_items row.
+func rotateRow(t *testing.T, keyring *TokenKeyring, itemID string, token *encryptedToken) {
+	t.Helper()
+
+	wrapped, err := keyring.rewrap(itemID, token.DataKey, token.KeyID)
+	if err != nil {
+		t.Fatalf("rewrap: %v", err)
+	}
+	token.DataKey = wrapped
+	token.KeyID = keyring.ActiveKeyID()
+}
+
+func TestTokenKeyRotation(t *testing.T) {
+	v1, v2 := newTestKey(t), newTestKey(t)
+	before := newTestKeyring(t, map[string][]byte{"v1": v1}, "v1")
+	during := newTestKeyring(t, map[string][]byte{"v1": v1, "v2": v2}, "v2")
+	after := newTestKeyring(t, map[string][]byte{"v2": v2}, "v2")
+
+	rotated, err := before.encrypt("item-1", "access-token-1")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	pending, err := before.encrypt("item-2", "access-token-2")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	ciphertext := rotated.Ciphertext
+
+	rotateRow(t, during, "item-1", rotated)
+	if rotated.KeyID != "v2" {
+		t.Fatalf("KeyID after rotation = %q, want v2", rotated.KeyID)
+	}
+	if !bytes.Equal(rotated.Ciphertext, ciphertext) {
+		t.Fatal("rotation changed the token ciphertext")
+	}
+
+	// Rows not rotated yet still decrypt under the retired key while the keyring holds it
+	tests := []struct {
+		name    string
+		keyring *TokenKeyring
+		itemID  string
+		token   *encryptedToken
+		want    string
+		wantErr error
+	}{
+		{name: "rotated row, both keys", keyring: during, itemID: "item-1", token: rotated, want: "access-token-1"},
+		{name: "rotated row, retired key dropped", keyring: after, itemID: "item-1", token: rotated, want: "access-token-1"},
+		{name: "pending row, retired key still held", keyring: during, itemID: "item-2", token: pending, want: "access-token-2"},
+		{name: "pending row, retired key dropped", keyring: after, itemID: "item-2", token: pending, wantErr: ErrUnknownTokenKey},
+		{name: "rotated row, only the retired key", keyring: before, itemID: "item-1", token: rotated, wantErr: ErrUnknownTokenKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.keyring.decrypt(tt.itemID, *tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("decrypt error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("decrypt = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenKeyRewrapIsIdempotent(t *testing.T) {
+	v1, v2 := newTestKey(t), newTestKey(t)
+	keyring := newTestKeyring(t, map[string][]byte{"v1": v1, "v2": v2}, "v2")
+	old := newTestKeyring(t, map[string][]byte{"v1": v1}, "v1")
+
+	token, err := old.encrypt("item-1", "access-token")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	// A row re-wrapped twice, e.g. by two Rotate runs, still holds the same data key
+	for i := 0; i < 3; i++ {
+		rotateRow(t, keyring, "item-1", token)
+
+		got, err := keyring.decrypt("item-1", *token)
+		if err != nil {
+			t.Fatalf("decrypt after rewrap %d: %v", i+1, err)
+		}
+		if got != "access-token" {
+			t.Fatalf("decrypt after rewrap %d = %q, want access-token", i+1, got)
+		}
+		if token.KeyID != "v2" {
+			t.Fatalf("KeyID after rewrap %d = %q, want v2", i+1, token.KeyID)
+		}
+	}
+}
+
+func TestTokenKeyRewrapRejectsAnotherItem(t *testing.T) {
+	keyring := newTestKeyring(t, map[string][]byte{"v1": newTestKey(t), "v2": newTestKey(t)}, "v1")
+
+	token, err := keyring.encrypt("item-1", "access-token")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if _, err := keyring.rewrap("item-2", token.DataKey, token.KeyID); err == nil {
+		t.Fatal("rewrap under another item ID succeeded, want an error")
+	}
+}
@@ -44,12 +44,18 @@ func (p *PostgresTransactor) WithinTransaction(ctx context.Context, txFunc func(
 	}
 	// if no err, commit
 	if errCommit := tx.Commit(ctx); errCommit != nil {
-		log.Printf("commit tx: %v", err)
+		return fmt.Errorf("commit tx: %w", errCommit)
 	}
 
 	return nil
 }
 
+// WithQtx returns queries bound to the transaction in ctx, or to the pool
+// when no transaction is running.
 func (p *PostgresTransactor) WithQtx(ctx context.Context) orm.Querier {
-	return p.orm.WithTx(ExtractTx(ctx))
+	tx := ExtractTx(ctx)
+	if tx == nil {
+		return p.orm
+	}
+	return p.orm.WithTx(tx)
 }
@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/GalaDe/payments-service/internal/domain"
+	orm "github.com/GalaDe/payments-service/internal/sqlc"
+	"github.com/GalaDe/payments-service/internal/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// SaveWebhookEvent stores a received webhook, deduplicating on provider and
+// event ID. It reports whether the event was new; for duplicates, event is
+// filled in with the stored copy.
+func (r *postgresRepo) SaveWebhookEvent(ctx context.Context, event *domain.WebhookEvent) (bool, error) {
+	q := r.tx.WithQtx(ctx)
+
+	dbEvent, err := q.InsertWebhookEvent(ctx, orm.InsertWebhookEventParams{
+		Provider:  event.Provider,
+		EventID:   event.EventID,
+		EventType: event.EventType,
+		Payload:   event.Payload,
+	})
+	if err == nil {
+		*event = *toDomainWebhookEvent(dbEvent)
+		return true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("failed to insert %s webhook event %s: %w", event.Provider, event.EventID, err)
+	}
+
+	// ON CONFLICT DO NOTHING returns no row: the event was already received
+	dbEvent, err = q.GetWebhookEventByProviderEventID(ctx, orm.GetWebhookEventByProviderEventIDParams{
+		Provider: event.Provider,
+		EventID:  event.EventID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to get %s webhook event %s: %w", event.Provider, event.EventID, err)
+	}
+	*event = *toDomainWebhookEvent(dbEvent)
+	return false, nil
+}
+
+func (r *postgresRepo) GetWebhookEventByID(ctx context.Context, eventID string) (*domain.WebhookEvent, error) {
+	id, err := uuid.Parse(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := r.tx.WithQtx(ctx)
+	dbEvent, err := q.GetWebhookEventByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toDomainWebhookEvent(dbEvent), nil
+}
+
+func (r *postgresRepo) ListWebhookEvents(ctx context.Context, status string, limit int32) ([]*domain.WebhookEvent, error) {
+	q := r.tx.WithQtx(ctx)
+
+	dbEvents, err := q.ListWebhookEventsByStatus(ctx, orm.ListWebhookEventsByStatusParams{
+		Status: status,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook events: %w", err)
+	}
+
+	events := make([]*domain.WebhookEvent, 0, len(dbEvents))
+	for _, e := range dbEvents {
+		events = append(events, toDomainWebhookEvent(e))
+	}
+	return events, nil
+}
+
+func (r *postgresRepo) MarkWebhookEventProcessing(ctx context.Context, eventID string) error {
+	id, err := uuid.Parse(eventID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+	return r.tx.WithQtx(ctx).MarkWebhookEventProcessing(ctx, id)
+}
+
+func (r *postgresRepo) MarkWebhookEventProcessed(ctx context.Context, eventID string) error {
+	id, err := uuid.Parse(eventID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+	return r.tx.WithQtx(ctx).MarkWebhookEventProcessed(ctx, id)
+}
+
+func (r *postgresRepo) MarkWebhookEventFailed(ctx context.Context, eventID string, reason string) error {
+	id, err := uuid.Parse(eventID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+	return r.tx.WithQtx(ctx).MarkWebhookEventFailed(ctx, orm.MarkWebhookEventFailedParams{
+		ID:        id,
+		LastError: utils.StringToNull(reason),
+	})
+}
+
+func (r *postgresRepo) ResetWebhookEvent(ctx context.Context, eventID string) error {
+	id, err := uuid.Parse(eventID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+	return r.tx.WithQtx(ctx).ResetWebhookEvent(ctx, id)
+}
+
+func toDomainWebhookEvent(e *orm.WebhookEvent) *domain.WebhookEvent {
+	event := &domain.WebhookEvent{
+		ID:         e.ID.String(),
+		Provider:   e.Provider,
+		EventID:    e.EventID,
+		EventType:  e.EventType,
+		Payload:    e.Payload,
+		Status:     e.Status,
+		Attempts:   e.Attempts,
+		LastError:  utils.NullStringToStr(e.LastError),
+		ReceivedAt: e.ReceivedAt.Time,
+	}
+	if e.ProcessedAt.Valid {
+		event.ProcessedAt = &e.ProcessedAt.Time
+	}
+	return event
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,7 +12,9 @@ import (
 	"go.temporal.io/sdk/client"
 	"go.uber.org/zap"
 
+	"github.com/GalaDe/payments-service/internal/auth"
 	config "github.com/GalaDe/payments-service/internal/config"
+	"github.com/GalaDe/payments-service/internal/domain"
 	handler "github.com/GalaDe/payments-service/internal/handlers"
 	plaid "github.com/GalaDe/payments-service/internal/services/plaid"
 	stripe "github.com/GalaDe/payments-service/internal/services/stripe"
@@ -46,8 +49,17 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to connect to DB: %v", err)
 	}
+	tokenKeys, err := repository.ParseTokenKeys(cfg.PlaidTokenKeys)
+	if err != nil {
+		log.Fatalf("invalid PLAID_TOKEN_KEYS: %v", err)
+	}
+	tokenKeyring, err := repository.NewTokenKeyring(tokenKeys, cfg.PlaidTokenActiveKeyID)
+	if err != nil {
+		log.Fatalf("failed to load Plaid token keys: %v", err)
+	}
+
 	transactor := repository.NewPostgresTransactor(db, orm.New(db.Pool))
-	repo := repository.NewPostgresRepo(transactor)
+	repo := repository.NewPostgresRepo(transactor, tokenKeyring)
 
 	defer db.Close()
 
@@ -66,15 +78,50 @@ func main() {
 	workflow.RegisterWorkflows(w)
 
 	// Build repository, services, and handlers
-	stripeConfig := &stripe.StripeConfig{}
+	stripeConfig := &stripe.StripeConfig{
+		AppKey:           cfg.StripeAPIKey,
+		WebhookKey:       cfg.StripeWebhookKey,
+		WebhookTolerance: cfg.StripeWebhookTolerance,
+	}
 	stripeSvc := stripe.NewStripe(stripeConfig)
-	plaidOpt := &plaid.PlaidOpts{}
+	plaidOpt := &plaid.PlaidOpts{
+		ClientID:     cfg.PlaidClientID,
+		ClientSecret: cfg.PlaidSecret,
+		Environment:  cfg.PlaidEnv,
+		KeyStore:     repo,
+	}
 	plaidSvc := plaid.New(plaidOpt)
 
-	activityPort := activity.NewTemporalActivityPort(repo, stripeSvc, plaidSvc, temporalClient)
+	balanceCheck := domain.BalanceCheckPolicy{
+		Mode:   cfg.PaymentBalanceCheck,
+		Buffer: cfg.PaymentBalanceBuffer,
+	}
+	if err := balanceCheck.Validate(); err != nil {
+		log.Fatalf("invalid PAYMENT_BALANCE_CHECK settings: %v", err)
+	}
+
+	activityPort := activity.NewTemporalActivityPort(repo, stripeSvc, plaidSvc, temporalClient, balanceCheck)
 	activityPort.RegisterActivities(w)
 
-	httpHandler := handler.NewHttpServer(logger, temporalClient, repo, plaidSvc, stripeSvc)
+	if err := w.Start(); err != nil {
+		log.Fatalf("unable to start Temporal worker: %v", err)
+	}
+	defer w.Stop()
+
+	if err := workflow.EnsureReconciliationSchedule(context.Background(), temporalClient); err != nil {
+		log.Fatalf("unable to create reconciliation schedule: %v", err)
+	}
+
+	verifier, err := auth.NewJWTVerifier(auth.JWTConfig{
+		Secret:   cfg.JWTSecret,
+		Issuer:   cfg.JWTIssuer,
+		Audience: cfg.JWTAudience,
+	})
+	if err != nil {
+		log.Fatalf("invalid JWT settings: %v", err)
+	}
+
+	httpHandler := handler.NewHttpServer(logger, temporalClient, repo, plaidSvc, stripeSvc, verifier, cfg.PaymentSettlementTimeout)
 
 	// HTTP router
 	r := chi.NewRouter()
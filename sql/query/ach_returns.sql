@@ -0,0 +1,25 @@
+-- name: InsertACHReturn :one
+INSERT INTO ach_returns (
+    id, payment_id, stripe_charge_id, stripe_event_id, return_code,
+    reason, stripe_failure_code, retryable
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (stripe_charge_id) DO NOTHING
+RETURNING *;
+
+-- name: GetACHReturnByID :one
+SELECT * FROM ach_returns WHERE id = $1;
+
+-- name: GetACHReturnByStripeChargeID :one
+SELECT * FROM ach_returns WHERE stripe_charge_id = $1;
+
+-- name: GetACHReturnsByPaymentID :many
+SELECT * FROM ach_returns WHERE payment_id = $1 ORDER BY created_at;
+
+-- name: UpdateACHReturnAction :exec
+UPDATE ach_returns
+SET action = $2,
+    retry_payment_id = $3,
+    updated_at = NOW()
+WHERE id = $1;
@@ -0,0 +1,27 @@
+-- name: InsertAPIKey :one
+INSERT INTO api_keys (
+    id, name, prefix, secret_hash, scopes, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetAPIKeyByID :one
+SELECT * FROM api_keys WHERE id = $1;
+
+-- name: GetAPIKeyByPrefix :one
+SELECT * FROM api_keys WHERE prefix = $1;
+
+-- name: ListAPIKeys :many
+SELECT * FROM api_keys
+ORDER BY created_at DESC;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys
+SET revoked_at = NOW()
+WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute');
@@ -0,0 +1,45 @@
+-- name: UpsertDispute :one
+-- Closed disputes are final, a late event for one returns no row.
+INSERT INTO disputes (
+    id, payment_id, stripe_dispute_id, stripe_charge_id, amount, currency, reason, status, evidence_due_by, stripe_event_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+ON CONFLICT (stripe_dispute_id) DO UPDATE
+SET
+    amount = EXCLUDED.amount,
+    reason = EXCLUDED.reason,
+    status = EXCLUDED.status,
+    evidence_due_by = EXCLUDED.evidence_due_by,
+    stripe_event_id = EXCLUDED.stripe_event_id,
+    updated_at = NOW()
+WHERE disputes.status NOT IN ('won', 'lost', 'warning_closed')
+RETURNING *;
+
+-- name: GetDisputeByID :one
+SELECT * FROM disputes WHERE id = $1;
+
+-- name: GetDisputeByStripeID :one
+SELECT * FROM disputes WHERE stripe_dispute_id = $1;
+
+-- name: GetDisputesByPaymentID :many
+SELECT * FROM disputes WHERE payment_id = $1 ORDER BY created_at DESC;
+
+-- name: ListDisputes :many
+SELECT * FROM disputes
+WHERE (sqlc.arg(status)::text = '' OR status = sqlc.arg(status))
+ORDER BY evidence_due_by NULLS LAST, created_at DESC
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateDisputeEvidence :exec
+UPDATE disputes
+SET
+    status = sqlc.arg(status),
+    evidence_submitted_at = CASE WHEN sqlc.arg(submitted)::boolean THEN NOW() ELSE evidence_submitted_at END,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: MarkDisputeAlerted :exec
+UPDATE disputes
+SET last_alert_at = NOW()
+WHERE id = $1;
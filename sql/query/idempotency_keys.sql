@@ -0,0 +1,19 @@
+-- name: InsertIdempotencyKey :one
+INSERT INTO idempotency_keys (
+    user_id, idempotency_key, request_hash, payment_id
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (user_id, idempotency_key) DO NOTHING
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE user_id = $1 AND idempotency_key = $2;
+
+-- name: SaveIdempotencyKeyResponse :exec
+UPDATE idempotency_keys
+SET response_status = $3,
+    response_body = $4,
+    updated_at = NOW()
+WHERE user_id = $1 AND idempotency_key = $2;
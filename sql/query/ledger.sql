@@ -0,0 +1,48 @@
+-- name: UpsertLedgerAccount :one
+INSERT INTO ledger_accounts (
+    id, code, user_id, currency, normal_balance
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (code, user_id, currency) DO UPDATE SET code = EXCLUDED.code
+RETURNING *;
+
+-- name: GetLedgerAccountByID :one
+SELECT * FROM ledger_accounts WHERE id = $1;
+
+-- name: ListLedgerAccountsByUserID :many
+SELECT * FROM ledger_accounts WHERE user_id = $1 ORDER BY code, currency;
+
+-- name: InsertJournalEntry :execrows
+INSERT INTO journal_entries (
+    id, entry_type, reference, payment_id, currency, description, effective_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (reference) DO NOTHING;
+
+-- name: InsertJournalLine :exec
+INSERT INTO journal_lines (
+    entry_id, account_id, direction, amount
+) VALUES (
+    $1, $2, $3, $4
+);
+
+-- name: GetJournalEntriesByPaymentID :many
+SELECT * FROM journal_entries
+WHERE payment_id = $1
+ORDER BY effective_at, created_at;
+
+-- name: GetJournalLinesByPaymentID :many
+SELECT l.entry_id, l.account_id, a.code, a.user_id, l.direction, l.amount
+FROM journal_lines l
+JOIN journal_entries e ON e.id = l.entry_id
+JOIN ledger_accounts a ON a.id = l.account_id
+WHERE e.payment_id = sqlc.arg(payment_id)::uuid
+ORDER BY l.id;
+
+-- name: GetLedgerAccountBalance :one
+SELECT COALESCE(SUM(CASE WHEN l.direction = 'debit' THEN l.amount ELSE -l.amount END), 0)::bigint AS balance
+FROM journal_lines l
+JOIN journal_entries e ON e.id = l.entry_id
+WHERE l.account_id = sqlc.arg(account_id) AND e.effective_at <= sqlc.arg(as_of);
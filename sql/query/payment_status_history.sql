@@ -0,0 +1,12 @@
+-- name: InsertPaymentStatusHistory :one
+INSERT INTO payment_status_history (
+    payment_id, from_status, to_status, reason
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING id;
+
+-- name: GetPaymentStatusHistory :many
+SELECT * FROM payment_status_history
+WHERE payment_id = $1
+ORDER BY id;
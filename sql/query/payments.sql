@@ -1,16 +1,87 @@
--- name: InsertPayment :exec
+-- name: InsertPayment :execrows
 INSERT INTO payments (
-    user_id, amount, currency, plaid_account_id,
-    plaid_item_id, stripe_customer_id, stripe_payment_id, status
+    id, user_id, amount, currency, plaid_account_id,
+    plaid_item_id, stripe_customer_id, stripe_payment_id, status, workflow_id,
+    retry_of_payment_id, attempt, recurring_payment_id, execute_at
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8
-);
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+)
+ON CONFLICT (id) DO NOTHING;
 
 -- name: UpdatePaymentStatus :exec
 UPDATE payments SET status = $2, updated_at = NOW() WHERE id = $1;
 
+-- name: UpdatePaymentStripeID :exec
+UPDATE payments SET stripe_payment_id = $2, payment_method_id = $3, updated_at = NOW() WHERE id = $1;
+
+-- name: UpdatePaymentExecuteAt :exec
+UPDATE payments SET execute_at = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: UpdatePaymentExpectedSettlement :exec
+UPDATE payments SET expected_settlement_at = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: UpdatePaymentBalanceCheck :exec
+UPDATE payments
+SET balance_check_result = $2,
+    balance_available = $3,
+    balance_required = $4,
+    balance_checked_at = $5,
+    updated_at = NOW()
+WHERE id = $1;
+
 -- name: GetPaymentByID :one
 SELECT * FROM payments WHERE id = $1;
 
--- name: GetAllPayments :many
-SELECT * FROM payments ORDER BY created_at DESC;
+-- name: GetPaymentByStripePaymentID :one
+SELECT * FROM payments WHERE stripe_payment_id = $1;
+
+-- name: GetPaymentByIDForUpdate :one
+SELECT * FROM payments WHERE id = $1 FOR UPDATE;
+
+-- name: ListPaymentsByStripePaymentIDs :many
+SELECT * FROM payments
+WHERE stripe_payment_id = ANY(sqlc.arg(stripe_payment_ids)::text[]);
+
+-- name: ListPaymentsSucceededBetween :many
+SELECT * FROM payments
+WHERE stripe_payment_id IS NOT NULL
+  AND id IN (
+    SELECT h.payment_id FROM payment_status_history h
+    WHERE h.to_status = 'succeeded'
+      AND h.created_at >= sqlc.arg(period_start)::timestamp
+      AND h.created_at < sqlc.arg(period_end)::timestamp
+  );
+
+-- name: UpdatePaymentDisputeStatus :exec
+UPDATE payments
+SET dispute_status = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: ListPayments :many
+-- Empty strings, NULLs and an empty status list don't filter. Pages are ordered newest
+-- first, with the ID breaking ties; the cursor is the last row of the previous page.
+SELECT * FROM payments
+WHERE (sqlc.arg(user_id)::text = '' OR user_id = sqlc.arg(user_id))
+  AND (COALESCE(cardinality(sqlc.arg(statuses)::text[]), 0) = 0 OR status = ANY(sqlc.arg(statuses)::text[]))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR amount <= sqlc.narg(max_amount))
+  AND (sqlc.arg(currency)::text = '' OR currency = sqlc.arg(currency))
+  AND (sqlc.narg(created_from)::timestamp IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamp IS NULL OR created_at < sqlc.narg(created_to))
+  AND (sqlc.arg(stripe_payment_id)::text = '' OR stripe_payment_id = sqlc.arg(stripe_payment_id))
+  AND (sqlc.arg(stripe_customer_id)::text = '' OR stripe_customer_id = sqlc.arg(stripe_customer_id))
+  AND (sqlc.narg(cursor_created_at)::timestamp IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.arg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CountPayments :one
+SELECT COUNT(*) FROM payments
+WHERE (sqlc.arg(user_id)::text = '' OR user_id = sqlc.arg(user_id))
+  AND (COALESCE(cardinality(sqlc.arg(statuses)::text[]), 0) = 0 OR status = ANY(sqlc.arg(statuses)::text[]))
+  AND (sqlc.narg(min_amount)::bigint IS NULL OR amount >= sqlc.narg(min_amount))
+  AND (sqlc.narg(max_amount)::bigint IS NULL OR amount <= sqlc.narg(max_amount))
+  AND (sqlc.arg(currency)::text = '' OR currency = sqlc.arg(currency))
+  AND (sqlc.narg(created_from)::timestamp IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_to)::timestamp IS NULL OR created_at < sqlc.narg(created_to))
+  AND (sqlc.arg(stripe_payment_id)::text = '' OR stripe_payment_id = sqlc.arg(stripe_payment_id))
+  AND (sqlc.arg(stripe_customer_id)::text = '' OR stripe_customer_id = sqlc.arg(stripe_customer_id));
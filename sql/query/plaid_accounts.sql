@@ -0,0 +1,57 @@
+-- name: UpsertPlaidAccount :exec
+INSERT INTO plaid_accounts (account_id, item_id, user_id, name, official_name, mask, type, subtype)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (account_id) DO UPDATE
+SET name = EXCLUDED.name,
+    official_name = EXCLUDED.official_name,
+    mask = EXCLUDED.mask,
+    type = EXCLUDED.type,
+    subtype = EXCLUDED.subtype,
+    updated_at = NOW();
+
+-- name: ListPlaidAccountsByUserID :many
+SELECT a.account_id, a.item_id, a.user_id, a.name, a.official_name, a.mask, a.type, a.subtype,
+       a.is_default, a.stripe_payment_method_id, a.created_at, a.updated_at, i.institution_name, i.status AS item_status
+FROM plaid_accounts a
+JOIN plaid_items i ON i.item_id = a.item_id
+WHERE a.user_id = $1
+ORDER BY a.is_default DESC, a.created_at, a.account_id;
+
+-- name: GetDefaultPlaidTokenByUserID :one
+SELECT i.access_token_ciphertext, i.access_token_data_key, i.access_token_key_id, a.account_id, a.item_id,
+       a.stripe_payment_method_id
+FROM plaid_accounts a
+JOIN plaid_items i ON i.item_id = a.item_id
+WHERE a.user_id = $1 AND a.is_default;
+
+-- name: GetPlaidTokenByAccountID :one
+SELECT i.access_token_ciphertext, i.access_token_data_key, i.access_token_key_id, a.account_id, a.item_id,
+       a.stripe_payment_method_id
+FROM plaid_accounts a
+JOIN plaid_items i ON i.item_id = a.item_id
+WHERE a.user_id = $1 AND a.account_id = $2;
+
+-- name: ClearDefaultPlaidAccount :exec
+UPDATE plaid_accounts
+SET is_default = FALSE,
+    updated_at = NOW()
+WHERE user_id = $1 AND is_default;
+
+-- name: SetDefaultPlaidAccount :execrows
+UPDATE plaid_accounts
+SET is_default = TRUE,
+    updated_at = NOW()
+WHERE user_id = $1 AND account_id = $2;
+
+-- name: SetDefaultPlaidAccountIfNone :exec
+UPDATE plaid_accounts
+SET is_default = TRUE,
+    updated_at = NOW()
+WHERE plaid_accounts.account_id = $1
+  AND NOT EXISTS (SELECT 1 FROM plaid_accounts p WHERE p.user_id = $2 AND p.is_default);
+
+-- name: SetPlaidAccountPaymentMethod :execrows
+UPDATE plaid_accounts
+SET stripe_payment_method_id = $3,
+    updated_at = NOW()
+WHERE user_id = $1 AND account_id = $2;
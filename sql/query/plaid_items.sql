@@ -0,0 +1,69 @@
+-- name: UpsertPlaidItem :exec
+INSERT INTO plaid_items (
+    item_id, user_id, access_token_ciphertext, access_token_data_key,
+    access_token_key_id, institution_id, institution_name
+) VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (item_id) DO UPDATE
+SET access_token_ciphertext = EXCLUDED.access_token_ciphertext,
+    access_token_data_key = EXCLUDED.access_token_data_key,
+    access_token_key_id = EXCLUDED.access_token_key_id,
+    institution_id = EXCLUDED.institution_id,
+    institution_name = EXCLUDED.institution_name,
+    status = 'healthy',
+    error_code = NULL,
+    error_message = NULL,
+    updated_at = NOW();
+
+-- name: GetPlaidItemByItemID :one
+SELECT * FROM plaid_items
+WHERE item_id = $1;
+
+-- name: DeletePlaidItem :execrows
+DELETE FROM plaid_items
+WHERE item_id = $1 AND user_id = $2;
+
+-- name: UpdatePlaidItemStatus :exec
+UPDATE plaid_items
+SET status = $2,
+    error_code = $3,
+    error_message = $4,
+    last_webhook_code = $5,
+    updated_at = NOW()
+WHERE item_id = $1;
+
+-- name: UpdatePlaidItemConsentExpiration :exec
+UPDATE plaid_items
+SET status = CASE WHEN status = 'healthy' THEN 'pending_expiration' ELSE status END,
+    consent_expires_at = $2,
+    last_webhook_code = 'PENDING_EXPIRATION',
+    updated_at = NOW()
+WHERE item_id = $1;
+
+-- name: UpdatePlaidItemVerification :exec
+UPDATE plaid_items
+SET verification_status = $2,
+    verification_account_id = $3,
+    last_webhook_code = $4,
+    updated_at = NOW()
+WHERE item_id = $1;
+
+-- name: UpdatePlaidItemAuthUpdated :exec
+UPDATE plaid_items
+SET auth_updated_at = NOW(),
+    last_webhook_code = 'DEFAULT_UPDATE',
+    updated_at = NOW()
+WHERE item_id = $1;
+
+-- name: ListPlaidItemsForKeyRotation :many
+SELECT item_id, access_token_data_key, access_token_key_id
+FROM plaid_items
+WHERE access_token_key_id != $1
+ORDER BY item_id
+LIMIT $2
+FOR UPDATE SKIP LOCKED;
+
+-- name: RewrapPlaidItemDataKey :exec
+UPDATE plaid_items
+SET access_token_data_key = $2,
+    access_token_key_id = $3
+WHERE item_id = $1;
@@ -0,0 +1,10 @@
+-- name: GetPlaidWebhookKey :one
+SELECT * FROM plaid_webhook_keys
+WHERE kid = $1;
+
+-- name: UpsertPlaidWebhookKey :exec
+INSERT INTO plaid_webhook_keys (kid, alg, crv, kty, key_use, x, y, created_at, expired_at, fetched_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+ON CONFLICT (kid) DO UPDATE
+SET expired_at = EXCLUDED.expired_at,
+    fetched_at = EXCLUDED.fetched_at;
@@ -0,0 +1,33 @@
+-- name: InsertReconciliationRun :exec
+INSERT INTO reconciliation_runs (
+    id, workflow_id, period_start, period_end
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (id) DO NOTHING;
+
+-- name: CompleteReconciliationRun :exec
+UPDATE reconciliation_runs
+SET
+    status = 'completed',
+    discrepancies = $2,
+    report = $3,
+    error = NULL,
+    completed_at = NOW()
+WHERE id = $1;
+
+-- name: FailReconciliationRun :exec
+UPDATE reconciliation_runs
+SET
+    status = 'failed',
+    error = $2,
+    completed_at = NOW()
+WHERE id = $1;
+
+-- name: GetReconciliationRunByID :one
+SELECT * FROM reconciliation_runs WHERE id = $1;
+
+-- name: ListReconciliationRuns :many
+SELECT * FROM reconciliation_runs
+ORDER BY started_at DESC
+LIMIT $1;
@@ -0,0 +1,36 @@
+-- name: InsertRecurringPayment :one
+INSERT INTO recurring_payments (
+    id, user_id, stripe_customer_id, payment_method_id, plaid_account_id,
+    plaid_item_id, amount, currency, description, billing_interval,
+    anchor_at, end_at, status, schedule_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+)
+RETURNING *;
+
+-- name: GetRecurringPaymentByID :one
+SELECT * FROM recurring_payments WHERE id = $1;
+
+-- name: ListRecurringPaymentsByUserID :many
+SELECT * FROM recurring_payments
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: UpdateRecurringPayment :one
+UPDATE recurring_payments
+SET amount = $2,
+    payment_method_id = $3,
+    description = $4,
+    end_at = $5,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateRecurringPaymentStatus :exec
+UPDATE recurring_payments
+SET status = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteRecurringPayment :exec
+DELETE FROM recurring_payments WHERE id = $1;
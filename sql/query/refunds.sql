@@ -0,0 +1,34 @@
+-- name: InsertRefund :exec
+INSERT INTO refunds (
+    id, payment_id, amount, currency, reason, status
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+);
+
+-- name: UpdateRefundResult :exec
+UPDATE refunds
+SET
+    stripe_refund_id = $2,
+    status = $3,
+    failure_reason = $4,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetRefundByID :one
+SELECT * FROM refunds WHERE id = $1;
+
+-- name: GetRefundByStripeRefundID :one
+SELECT * FROM refunds WHERE stripe_refund_id = $1;
+
+-- name: GetRefundsByPaymentID :many
+SELECT * FROM refunds WHERE payment_id = $1 ORDER BY created_at DESC;
+
+-- name: GetRefundedAmountByPaymentID :one
+SELECT COALESCE(SUM(amount), 0)::BIGINT AS refunded
+FROM refunds
+WHERE payment_id = $1 AND status IN ('pending', 'succeeded');
+
+-- name: GetSucceededRefundAmountByPaymentID :one
+SELECT COALESCE(SUM(amount), 0)::BIGINT AS refunded
+FROM refunds
+WHERE payment_id = $1 AND status = 'succeeded';
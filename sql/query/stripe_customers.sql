@@ -36,3 +36,10 @@ WHERE user_id = $1;
 -- name: DeleteStripeCustomer :exec
 DELETE FROM stripe_customers
 WHERE user_id = $1;
+
+-- name: ClearStripeCustomerDefaultPayment :exec
+UPDATE stripe_customers
+SET
+    default_payment_id = NULL,
+    updated_at = NOW()
+WHERE user_id = $1 AND default_payment_id = $2;
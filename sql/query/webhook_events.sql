@@ -0,0 +1,53 @@
+-- name: InsertWebhookEvent :one
+INSERT INTO webhook_events (
+    provider, event_id, event_type, payload
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (provider, event_id) DO NOTHING
+RETURNING *;
+
+-- name: GetWebhookEventByID :one
+SELECT * FROM webhook_events WHERE id = $1;
+
+-- name: GetWebhookEventByProviderEventID :one
+SELECT * FROM webhook_events WHERE provider = $1 AND event_id = $2;
+
+-- name: ListWebhookEventsByStatus :many
+SELECT * FROM webhook_events
+WHERE status = $1
+ORDER BY received_at ASC
+LIMIT $2;
+
+-- name: MarkWebhookEventProcessing :exec
+UPDATE webhook_events
+SET
+    status = 'processing',
+    attempts = attempts + 1,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkWebhookEventProcessed :exec
+UPDATE webhook_events
+SET
+    status = 'processed',
+    last_error = NULL,
+    processed_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkWebhookEventFailed :exec
+UPDATE webhook_events
+SET
+    status = 'failed',
+    last_error = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: ResetWebhookEvent :exec
+UPDATE webhook_events
+SET
+    status = 'received',
+    last_error = NULL,
+    updated_at = NOW()
+WHERE id = $1;
@@ -1,13 +1,48 @@
 -- sql/schema.sql
 
-CREATE TABLE plaid_tokens (
-    user_id TEXT PRIMARY KEY,
-    access_token TEXT NOT NULL,
-    account_id TEXT NOT NULL,
-    item_id TEXT NOT NULL,
-    created_at TIMESTAMP DEFAULT NOW()
+-- One row per Plaid Item (a login at one institution); a user may link several
+CREATE TABLE plaid_items (
+    item_id                  TEXT PRIMARY KEY,
+    user_id                  TEXT NOT NULL,
+    access_token_ciphertext  BYTEA NOT NULL, -- AES-GCM under a per-row data key, nonce prefixed
+    access_token_data_key    BYTEA NOT NULL, -- data key wrapped with the key-encryption key
+    access_token_key_id      TEXT NOT NULL, -- ID of the key-encryption key, see PLAID_TOKEN_KEYS
+    institution_id           TEXT,
+    institution_name         TEXT,
+    status                   TEXT NOT NULL DEFAULT 'healthy', -- healthy, login_required, pending_expiration, permission_revoked, error
+    error_code               TEXT, -- Plaid error code from the last ITEM ERROR webhook
+    error_message            TEXT,
+    consent_expires_at       TIMESTAMP, -- from PENDING_EXPIRATION
+    verification_status      TEXT, -- automatically_verified, verification_expired
+    verification_account_id  TEXT, -- account the verification webhook was about
+    auth_updated_at          TIMESTAMP, -- last AUTH DEFAULT_UPDATE, account numbers changed
+    last_webhook_code        TEXT,
+    created_at               TIMESTAMP DEFAULT NOW(),
+    updated_at               TIMESTAMP DEFAULT NOW()
 );
 
+CREATE INDEX plaid_items_user_id_idx ON plaid_items (user_id);
+CREATE INDEX plaid_items_token_key_id_idx ON plaid_items (access_token_key_id);
+
+-- Every account AccountsGet returned for an Item; the default one funds payments unless another is picked
+CREATE TABLE plaid_accounts (
+    account_id          TEXT PRIMARY KEY,
+    item_id             TEXT NOT NULL REFERENCES plaid_items(item_id) ON DELETE CASCADE,
+    user_id             TEXT NOT NULL,
+    name                TEXT NOT NULL,
+    official_name       TEXT,
+    mask                TEXT, -- last 2-4 digits of the account number
+    type                TEXT NOT NULL, -- depository, credit, loan, ...
+    subtype             TEXT, -- checking, savings, ...
+    is_default          BOOLEAN NOT NULL DEFAULT FALSE,
+    stripe_payment_method_id TEXT, -- Stripe payment method that debits this account
+    created_at          TIMESTAMP DEFAULT NOW(),
+    updated_at          TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX plaid_accounts_item_id_idx ON plaid_accounts (item_id);
+CREATE UNIQUE INDEX plaid_accounts_user_default_idx ON plaid_accounts (user_id) WHERE is_default;
+
 CREATE TABLE stripe_customers (
     user_id              TEXT PRIMARY KEY,
     stripe_customer_id   TEXT NOT NULL,
@@ -31,9 +66,246 @@ CREATE TABLE payments (
     plaid_item_id       TEXT,
     stripe_customer_id  TEXT,
     stripe_payment_id   TEXT,
+    status              TEXT NOT NULL DEFAULT 'created', -- see domain.PaymentStatus for the allowed transitions
+    workflow_id         TEXT, -- owning PaymentWorkflow, signaled by webhooks
+    payment_method_id   TEXT, -- Stripe us_bank_account payment method debited
+    retry_of_payment_id UUID, -- original payment when this is a retry after an ACH return
+    attempt             INT NOT NULL DEFAULT 1, -- 1 for the original debit, 2+ for retries
+    recurring_payment_id UUID, -- recurring_payments row whose schedule started this payment
+    execute_at          TIMESTAMP, -- scheduled payments are charged on this date
+    expected_settlement_at TIMESTAMP, -- from the banking calendar, once the debit was submitted
+    balance_check_result TEXT, -- passed, insufficient, unsupported, unavailable; NULL when not checked
+    balance_available   BIGINT, -- in cents, Plaid balance seen before charging
+    balance_required    BIGINT, -- in cents, amount plus the configured buffer
+    balance_checked_at  TIMESTAMP,
+    dispute_status      TEXT, -- status of the latest Stripe dispute, NULL when never disputed
+    created_at          TIMESTAMP DEFAULT NOW(),
+    updated_at          TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT payments_status_check CHECK (status IN ('scheduled', 'created', 'on_hold', 'processing', 'succeeded', 'failed', 'returned', 'refunded', 'partially_refunded', 'canceled', 'disputed'))
+);
+
+CREATE INDEX payments_stripe_payment_id_idx ON payments (stripe_payment_id);
+-- Listing pages through payments newest first, per user, per status or across all of them
+CREATE INDEX payments_user_id_idx ON payments (user_id, created_at DESC, id DESC);
+CREATE INDEX payments_status_idx ON payments (status, created_at DESC, id DESC);
+CREATE INDEX payments_created_at_idx ON payments (created_at DESC, id DESC);
+CREATE INDEX payments_stripe_customer_id_idx ON payments (stripe_customer_id);
+
+-- Payments repeated on a calendar interval, each cycle started by a Temporal Schedule
+CREATE TABLE recurring_payments (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id            TEXT NOT NULL,
+    stripe_customer_id TEXT NOT NULL,
+    payment_method_id  TEXT NOT NULL,
+    plaid_account_id   TEXT,
+    plaid_item_id      TEXT,
+    amount             BIGINT NOT NULL, -- in cents
+    currency           TEXT NOT NULL DEFAULT 'usd',
+    description        TEXT NOT NULL DEFAULT '',
+    billing_interval   TEXT NOT NULL, -- daily, weekly, monthly, yearly
+    anchor_at          TIMESTAMP NOT NULL, -- first charge, later ones repeat its calendar position (UTC)
+    end_at             TIMESTAMP, -- no charges after this time
+    status             TEXT NOT NULL DEFAULT 'active', -- active, paused, canceled
+    schedule_id        TEXT NOT NULL,
+    created_at         TIMESTAMP DEFAULT NOW(),
+    updated_at         TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX recurring_payments_user_id_idx ON recurring_payments (user_id);
+
+-- Idempotency-Key values sent with POST /payments, scoped to the user. The first
+-- response is stored so an identical retry gets the same answer instead of a second charge.
+CREATE TABLE idempotency_keys (
+    user_id         TEXT NOT NULL,
+    idempotency_key TEXT NOT NULL,
+    request_hash    TEXT NOT NULL, -- hex SHA-256 of the request body
+    payment_id      UUID NOT NULL, -- payment the key created, fixed when the key is first seen
+    response_status INT, -- NULL while the first request is still in flight
+    response_body   BYTEA,
+    created_at      TIMESTAMP DEFAULT NOW(),
+    updated_at      TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (user_id, idempotency_key)
+);
+
+-- Every status a payment has been in, written in the same transaction as the status change
+CREATE TABLE payment_status_history (
+    id          BIGSERIAL PRIMARY KEY,
+    payment_id  UUID NOT NULL REFERENCES payments(id),
+    from_status TEXT, -- NULL for the initial status
+    to_status   TEXT NOT NULL,
+    reason      TEXT NOT NULL DEFAULT '', -- what caused the change, e.g. a Stripe event ID
+    created_at  TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX payment_status_history_payment_id_idx ON payment_status_history (payment_id, id);
+CREATE INDEX payment_status_history_to_status_idx ON payment_status_history (to_status, created_at);
+
+
+
+CREATE TABLE refunds (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id          UUID NOT NULL REFERENCES payments(id),
+    amount              BIGINT NOT NULL, -- in cents
+    currency            TEXT NOT NULL DEFAULT 'usd',
+    reason              TEXT, -- duplicate, fraudulent, requested_by_customer
+    stripe_refund_id    TEXT,
     status              TEXT NOT NULL DEFAULT 'pending', -- pending, succeeded, failed, canceled
+    failure_reason      TEXT,
+    created_at          TIMESTAMP DEFAULT NOW(),
+    updated_at          TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX refunds_payment_id_idx ON refunds (payment_id);
+CREATE UNIQUE INDEX refunds_stripe_refund_id_idx ON refunds (stripe_refund_id);
+
+-- ACH debits returned by the customer's bank, as reported by Stripe
+CREATE TABLE ach_returns (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id          UUID NOT NULL REFERENCES payments(id),
+    stripe_charge_id    TEXT NOT NULL UNIQUE, -- a charge is returned at most once
+    stripe_event_id     TEXT NOT NULL,
+    return_code         TEXT NOT NULL, -- NACHA code, e.g. R01; empty when Stripe's failure code is unknown
+    reason              TEXT NOT NULL,
+    stripe_failure_code TEXT NOT NULL, -- e.g. insufficient_funds, account_closed
+    retryable           BOOLEAN NOT NULL,
+    action              TEXT NOT NULL DEFAULT 'pending', -- pending, retry_scheduled, retry_limit_reached, payment_method_disabled, none
+    retry_payment_id    UUID, -- payment created by the retry
     created_at          TIMESTAMP DEFAULT NOW(),
     updated_at          TIMESTAMP DEFAULT NOW()
 );
 
+CREATE INDEX ach_returns_payment_id_idx ON ach_returns (payment_id);
+
+-- Disputes (chargebacks) opened against a payment's charge, as reported by Stripe
+CREATE TABLE disputes (
+    id                    UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id            UUID NOT NULL REFERENCES payments(id),
+    stripe_dispute_id     TEXT NOT NULL UNIQUE,
+    stripe_charge_id      TEXT NOT NULL,
+    amount                BIGINT NOT NULL, -- in cents
+    currency              TEXT NOT NULL,
+    reason                TEXT NOT NULL, -- Stripe reason, e.g. fraudulent, product_not_received
+    status                TEXT NOT NULL, -- needs_response, under_review, won, lost, warning_*
+    evidence_due_by       TIMESTAMP, -- NULL when the bank doesn't accept a response
+    evidence_submitted_at TIMESTAMP,
+    last_alert_at         TIMESTAMP, -- last evidence deadline alert raised
+    stripe_event_id       TEXT NOT NULL, -- last event applied
+    created_at            TIMESTAMP DEFAULT NOW(),
+    updated_at            TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX disputes_payment_id_idx ON disputes (payment_id);
+CREATE INDEX disputes_status_idx ON disputes (status, evidence_due_by);
+
+CREATE TABLE webhook_events (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider            TEXT NOT NULL, -- stripe, plaid
+    event_id            TEXT NOT NULL, -- provider event ID, or body hash when the provider has none
+    event_type          TEXT NOT NULL,
+    payload             BYTEA NOT NULL, -- raw request body
+    status              TEXT NOT NULL DEFAULT 'received', -- received, processing, processed, failed
+    attempts            INT NOT NULL DEFAULT 0,
+    last_error          TEXT,
+    received_at         TIMESTAMP DEFAULT NOW(),
+    processed_at        TIMESTAMP,
+    updated_at          TIMESTAMP DEFAULT NOW(),
+    UNIQUE (provider, event_id)
+);
+
+CREATE INDEX webhook_events_status_idx ON webhook_events (status, received_at);
+
+-- Comparisons of payments with Stripe balance transactions and payouts, one per period
+CREATE TABLE reconciliation_runs (
+    id                  UUID PRIMARY KEY,
+    workflow_id         TEXT NOT NULL,
+    period_start        TIMESTAMP NOT NULL,
+    period_end          TIMESTAMP NOT NULL, -- exclusive
+    status              TEXT NOT NULL DEFAULT 'running', -- running, completed, failed
+    discrepancies       INT NOT NULL DEFAULT 0,
+    report              BYTEA, -- JSON encoded report, set once the run completed
+    error               TEXT, -- set when the run failed
+    started_at          TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at        TIMESTAMP
+);
+
+CREATE INDEX reconciliation_runs_started_at_idx ON reconciliation_runs (started_at);
+
+-- Plaid webhook verification keys (JWKs), cached by kid across restarts and instances
+CREATE TABLE plaid_webhook_keys (
+    kid                 TEXT PRIMARY KEY,
+    alg                 TEXT NOT NULL, -- ES256
+    crv                 TEXT NOT NULL, -- P-256
+    kty                 TEXT NOT NULL, -- EC
+    key_use             TEXT NOT NULL, -- sig
+    x                   TEXT NOT NULL, -- base64url encoded curve point
+    y                   TEXT NOT NULL,
+    created_at          BIGINT NOT NULL, -- unix time, as reported by Plaid
+    expired_at          BIGINT, -- unix time, set once Plaid rotates the key out
+    fetched_at          TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Credentials of internal backends calling the API, the key itself is only shown once on creation
+CREATE TABLE api_keys (
+    id                  UUID PRIMARY KEY,
+    name                TEXT NOT NULL, -- the backend using the key
+    prefix              TEXT NOT NULL UNIQUE, -- public part of the key, used to look it up
+    secret_hash         TEXT NOT NULL, -- hex SHA-256 of the full key
+    scopes              TEXT[] NOT NULL, -- payments:read, payments:write, refunds:write, admin
+    created_by          TEXT NOT NULL, -- who created the key
+    last_used_at        TIMESTAMP, -- updated at most once a minute
+    revoked_at          TIMESTAMP,
+    created_at          TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Double-entry ledger. Accounts are created on first use; customer accounts carry
+-- the user ID, system accounts (stripe_balance, processing_fees) an empty one.
+CREATE TABLE ledger_accounts (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code                TEXT NOT NULL, -- customer_receivable, customer_payments, customer_disputes, stripe_balance, processing_fees
+    user_id             TEXT NOT NULL DEFAULT '',
+    currency            TEXT NOT NULL,
+    normal_balance      TEXT NOT NULL, -- debit or credit, the side that increases the balance
+    created_at          TIMESTAMP DEFAULT NOW(),
+    UNIQUE (code, user_id, currency)
+);
+
+CREATE INDEX ledger_accounts_user_id_idx ON ledger_accounts (user_id);
+
+CREATE TABLE journal_entries (
+    id                  UUID PRIMARY KEY,
+    entry_type          TEXT NOT NULL, -- charge, settlement, fee, refund, return, dispute, ...
+    reference           TEXT NOT NULL UNIQUE, -- what caused the entry, posting the same reference again is a no-op
+    payment_id          UUID REFERENCES payments(id),
+    currency            TEXT NOT NULL,
+    description         TEXT NOT NULL DEFAULT '',
+    effective_at        TIMESTAMP NOT NULL, -- when the money moved
+    created_at          TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX journal_entries_payment_id_idx ON journal_entries (payment_id);
+
+-- Lines of a journal entry, its debits and credits always add up to the same amount
+CREATE TABLE journal_lines (
+    id                  BIGSERIAL PRIMARY KEY,
+    entry_id            UUID NOT NULL REFERENCES journal_entries(id),
+    account_id          UUID NOT NULL REFERENCES ledger_accounts(id),
+    direction           TEXT NOT NULL, -- debit or credit
+    amount              BIGINT NOT NULL, -- in cents
+    CONSTRAINT journal_lines_amount_check CHECK (amount > 0)
+);
+
+CREATE INDEX journal_lines_entry_id_idx ON journal_lines (entry_id);
+CREATE INDEX journal_lines_account_id_idx ON journal_lines (account_id);
+
+-- Posted entries are immutable, corrections are posted as new entries
+CREATE FUNCTION reject_ledger_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION '% rows are immutable', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER journal_entries_immutable BEFORE UPDATE OR DELETE ON journal_entries
+    FOR EACH ROW EXECUTE FUNCTION reject_ledger_change();
 
+CREATE TRIGGER journal_lines_immutable BEFORE UPDATE OR DELETE ON journal_lines
+    FOR EACH ROW EXECUTE FUNCTION reject_ledger_change();